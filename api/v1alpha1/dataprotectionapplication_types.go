@@ -33,6 +33,24 @@ const ConditionReconciled = "Reconciled"
 const ReconciledReasonComplete = "Complete"
 const ReconciledReasonError = "Error"
 const ReconcileCompleteMessage = "Reconcile complete"
+const ConditionConfigMapsValid = "ConfigMapsValid"
+const ConfigMapsValidReasonValid = "Valid"
+const ConfigMapsValidReasonInvalid = "Invalid"
+const ConditionCredentialsRotated = "CredentialsRotated"
+const CredentialsRotatedReasonRolloutTriggered = "RolloutTriggered"
+const ConditionCredentialsValid = "CredentialsValid"
+const CredentialsValidReasonValid = "Valid"
+const CredentialsValidReasonInvalid = "Invalid"
+const CredentialsValidReasonCheckFailed = "CheckFailed"
+const CredentialsValidReasonNotChecked = "NotChecked"
+const ConditionCredentialsPolicyCompliant = "CredentialsPolicyCompliant"
+const CredentialsPolicyCompliantReasonCompliant = "Compliant"
+const CredentialsPolicyCompliantReasonStaticKeyTooOld = "StaticKeyTooOld"
+const CredentialsPolicyCompliantReasonTokenRefreshFailing = "TokenRefreshFailing"
+const ConditionSTSCredentialsReady = "STSCredentialsReady"
+const STSCredentialsReadyReasonReady = "Ready"
+const STSCredentialsReadyReasonRestored = "Restored"
+const STSCredentialsReadyReasonTokenFileMissing = "TokenFileMissing"
 
 const OadpOperatorLabel = "openshift.io/oadp"
 
@@ -52,6 +70,71 @@ const DefaultPluginHypershift DefaultPlugin = "hypershift"
 type CustomPlugin struct {
 	Name  string `json:"name"`
 	Image string `json:"image"`
+	// digest pins the plugin image to a manifest digest, sha256:<hex>. The image is deployed by digest, a digest in
+	// image must match it.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	Digest string `json:"digest,omitempty"`
+	// signature verifies the cosign signature of the plugin image offline against a public key. It requires digest.
+	// +optional
+	Signature *CustomPluginSignature `json:"signature,omitempty"`
+	// requiredEnv lists the environment variables the plugin reads, which must be set in the velero container
+	// +optional
+	RequiredEnv []string `json:"requiredEnv,omitempty"`
+	// requiredVolumeMounts lists the paths the plugin reads, which must be mounted in the velero container
+	// +optional
+	RequiredVolumeMounts []string `json:"requiredVolumeMounts,omitempty"`
+	// veleroVersions is the range of Velero versions the plugin supports, such as ">=1.14.0 <1.16.0". It is not
+	// checked when the tag of the velero image is not a version, such as an image referenced by digest.
+	// +optional
+	VeleroVersions string `json:"veleroVersions,omitempty"`
+	PluginConfig   `json:",inline"`
+}
+
+// PluginConfig defines the configuration of a plugin. The plugin runs in the velero container, where the env, volumes
+// and volumeMounts are added, the resources and imagePullPolicy apply to the plugin init container.
+type PluginConfig struct {
+	// env defines the environment variables read by the plugin, added to the velero container
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// volumes defines the ConfigMaps, Secrets and empty directories used by the plugin, added to the velero pod
+	// +optional
+	Volumes []PluginVolume `json:"volumes,omitempty"`
+	// volumeMounts defines the volumes mounted for the plugin in the velero container
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// resources defines the resources of the plugin init container, the velero container resources by default
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// imagePullPolicy of the plugin init container, spec.imagePullPolicy by default
+	// +optional
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy *corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+}
+
+// PluginVolume defines a volume of a plugin, with exactly one of configMap, secret and emptyDir
+type PluginVolume struct {
+	// name of the volume, referenced by volumeMounts
+	Name string `json:"name"`
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+	// +optional
+	Secret *corev1.SecretVolumeSource `json:"secret,omitempty"`
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
+// CustomPluginSignature defines a cosign signature of a plugin image, as printed by cosign download signature, so it
+// is verified without access to the registry
+type CustomPluginSignature struct {
+	// publicKey is the PEM encoded ECDSA, RSA or Ed25519 public key of the signer
+	PublicKey string `json:"publicKey"`
+	// payload is the base64 encoded cosign simple signing payload naming the signed image digest
+	// +optional
+	Payload string `json:"payload,omitempty"`
+	// signature is the base64 encoded signature of the payload
+	// +optional
+	Signature string `json:"signature,omitempty"`
 }
 
 type LogFormat string
@@ -281,6 +364,9 @@ type VeleroConfig struct {
 	// +optional
 	FeatureFlags   []string        `json:"featureFlags,omitempty"`
 	DefaultPlugins []DefaultPlugin `json:"defaultPlugins,omitempty"`
+	// defaultPluginConfigs defines the configuration of the default plugins, by plugin name
+	// +optional
+	DefaultPluginConfigs map[DefaultPlugin]PluginConfig `json:"defaultPluginConfigs,omitempty"`
 	// customPlugins defines the custom plugin to be installed with Velero
 	// +optional
 	CustomPlugins []CustomPlugin `json:"customPlugins,omitempty"`
@@ -340,6 +426,25 @@ type VeleroConfig struct {
 	// LoadAffinityConfig is the config for data path load affinity.
 	// +optional
 	LoadAffinityConfig []*LoadAffinity `json:"loadAffinity,omitempty"`
+	// highAvailability configures the replacement of the Velero server pod of a lost node
+	// +optional
+	HighAvailability *VeleroHighAvailability `json:"highAvailability,omitempty"`
+}
+
+// VeleroHighAvailability defines the high availability configuration for the Velero server.
+// Velero does not support running more than one active server, so the Velero Deployment keeps
+// a single replica with the Recreate strategy. When the node running the Velero server is deleted
+// or has the node.kubernetes.io/out-of-service taint, the operator deletes the stranded pod so the
+// Velero Deployment schedules a replacement. A node that is only NotReady is reported in the
+// status, since the Velero server may still be running on it.
+type VeleroHighAvailability struct {
+	// enabled defines whether the Velero server pod of a lost node is replaced
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// failoverTimeout is how long the node running the active Velero server must be NotReady
+	// before it is reported as unreachable. Default is 2m.
+	// +optional
+	FailoverTimeout *metav1.Duration `json:"failoverTimeout,omitempty"`
 }
 
 // PodConfig defines the pod configuration options
@@ -520,6 +625,61 @@ type ApplicationConfig struct {
 	//  - "<repository type>" : Either "kopia" or "restic".
 	// +optional
 	RepositoryMaintenance map[string]RepositoryMaintenanceConfig `json:"repositoryMaintenance,omitempty"`
+
+	// resourceAutoSizing enables computing the Velero and NodeAgent resource requirements from the cluster
+	// +optional
+	ResourceAutoSizing *ResourceAutoSizing `json:"resourceAutoSizing,omitempty"`
+
+	// credentialsValidation enables checking the backup location credentials with the cloud provider during the
+	// DPA reconcile. The result is reported in the CredentialsValid condition.
+	// +optional
+	CredentialsValidation *CredentialsValidation `json:"credentialsValidation,omitempty"`
+
+	// credentialsPolicy checks the backup location credentials against a maximum static key age and for short-lived
+	// token refresh failures. The result is reported in the CredentialsPolicyCompliant condition.
+	// +optional
+	CredentialsPolicy *CredentialsPolicy `json:"credentialsPolicy,omitempty"`
+}
+
+// CredentialsValidation defines the configuration for checking the backup location credentials with the cloud provider.
+// AWS credentials are checked with STS, GCP and Azure credentials by requesting an access token, and Azure storage
+// account keys by reading the storage account information. Locations using a credentialSource or an S3 compatible
+// storage are not checked.
+type CredentialsValidation struct {
+	// interval is the minimum time between two checks of unchanged credentials, defaults to 1h and must be at least 5m.
+	// Changed credentials or backup locations are checked in the next reconcile.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// CredentialsPolicy defines the policy the backup location credentials are checked against. Locations using a
+// credentialSource are not checked.
+type CredentialsPolicy struct {
+	// staticKeyMaxAge is the maximum age of static keys, such as AWS access keys, GCP service account keys and Azure
+	// client secrets. The age is counted from the last change of the credentials secret seen by the operator, or from
+	// its creation. Unset to not check the age.
+	// +optional
+	StaticKeyMaxAge *metav1.Duration `json:"staticKeyMaxAge,omitempty"`
+}
+
+// ResourceAutoSizingMode defines what the operator does with the computed resource requirements
+type ResourceAutoSizingMode string
+
+const (
+	// ResourceAutoSizingRecommend only reports the computed resource requirements in the DPA status
+	ResourceAutoSizingRecommend ResourceAutoSizingMode = "Recommend"
+	// ResourceAutoSizingApply reports and applies the computed resource requirements
+	ResourceAutoSizingApply ResourceAutoSizingMode = "Apply"
+)
+
+// ResourceAutoSizing defines the configuration for computing the Velero and NodeAgent resource requirements.
+// The requirements are computed from the number and size of persistent volumes in the cluster, the Kopia
+// cache limit, the data path load concurrency and OOMKilled restarts observed on the pods.
+type ResourceAutoSizing struct {
+	// mode defines whether the computed resource requirements are only reported in the DPA status (Recommend),
+	// or also applied to the Velero and NodeAgent pods which do not set podConfig.resourceAllocations (Apply)
+	// +kubebuilder:validation:Enum=Recommend;Apply
+	Mode ResourceAutoSizingMode `json:"mode"`
 }
 
 // CloudStorageLocation defines BackupStorageLocation using bucket referenced by CloudStorage CR.
@@ -564,6 +724,15 @@ type BackupLocation struct {
 	Velero *velero.BackupStorageLocationSpec `json:"velero,omitempty"`
 	// +optional
 	CloudStorage *CloudStorageLocation `json:"bucket,omitempty"`
+	// credentialSource reads the cloud credentials from an external secret manager instead of a Secret.
+	// It requires the velero configuration and backupImages set to false.
+	// +optional
+	CredentialSource *CredentialSource `json:"credentialSource,omitempty"`
+	// stsIdentity generates a dedicated short-lived credentials secret for this location, for its own AWS role or
+	// GCP/Azure federated identity, and sets it as the velero credential. It requires the velero configuration and
+	// can not be combined with velero.credential or credentialSource.
+	// +optional
+	STSIdentity *STSIdentity `json:"stsIdentity,omitempty"`
 }
 
 // SnapshotLocation defines the configuration for the DPA snapshot store
@@ -573,6 +742,75 @@ type SnapshotLocation struct {
 	// +optional
 	Name   string                             `json:"name,omitempty"`
 	Velero *velero.VolumeSnapshotLocationSpec `json:"velero"`
+	// credentialSource reads the cloud credentials from an external secret manager instead of a Secret
+	// +optional
+	CredentialSource *CredentialSource `json:"credentialSource,omitempty"`
+}
+
+// CredentialSource defines where the cloud credentials of a location are read from instead of a Secret.
+// The credentials are mounted into the Velero and node-agent pods and passed to the plugin with the
+// credentialsFile config key, so they are not stored in etcd. Exactly one source must be set.
+type CredentialSource struct {
+	// secretProviderClass mounts the credentials with the Secrets Store CSI driver
+	// +optional
+	SecretProviderClass *SecretProviderClassCredentialSource `json:"secretProviderClass,omitempty"`
+	// file mounts the credentials from a directory on the node kept up to date by an external agent,
+	// such as a Vault agent, or by a local stand-in for testing
+	// +optional
+	File *FileCredentialSource `json:"file,omitempty"`
+}
+
+// SecretProviderClassCredentialSource defines credentials mounted with the Secrets Store CSI driver
+type SecretProviderClassCredentialSource struct {
+	// name of the SecretProviderClass in the DPA namespace
+	Name string `json:"name"`
+	// fileName is the name of the file the SecretProviderClass writes the credentials to
+	FileName string `json:"fileName"`
+}
+
+// FileCredentialSource defines credentials read from a directory on the node
+type FileCredentialSource struct {
+	// path is the directory on the node containing the credentials file. It must be under
+	// /var/run/oadp/credentials, other node directories are not mounted.
+	Path string `json:"path"`
+	// fileName is the name of the credentials file in path
+	FileName string `json:"fileName"`
+}
+
+// STSIdentity defines the cloud identity a backup location assumes with the projected service account token.
+// Exactly one identity must be set, matching the provider of the location.
+type STSIdentity struct {
+	// roleARN is the AWS IAM role assumed with the web identity token
+	// +optional
+	RoleARN string `json:"roleARN,omitempty"`
+	// gcp is the GCP service account impersonated with Workload Identity Federation
+	// +optional
+	GCP *GCPWorkloadIdentity `json:"gcp,omitempty"`
+	// azure is the Azure identity federated with the Velero service account
+	// +optional
+	Azure *AzureWorkloadIdentity `json:"azure,omitempty"`
+}
+
+// GCPWorkloadIdentity defines a GCP Workload Identity Federation identity
+type GCPWorkloadIdentity struct {
+	// serviceAccountEmail is the GCP service account impersonated by Velero
+	ServiceAccountEmail string `json:"serviceAccountEmail"`
+	// projectNumber is the number of the project of the workload identity pool
+	ProjectNumber string `json:"projectNumber"`
+	// poolID is the workload identity pool ID
+	PoolID string `json:"poolID"`
+	// providerID is the workload identity pool provider ID
+	ProviderID string `json:"providerID"`
+}
+
+// AzureWorkloadIdentity defines an Azure workload identity
+type AzureWorkloadIdentity struct {
+	// clientID is the client ID of the managed identity or application
+	ClientID string `json:"clientID"`
+	// tenantID is the Microsoft Entra ID tenant
+	TenantID string `json:"tenantID"`
+	// subscriptionID is the subscription of the storage account
+	SubscriptionID string `json:"subscriptionID"`
 }
 
 // We need to create enforcement structures for the BSL spec fields, because the Velero BSL spec
@@ -828,6 +1066,94 @@ type DataProtectionApplicationStatus struct {
 	// Conditions defines the observed state of DataProtectionApplication
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// velero reports the state of the Velero server pods
+	// +optional
+	Velero *VeleroServerStatus `json:"velero,omitempty"`
+	// resourceRecommendations are the resource requirements computed when resourceAutoSizing is enabled
+	// +optional
+	ResourceRecommendations []ResourceRecommendation `json:"resourceRecommendations,omitempty"`
+	// nodeAgentRules lists the node-agent loadConcurrency and loadAffinity rules that apply to each node.
+	// It is only reported when loadConcurrency or loadAffinity is configured.
+	// +optional
+	NodeAgentRules []NodeAgentRules `json:"nodeAgentRules,omitempty"`
+	// credentialSources reports the state of the credentials of the locations using a credentialSource
+	// +optional
+	CredentialSources []CredentialSourceStatus `json:"credentialSources,omitempty"`
+}
+
+// CredentialSourceStatus defines the observed state of the credentials of a location using a credentialSource
+type CredentialSourceStatus struct {
+	// location is the name of the BackupStorageLocation or VolumeSnapshotLocation
+	Location string `json:"location"`
+	// kind is BackupStorageLocation or VolumeSnapshotLocation
+	Kind string `json:"kind"`
+	// type is the credential source type, SecretProviderClass or File
+	Type string `json:"type"`
+	// path is the path of the credentials file in the Velero and node-agent containers
+	Path string `json:"path"`
+	// mounted defines whether the credentials are mounted in a running Velero pod
+	Mounted bool `json:"mounted"`
+	// version identifies the mounted credentials, as reported by the Secrets Store CSI driver
+	// +optional
+	Version string `json:"version,omitempty"`
+	// lastRotationTime is the last time a change of the mounted credentials version was observed
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// message describes the state of the credentials
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// NodeAgentRules defines the node-agent loadConcurrency and loadAffinity rules that apply to a node
+type NodeAgentRules struct {
+	// node is the name of the node
+	Node string `json:"node"`
+	// loadConcurrency is the number of concurrent data path loads node-agent runs on the node
+	LoadConcurrency int `json:"loadConcurrency"`
+	// loadConcurrencyRules are the indexes of the loadConcurrency.perNodeConfig rules matching the node.
+	// node-agent uses the smallest number of the matching rules, or globalConfig when no rule matches.
+	// +optional
+	LoadConcurrencyRules []int `json:"loadConcurrencyRules,omitempty"`
+	// loadAffinityRules are the indexes of the loadAffinity rules matching the node
+	// +optional
+	LoadAffinityRules []int `json:"loadAffinityRules,omitempty"`
+	// dataMoverEligible defines whether data mover pods may run on the node.
+	// node-agent only applies the first loadAffinity rule.
+	DataMoverEligible bool `json:"dataMoverEligible"`
+}
+
+// ResourceRecommendation defines the resource requirements computed for a component
+type ResourceRecommendation struct {
+	// component is the name of the component the recommendation is for, velero or node-agent
+	Component string `json:"component"`
+	// resources are the recommended resource requirements
+	Resources corev1.ResourceRequirements `json:"resources"`
+	// applied defines whether the recommended resource requirements are used by the component
+	Applied bool `json:"applied"`
+	// oomKilled is the number of OOMKilled containers observed for the component since auto sizing was enabled
+	// +optional
+	OOMKilled int32 `json:"oomKilled,omitempty"`
+	// reasons explains how the recommended resource requirements were computed
+	// +optional
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// VeleroServerStatus defines the observed state of the Velero server pods
+type VeleroServerStatus struct {
+	// activePod is the name of the Velero server pod processing backups, restores and schedules
+	// +optional
+	ActivePod string `json:"activePod,omitempty"`
+	// activeNode is the name of the node running the active Velero server pod
+	// +optional
+	ActiveNode string `json:"activeNode,omitempty"`
+	// lastFailoverTime is the last time the operator deleted the Velero server pod of a node
+	// confirmed down, so a replacement is scheduled
+	// +optional
+	LastFailoverTime *metav1.Time `json:"lastFailoverTime,omitempty"`
+	// message describes why the Velero server pod is not replaced, like its node being NotReady
+	// without being confirmed down
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
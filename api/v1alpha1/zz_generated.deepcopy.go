@@ -55,6 +55,21 @@ func (in *ApplicationConfig) DeepCopyInto(out *ApplicationConfig) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.ResourceAutoSizing != nil {
+		in, out := &in.ResourceAutoSizing, &out.ResourceAutoSizing
+		*out = new(ResourceAutoSizing)
+		**out = **in
+	}
+	if in.CredentialsValidation != nil {
+		in, out := &in.CredentialsValidation, &out.CredentialsValidation
+		*out = new(CredentialsValidation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialsPolicy != nil {
+		in, out := &in.CredentialsPolicy, &out.CredentialsPolicy
+		*out = new(CredentialsPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationConfig.
@@ -67,6 +82,21 @@ func (in *ApplicationConfig) DeepCopy() *ApplicationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureWorkloadIdentity) DeepCopyInto(out *AzureWorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureWorkloadIdentity.
+func (in *AzureWorkloadIdentity) DeepCopy() *AzureWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 	*out = *in
@@ -80,6 +110,16 @@ func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 		*out = new(CloudStorageLocation)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CredentialSource != nil {
+		in, out := &in.CredentialSource, &out.CredentialSource
+		*out = new(CredentialSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.STSIdentity != nil {
+		in, out := &in.STSIdentity, &out.STSIdentity
+		*out = new(STSIdentity)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocation.
@@ -268,9 +308,109 @@ func (in *CloudStorageStatus) DeepCopy() *CloudStorageStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialSource) DeepCopyInto(out *CredentialSource) {
+	*out = *in
+	if in.SecretProviderClass != nil {
+		in, out := &in.SecretProviderClass, &out.SecretProviderClass
+		*out = new(SecretProviderClassCredentialSource)
+		**out = **in
+	}
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileCredentialSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialSource.
+func (in *CredentialSource) DeepCopy() *CredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialSourceStatus) DeepCopyInto(out *CredentialSourceStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialSourceStatus.
+func (in *CredentialSourceStatus) DeepCopy() *CredentialSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsPolicy) DeepCopyInto(out *CredentialsPolicy) {
+	*out = *in
+	if in.StaticKeyMaxAge != nil {
+		in, out := &in.StaticKeyMaxAge, &out.StaticKeyMaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsPolicy.
+func (in *CredentialsPolicy) DeepCopy() *CredentialsPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsValidation) DeepCopyInto(out *CredentialsValidation) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsValidation.
+func (in *CredentialsValidation) DeepCopy() *CredentialsValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomPlugin) DeepCopyInto(out *CustomPlugin) {
 	*out = *in
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = new(CustomPluginSignature)
+		**out = **in
+	}
+	if in.RequiredEnv != nil {
+		in, out := &in.RequiredEnv, &out.RequiredEnv
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredVolumeMounts != nil {
+		in, out := &in.RequiredVolumeMounts, &out.RequiredVolumeMounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.PluginConfig.DeepCopyInto(&out.PluginConfig)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomPlugin.
@@ -283,6 +423,21 @@ func (in *CustomPlugin) DeepCopy() *CustomPlugin {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomPluginSignature) DeepCopyInto(out *CustomPluginSignature) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomPluginSignature.
+func (in *CustomPluginSignature) DeepCopy() *CustomPluginSignature {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomPluginSignature)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataMover) DeepCopyInto(out *DataMover) {
 	*out = *in
@@ -473,6 +628,32 @@ func (in *DataProtectionApplicationStatus) DeepCopyInto(out *DataProtectionAppli
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Velero != nil {
+		in, out := &in.Velero, &out.Velero
+		*out = new(VeleroServerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceRecommendations != nil {
+		in, out := &in.ResourceRecommendations, &out.ResourceRecommendations
+		*out = make([]ResourceRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeAgentRules != nil {
+		in, out := &in.NodeAgentRules, &out.NodeAgentRules
+		*out = make([]NodeAgentRules, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CredentialSources != nil {
+		in, out := &in.CredentialSources, &out.CredentialSources
+		*out = make([]CredentialSourceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionApplicationStatus.
@@ -659,6 +840,36 @@ func (in *Features) DeepCopy() *Features {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileCredentialSource) DeepCopyInto(out *FileCredentialSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileCredentialSource.
+func (in *FileCredentialSource) DeepCopy() *FileCredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FileCredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPWorkloadIdentity) DeepCopyInto(out *GCPWorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPWorkloadIdentity.
+func (in *GCPWorkloadIdentity) DeepCopy() *GCPWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalFlags) DeepCopyInto(out *GlobalFlags) {
 	*out = *in
@@ -904,6 +1115,31 @@ func (in *NodeAgentConfigMapSettings) DeepCopy() *NodeAgentConfigMapSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentRules) DeepCopyInto(out *NodeAgentRules) {
+	*out = *in
+	if in.LoadConcurrencyRules != nil {
+		in, out := &in.LoadConcurrencyRules, &out.LoadConcurrencyRules
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.LoadAffinityRules != nil {
+		in, out := &in.LoadAffinityRules, &out.LoadAffinityRules
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentRules.
+func (in *NodeAgentRules) DeepCopy() *NodeAgentRules {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NonAdmin) DeepCopyInto(out *NonAdmin) {
 	*out = *in
@@ -974,6 +1210,82 @@ func (in *ObjectStorageLocation) DeepCopy() *ObjectStorageLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginConfig) DeepCopyInto(out *PluginConfig) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]PluginVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullPolicy != nil {
+		in, out := &in.ImagePullPolicy, &out.ImagePullPolicy
+		*out = new(v1.PullPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginConfig.
+func (in *PluginConfig) DeepCopy() *PluginConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginVolume) DeepCopyInto(out *PluginVolume) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ConfigMapVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.SecretVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(v1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginVolume.
+func (in *PluginVolume) DeepCopy() *PluginVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodConfig) DeepCopyInto(out *PodConfig) {
 	*out = *in
@@ -1049,6 +1361,42 @@ func (in *RepositoryMaintenanceConfig) DeepCopy() *RepositoryMaintenanceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceAutoSizing) DeepCopyInto(out *ResourceAutoSizing) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceAutoSizing.
+func (in *ResourceAutoSizing) DeepCopy() *ResourceAutoSizing {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceAutoSizing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRecommendation) DeepCopyInto(out *ResourceRecommendation) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRecommendation.
+func (in *ResourceRecommendation) DeepCopy() *ResourceRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResticConfig) DeepCopyInto(out *ResticConfig) {
 	*out = *in
@@ -1096,6 +1444,46 @@ func (in *RuledConfigs) DeepCopy() *RuledConfigs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *STSIdentity) DeepCopyInto(out *STSIdentity) {
+	*out = *in
+	if in.GCP != nil {
+		in, out := &in.GCP, &out.GCP
+		*out = new(GCPWorkloadIdentity)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureWorkloadIdentity)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new STSIdentity.
+func (in *STSIdentity) DeepCopy() *STSIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(STSIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretProviderClassCredentialSource) DeepCopyInto(out *SecretProviderClassCredentialSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviderClassCredentialSource.
+func (in *SecretProviderClassCredentialSource) DeepCopy() *SecretProviderClassCredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretProviderClassCredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServerFlags) DeepCopyInto(out *ServerFlags) {
 	*out = *in
@@ -1199,6 +1587,11 @@ func (in *SnapshotLocation) DeepCopyInto(out *SnapshotLocation) {
 		*out = new(velerov1.VolumeSnapshotLocationSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CredentialSource != nil {
+		in, out := &in.CredentialSource, &out.CredentialSource
+		*out = new(CredentialSource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotLocation.
@@ -1290,10 +1683,19 @@ func (in *VeleroConfig) DeepCopyInto(out *VeleroConfig) {
 		*out = make([]DefaultPlugin, len(*in))
 		copy(*out, *in)
 	}
+	if in.DefaultPluginConfigs != nil {
+		in, out := &in.DefaultPluginConfigs, &out.DefaultPluginConfigs
+		*out = make(map[DefaultPlugin]PluginConfig, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.CustomPlugins != nil {
 		in, out := &in.CustomPlugins, &out.CustomPlugins
 		*out = make([]CustomPlugin, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.PodConfig != nil {
 		in, out := &in.PodConfig, &out.PodConfig
@@ -1346,6 +1748,11 @@ func (in *VeleroConfig) DeepCopyInto(out *VeleroConfig) {
 			}
 		}
 	}
+	if in.HighAvailability != nil {
+		in, out := &in.HighAvailability, &out.HighAvailability
+		*out = new(VeleroHighAvailability)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroConfig.
@@ -1358,6 +1765,26 @@ func (in *VeleroConfig) DeepCopy() *VeleroConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VeleroHighAvailability) DeepCopyInto(out *VeleroHighAvailability) {
+	*out = *in
+	if in.FailoverTimeout != nil {
+		in, out := &in.FailoverTimeout, &out.FailoverTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroHighAvailability.
+func (in *VeleroHighAvailability) DeepCopy() *VeleroHighAvailability {
+	if in == nil {
+		return nil
+	}
+	out := new(VeleroHighAvailability)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VeleroServerArgs) DeepCopyInto(out *VeleroServerArgs) {
 	*out = *in
@@ -1375,6 +1802,25 @@ func (in *VeleroServerArgs) DeepCopy() *VeleroServerArgs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VeleroServerStatus) DeepCopyInto(out *VeleroServerStatus) {
+	*out = *in
+	if in.LastFailoverTime != nil {
+		in, out := &in.LastFailoverTime, &out.LastFailoverTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroServerStatus.
+func (in *VeleroServerStatus) DeepCopy() *VeleroServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VeleroServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeOptions) DeepCopyInto(out *VolumeOptions) {
 	*out = *in
@@ -24,7 +24,7 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 	// First, check for provider and then call functions based on the cloud provider for each backupstoragelocation configured
 	dpa := r.dpa
 	numDefaultLocations := 0
-	for _, bslSpec := range dpa.Spec.BackupLocations {
+	for i, bslSpec := range dpa.Spec.BackupLocations {
 		if err := r.ensureBackupLocationHasVeleroOrCloudStorage(&bslSpec); err != nil {
 			return false, err
 		}
@@ -33,7 +33,11 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 			return false, err
 		}
 
-		if err := r.ensureSecretDataExists(&bslSpec); err != nil {
+		if bslSpec.CredentialSource != nil {
+			if err := r.validateBackupLocationCredentialSource(fmt.Sprintf("spec.backupLocations[%d]", i), &bslSpec); err != nil {
+				return false, err
+			}
+		} else if err := r.ensureSecretDataExists(&bslSpec); err != nil {
 			return false, err
 		}
 		if bslSpec.Velero != nil {
@@ -50,17 +54,17 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 			// TODO: cases might need some updates for IBM/Minio/noobaa
 			switch provider {
 			case AWSProvider, "velero.io/aws":
-				err := r.validateAWSBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateAWSBackupStorageLocation(*bslSpec.Velero, bslSpec.CredentialSource)
 				if err != nil {
 					return false, err
 				}
 			case AzureProvider, "velero.io/azure":
-				err := r.validateAzureBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateAzureBackupStorageLocation(*bslSpec.Velero, bslSpec.CredentialSource)
 				if err != nil {
 					return false, err
 				}
 			case GCPProvider, "velero.io/gcp":
-				err := r.validateGCPBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateGCPBackupStorageLocation(*bslSpec.Velero, bslSpec.CredentialSource)
 				if err != nil {
 					return false, err
 				}
@@ -82,7 +86,7 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 	if numDefaultLocations == 0 && !dpa.Spec.Configuration.Velero.NoDefaultBackupLocation {
 		return false, errors.New("no default backupstoragelocations configured, ensure that one backupstoragelocation has been configured as the default location")
 	}
-	// TODO: Discuss If multiple BSLs exist, ensure we have multiple credentials
+	// node-agent reads the credential of each BSL through the Velero API, no per BSL mount is needed
 
 	return true, nil
 }
@@ -113,17 +117,20 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 		//	 1. oadpApi.OadpOperatorLabel: "True"
 		// 	 2. dataprotectionapplication.name: <name>
 		// which in turn will be used in the label handler to trigger the reconciliation loop
-		var secretName string
-		if bslSpec.CloudStorage != nil {
-			secretName, _, _ = r.getSecretNameAndKeyFromCloudStorage(bslSpec.CloudStorage)
-		}
+		// Locations using a credential source have no secret
+		if bslSpec.CredentialSource == nil {
+			var secretName string
+			if bslSpec.CloudStorage != nil {
+				secretName, _, _ = r.getSecretNameAndKeyFromCloudStorage(bslSpec.CloudStorage)
+			}
 
-		if bslSpec.Velero != nil {
-			secretName, _, _ = r.getSecretNameAndKey(bslSpec.Velero.Config, bslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Velero.Provider))
-		}
-		err := r.UpdateCredentialsSecretLabels(secretName, dpa.Name)
-		if err != nil {
-			return false, err
+			if bslSpec.Velero != nil {
+				secretName, _, _ = r.getSecretNameAndKey(bslSpec.Velero.Config, bslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Velero.Provider))
+			}
+			err := r.UpdateCredentialsSecretLabels(secretName, dpa.Name)
+			if err != nil {
+				return false, err
+			}
 		}
 
 		// Create BSL
@@ -135,8 +142,15 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 			// TODO: check for BSL status condition errors and respond here
 			if bslSpec.Velero != nil {
 				err := r.updateBSLFromSpec(&bsl, *bslSpec.Velero)
-
-				return err
+				if err != nil {
+					return err
+				}
+				if bslSpec.CredentialSource != nil {
+					mount := credentials.NewBackupLocationCredentialSourceMount(bslName, bslSpec.CredentialSource)
+					bsl.Spec.Credential = nil
+					bsl.Spec.Config = credentialSourceConfig(bsl.Spec.Config, mount)
+				}
+				return nil
 			}
 			if bslSpec.CloudStorage != nil {
 				bucket := &oadpv1alpha1.CloudStorage{}
@@ -189,7 +203,7 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 		}
 
 		// Patch secrets with BSL-specific configuration (only for the first BSL)
-		if i == 0 {
+		if i == 0 && bslSpec.CredentialSource == nil {
 			if err := r.patchSecretsForBSL(&bsl, bslSpec); err != nil {
 				r.Log.Error(err, "Failed to patch secret for BSL", "bsl", bsl.Name)
 				// Don't return error as this is an enhancement, log and continue
@@ -282,9 +296,9 @@ func (r *DataProtectionApplicationReconciler) updateBSLFromSpec(bsl *velerov1.Ba
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, credentialSource *oadpv1alpha1.CredentialSource) error {
 	// validate provider plugin and secret
-	err := r.validateProviderPluginAndSecret(bslSpec)
+	err := r.validateProviderPluginAndSecret(bslSpec, credentialSource)
 	if err != nil {
 		return err
 	}
@@ -316,9 +330,9 @@ func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(b
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateAzureBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateAzureBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, credentialSource *oadpv1alpha1.CredentialSource) error {
 	// validate provider plugin and secret
-	err := r.validateProviderPluginAndSecret(bslSpec)
+	err := r.validateProviderPluginAndSecret(bslSpec, credentialSource)
 	if err != nil {
 		return err
 	}
@@ -347,9 +361,9 @@ func (r *DataProtectionApplicationReconciler) validateAzureBackupStorageLocation
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateGCPBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateGCPBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, credentialSource *oadpv1alpha1.CredentialSource) error {
 	// validate provider plugin and secret
-	err := r.validateProviderPluginAndSecret(bslSpec)
+	err := r.validateProviderPluginAndSecret(bslSpec, credentialSource)
 	if err != nil {
 		return err
 	}
@@ -378,7 +392,7 @@ func pluginExistsInVeleroCR(configuredPlugins []oadpv1alpha1.DefaultPlugin, expe
 	return false
 }
 
-func (r *DataProtectionApplicationReconciler) validateProviderPluginAndSecret(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateProviderPluginAndSecret(bslSpec velerov1.BackupStorageLocationSpec, credentialSource *oadpv1alpha1.CredentialSource) error {
 	if r.dpa.Spec.Configuration.Velero.HasFeatureFlag("no-secret") {
 		return nil
 	}
@@ -387,6 +401,10 @@ func (r *DataProtectionApplicationReconciler) validateProviderPluginAndSecret(bs
 		r.Log.Info(fmt.Sprintf("%s backupstoragelocation is configured but velero plugin for %s is not present", bslSpec.Provider, bslSpec.Provider))
 		//TODO: set warning condition on Velero CR
 	}
+	// the credentials of a credential source are not available to the operator
+	if credentialSource != nil {
+		return nil
+	}
 	secretName, _, _ := r.getSecretNameAndKey(bslSpec.Config, bslSpec.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Provider))
 
 	_, err := r.getProviderSecret(secretName)
@@ -490,6 +508,9 @@ func (r *DataProtectionApplicationReconciler) ensureSecretDataExists(bsl *oadpv1
 	if err != nil {
 		return err
 	}
+	if err := validateCredentialsFormat(provider, secret, secretKey, awsProfile); err != nil {
+		return err
+	}
 	// Only parse secrets when backupImages is true
 	if !r.dpa.BackupImages() {
 		return nil
@@ -511,6 +532,25 @@ func (r *DataProtectionApplicationReconciler) ensureSecretDataExists(bsl *oadpv1
 	return nil
 }
 
+// validateCredentialsFormat validates the credentials file in the secret key against the format of the provider.
+// The error names the secret, the key and the line of the file.
+func validateCredentialsFormat(provider string, secret corev1.Secret, secretKey string, awsProfile string) error {
+	data := secret.Data[secretKey]
+	var err error
+	switch {
+	case provider == AWSProvider || strings.Contains(provider, "aws"):
+		err = credentials.ValidateAWSCredentials(data, awsProfile)
+	case provider == AzureProvider || strings.Contains(provider, "azure"):
+		err = credentials.ValidateAzureCredentials(data)
+	case provider == GCPProvider || strings.Contains(provider, "gcp"):
+		err = credentials.ValidateGCPCredentials(data)
+	}
+	if err != nil {
+		return fmt.Errorf("%s credentials in secret %s key %s are invalid: %w", provider, secret.Name, secretKey, err)
+	}
+	return nil
+}
+
 // patchSecretsForBSL patches cloud provider secrets with BSL-specific configuration
 func (r *DataProtectionApplicationReconciler) patchSecretsForBSL(bsl *velerov1.BackupStorageLocation, bslSpec oadpv1alpha1.BackupLocation) error {
 	// Determine provider and secret details
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// stsIdentityBackupLocationLabel is the backup location of a secret generated from a stsIdentity
+const stsIdentityBackupLocationLabel = "oadp.openshift.io/sts-backup-location"
+
+// ReconcileBackupLocationSTSSecrets generates the STS credentials secret of each backup location with a stsIdentity,
+// deletes the secrets of removed identities, and sets the generated secret as the velero credential of the location
+// for the rest of the reconcile. It runs before the validation, which then checks the generated secrets.
+func (r *DataProtectionApplicationReconciler) ReconcileBackupLocationSTSSecrets(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	desired := map[string]bool{}
+	for i := range dpa.Spec.BackupLocations {
+		bslSpec := &dpa.Spec.BackupLocations[i]
+		if bslSpec.STSIdentity == nil {
+			continue
+		}
+		bslName := fmt.Sprintf("%s-%d", dpa.Name, i+1)
+		if bslSpec.Name != "" {
+			bslName = bslSpec.Name
+		}
+		secretKey, credStringData, err := getSTSIdentitySecretData(bslSpec, bslName)
+		if err != nil {
+			return false, err
+		}
+		secretName := getSTSIdentitySecretName(bslName)
+		desired[secretName] = true
+
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: dpa.Namespace}}
+		op, err := controllerutil.CreateOrPatch(r.Context, r.Client, secret, func() error {
+			if secret.Labels == nil {
+				secret.Labels = map[string]string{}
+			}
+			for key, value := range getDpaAppLabels(dpa) {
+				secret.Labels[key] = value
+			}
+			secret.Labels[stsIdentityBackupLocationLabel] = bslName
+			// keep the region and resource group appended by the backup location reconcile
+			if stsflow.HasSTSSecretDrifted(secret, credStringData) {
+				secret.Labels[stsflow.STSSecretTypeLabel] = stsflow.STSSecretTypeValue
+				secret.Data = map[string][]byte{}
+				for key, value := range credStringData {
+					secret.Data[key] = []byte(value)
+				}
+			}
+			return controllerutil.SetControllerReference(dpa, secret, r.Scheme)
+		})
+		if err != nil {
+			return false, err
+		}
+		if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+			r.EventRecorder.Event(secret, corev1.EventTypeNormal, "BackupLocationSTSSecretReconciled",
+				fmt.Sprintf("performed %s on STS credentials secret %s/%s of backup location %s", op, secret.Namespace, secret.Name, bslName))
+		}
+		bslSpec.Velero.Credential = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+			Key:                  secretKey,
+		}
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(r.Context, secrets, client.InNamespace(dpa.Namespace), client.HasLabels{stsIdentityBackupLocationLabel},
+		client.MatchingLabels{"app.kubernetes.io/instance": dpa.Name}); err != nil {
+		return false, err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if desired[secret.Name] || !metav1.IsControlledBy(secret, dpa) {
+			continue
+		}
+		if err := r.Delete(r.Context, secret); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		log.Info("deleted STS credentials secret of removed backup location identity", "secret", secret.Name)
+	}
+	return true, nil
+}
+
+// getSTSIdentitySecretData validates the stsIdentity of a backup location and returns the key and content of its
+// STS credentials secret, in the format of the standardized flow with the projected service account token
+func getSTSIdentitySecretData(bslSpec *oadpv1alpha1.BackupLocation, bslName string) (string, map[string]string, error) {
+	identity := bslSpec.STSIdentity
+	if bslSpec.Velero == nil {
+		return "", nil, fmt.Errorf("backup location %s: stsIdentity requires the velero configuration", bslName)
+	}
+	if bslSpec.Velero.Credential != nil || bslSpec.CredentialSource != nil {
+		return "", nil, fmt.Errorf("backup location %s: stsIdentity can not be combined with velero.credential or credentialSource", bslName)
+	}
+	set := 0
+	for _, isSet := range []bool{identity.RoleARN != "", identity.GCP != nil, identity.Azure != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", nil, fmt.Errorf("backup location %s: stsIdentity must set exactly one of roleARN, gcp and azure", bslName)
+	}
+
+	provider := strings.TrimPrefix(bslSpec.Velero.Provider, veleroIOPrefix)
+	switch {
+	case identity.RoleARN != "":
+		if provider != AWSProvider {
+			return "", nil, fmt.Errorf("backup location %s: stsIdentity roleARN requires the %s provider", bslName, AWSProvider)
+		}
+		return "credentials", stsflow.AWSSecretData(identity.RoleARN), nil
+	case identity.GCP != nil:
+		gcp := identity.GCP
+		if provider != GCPProvider {
+			return "", nil, fmt.Errorf("backup location %s: stsIdentity gcp requires the %s provider", bslName, GCPProvider)
+		}
+		if gcp.ServiceAccountEmail == "" || gcp.ProjectNumber == "" || gcp.PoolID == "" || gcp.ProviderID == "" {
+			return "", nil, fmt.Errorf("backup location %s: stsIdentity gcp requires serviceAccountEmail, projectNumber, poolID and providerID", bslName)
+		}
+		return stsflow.GcpSecretJSONKey, stsflow.GCPSecretData(gcp.ServiceAccountEmail, gcp.ProjectNumber, gcp.PoolID, gcp.ProviderID), nil
+	default:
+		azure := identity.Azure
+		if provider != AzureProvider {
+			return "", nil, fmt.Errorf("backup location %s: stsIdentity azure requires the %s provider", bslName, AzureProvider)
+		}
+		if azure.ClientID == "" || azure.TenantID == "" || azure.SubscriptionID == "" {
+			return "", nil, fmt.Errorf("backup location %s: stsIdentity azure requires clientID, tenantID and subscriptionID", bslName)
+		}
+		credStringData := stsflow.AzureSecretData(azure.ClientID, azure.TenantID, azure.SubscriptionID)
+		// the federated token file is otherwise only set in the environment for the identity of the operator
+		credStringData["azurekey"] += fmt.Sprintf("AZURE_FEDERATED_TOKEN_FILE=%s\n", stsflow.WebIdentityTokenPath)
+		return "azurekey", credStringData, nil
+	}
+}
+
+// getSTSIdentitySecretName returns the name of the STS credentials secret generated for a backup location
+func getSTSIdentitySecretName(bslName string) string {
+	return fmt.Sprintf("%s-sts-credentials", bslName)
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+func TestGetSTSIdentitySecretData(t *testing.T) {
+	tests := []struct {
+		name     string
+		bslSpec  oadpv1alpha1.BackupLocation
+		wantKey  string
+		wantData string
+		wantErr  string
+	}{
+		{
+			name: "aws role",
+			bslSpec: oadpv1alpha1.BackupLocation{
+				Velero:      &velerov1.BackupStorageLocationSpec{Provider: "velero.io/aws"},
+				STSIdentity: &oadpv1alpha1.STSIdentity{RoleARN: "arn:aws:iam::123456789012:role/tenant-a"},
+			},
+			wantKey:  "credentials",
+			wantData: "role_arn = arn:aws:iam::123456789012:role/tenant-a\nweb_identity_token_file = " + stsflow.WebIdentityTokenPath,
+		},
+		{
+			name: "gcp workload identity",
+			bslSpec: oadpv1alpha1.BackupLocation{
+				Velero: &velerov1.BackupStorageLocationSpec{Provider: GCPProvider},
+				STSIdentity: &oadpv1alpha1.STSIdentity{GCP: &oadpv1alpha1.GCPWorkloadIdentity{
+					ServiceAccountEmail: "tenant-a@project.iam.gserviceaccount.com", ProjectNumber: "123", PoolID: "pool", ProviderID: "provider",
+				}},
+			},
+			wantKey:  stsflow.GcpSecretJSONKey,
+			wantData: "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider",
+		},
+		{
+			name: "azure workload identity",
+			bslSpec: oadpv1alpha1.BackupLocation{
+				Velero:      &velerov1.BackupStorageLocationSpec{Provider: AzureProvider},
+				STSIdentity: &oadpv1alpha1.STSIdentity{Azure: &oadpv1alpha1.AzureWorkloadIdentity{ClientID: "client", TenantID: "tenant", SubscriptionID: "sub"}},
+			},
+			wantKey:  "azurekey",
+			wantData: "AZURE_CLIENT_ID=client\nAZURE_CLOUD_NAME=AzurePublicCloud\nAZURE_FEDERATED_TOKEN_FILE=" + stsflow.WebIdentityTokenPath,
+		},
+		{
+			name: "identity of another provider",
+			bslSpec: oadpv1alpha1.BackupLocation{
+				Velero:      &velerov1.BackupStorageLocationSpec{Provider: GCPProvider},
+				STSIdentity: &oadpv1alpha1.STSIdentity{RoleARN: "arn:aws:iam::123456789012:role/tenant-a"},
+			},
+			wantErr: "backup location test: stsIdentity roleARN requires the aws provider",
+		},
+		{
+			name: "two identities",
+			bslSpec: oadpv1alpha1.BackupLocation{
+				Velero: &velerov1.BackupStorageLocationSpec{Provider: AWSProvider},
+				STSIdentity: &oadpv1alpha1.STSIdentity{
+					RoleARN: "arn:aws:iam::123456789012:role/tenant-a",
+					Azure:   &oadpv1alpha1.AzureWorkloadIdentity{ClientID: "client", TenantID: "tenant", SubscriptionID: "sub"},
+				},
+			},
+			wantErr: "backup location test: stsIdentity must set exactly one of roleARN, gcp and azure",
+		},
+		{
+			name: "combined with a credential",
+			bslSpec: oadpv1alpha1.BackupLocation{
+				Velero: &velerov1.BackupStorageLocationSpec{
+					Provider:   AWSProvider,
+					Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"}, Key: "cloud"},
+				},
+				STSIdentity: &oadpv1alpha1.STSIdentity{RoleARN: "arn:aws:iam::123456789012:role/tenant-a"},
+			},
+			wantErr: "backup location test: stsIdentity can not be combined with velero.credential or credentialSource",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, data, err := getSTSIdentitySecretData(&tt.bslSpec, "test")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getSTSIdentitySecretData() error = %v", err)
+			}
+			if key != tt.wantKey || !strings.Contains(data[key], tt.wantData) {
+				t.Errorf("expected key %s containing %q, got %v", tt.wantKey, tt.wantData, data)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileBackupLocationSTSSecrets(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName, UID: "test-uid"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{
+					Name:        "tenant-a",
+					Velero:      &velerov1.BackupStorageLocationSpec{Provider: AWSProvider, Config: map[string]string{Region: "us-east-1"}},
+					STSIdentity: &oadpv1alpha1.STSIdentity{RoleARN: "arn:aws:iam::123456789012:role/tenant-a"},
+				},
+				{Name: "shared", Velero: &velerov1.BackupStorageLocationSpec{Provider: AWSProvider}},
+			},
+		},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa)
+	if err != nil {
+		t.Fatalf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Context: context.Background(), dpa: dpa, EventRecorder: record.NewFakeRecorder(10)}
+
+	if _, err := r.ReconcileBackupLocationSTSSecrets(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileBackupLocationSTSSecrets() error = %v", err)
+	}
+	wantCredential := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "tenant-a-sts-credentials"}, Key: "credentials"}
+	if credential := dpa.Spec.BackupLocations[0].Velero.Credential; credential == nil || *credential != *wantCredential {
+		t.Errorf("expected credential %v, got %v", wantCredential, credential)
+	}
+	if dpa.Spec.BackupLocations[1].Velero.Credential != nil {
+		t.Errorf("expected no credential for the location without stsIdentity")
+	}
+	key := types.NamespacedName{Name: "tenant-a-sts-credentials", Namespace: testNamespaceName}
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(context.Background(), key, secret); err != nil {
+		t.Fatalf("expected the STS secret to be created: %v", err)
+	}
+	if !metav1.IsControlledBy(secret, dpa) || secret.Labels[stsflow.STSSecretTypeLabel] != stsflow.STSSecretTypeValue {
+		t.Errorf("expected a labeled secret controlled by the DPA, got %v", secret.ObjectMeta)
+	}
+
+	// the region appended by the backup location reconcile is kept
+	regionPatched := string(secret.Data["credentials"]) + "\nregion = us-east-1\n"
+	secret.Data["credentials"] = []byte(regionPatched)
+	if err := fakeClient.Update(context.Background(), secret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	dpa.Spec.BackupLocations[0].Velero.Credential = nil
+	if _, err := r.ReconcileBackupLocationSTSSecrets(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileBackupLocationSTSSecrets() error = %v", err)
+	}
+	if err := fakeClient.Get(context.Background(), key, secret); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if string(secret.Data["credentials"]) != regionPatched {
+		t.Errorf("expected the region to be kept, got %q", secret.Data["credentials"])
+	}
+
+	// the secret of a removed identity is deleted
+	dpa.Spec.BackupLocations = dpa.Spec.BackupLocations[1:]
+	if _, err := r.ReconcileBackupLocationSTSSecrets(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileBackupLocationSTSSecrets() error = %v", err)
+	}
+	if err := fakeClient.Get(context.Background(), key, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the STS secret to be deleted, got %v", err)
+	}
+}
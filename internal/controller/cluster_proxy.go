@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/operator-framework/operator-lib/proxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	clusterProxyName = "cluster"
+	// trustedCABundleInjectLabel asks the Cluster Network Operator to inject the cluster trusted CA bundle,
+	// which includes the CAs of the cluster-wide proxy trustedCA, into the ConfigMap
+	trustedCABundleInjectLabel = "config.openshift.io/inject-trusted-cabundle"
+	trustedCABundleKey         = "ca-bundle.crt"
+	trustedCABundleVolumeName  = "trusted-ca-bundle"
+	// the UBI based images read the system trust store extracted by update-ca-trust from this directory
+	trustedCABundleMountPath      = "/etc/pki/ca-trust/extracted/pem"
+	trustedCABundleFileName       = "tls-ca-bundle.pem"
+	trustedCABundleHashAnnotation = "oadp.openshift.io/trusted-ca-bundle-hash"
+)
+
+// ReconcileTrustedCABundle ensures the ConfigMap the cluster trusted CA bundle is injected into exists.
+// The bundle is mounted into the Velero, node-agent and non-admin controller pods once injected.
+func (r *DataProtectionApplicationReconciler) ReconcileTrustedCABundle(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.TrustedCABundleCMPrefix + dpa.Name,
+			Namespace: dpa.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, configMap, func() error {
+		if err := controllerutil.SetControllerReference(dpa, configMap, r.Scheme); err != nil {
+			return fmt.Errorf("failed to set controller reference: %w", err)
+		}
+		if configMap.Labels == nil {
+			configMap.Labels = map[string]string{}
+		}
+		configMap.Labels["app.kubernetes.io/instance"] = dpa.Name
+		configMap.Labels["app.kubernetes.io/managed-by"] = common.OADPOperator
+		configMap.Labels["app.kubernetes.io/component"] = trustedCABundleVolumeName
+		configMap.Labels[oadpv1alpha1.OadpOperatorLabel] = "True"
+		configMap.Labels[trustedCABundleInjectLabel] = "true"
+		// the data is owned by the Cluster Network Operator
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create or patch trusted CA bundle config map: %w", err)
+	}
+
+	if op == controllerutil.OperationResultCreated {
+		r.EventRecorder.Event(configMap, corev1.EventTypeNormal, "CreatedTrustedCABundleConfigMap", "Trusted CA bundle config map created")
+	}
+	return true, nil
+}
+
+// getProxyEnvVars returns the proxy environment variables for the operands from the cluster-wide Proxy status, so
+// a proxy change rolls the pods without an operator restart. The operator environment is used when the cluster has
+// no proxy configured or the Proxy API is not available.
+func (r *DataProtectionApplicationReconciler) getProxyEnvVars() ([]corev1.EnvVar, error) {
+	clusterProxy := &configv1.Proxy{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: clusterProxyName}, clusterProxy); err != nil {
+		if errors.IsNotFound(err) || apimeta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return proxy.ReadProxyVarsFromEnv(), nil
+		}
+		return nil, err
+	}
+
+	envVars := []corev1.EnvVar{}
+	for _, env := range []struct{ name, value string }{
+		{"HTTP_PROXY", clusterProxy.Status.HTTPProxy},
+		{"HTTPS_PROXY", clusterProxy.Status.HTTPSProxy},
+		{"NO_PROXY", clusterProxy.Status.NoProxy},
+	} {
+		if env.value == "" {
+			continue
+		}
+		envVars = append(envVars,
+			corev1.EnvVar{Name: env.name, Value: env.value},
+			corev1.EnvVar{Name: strings.ToLower(env.name), Value: env.value},
+		)
+	}
+	if len(envVars) == 0 {
+		return proxy.ReadProxyVarsFromEnv(), nil
+	}
+	return envVars, nil
+}
+
+// appendTrustedCABundle mounts the injected trusted CA bundle into the named containers and records its hash in
+// the pod template, so a bundle change rolls the pods. Velero plugins run in the velero container and use its
+// mount. The pod template is left without the bundle until it is injected.
+func (r *DataProtectionApplicationReconciler) appendTrustedCABundle(podTemplate *corev1.PodTemplateSpec, containerNames ...string) error {
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(r.Context, types.NamespacedName{Name: common.TrustedCABundleCMPrefix + r.dpa.Name, Namespace: r.dpa.Namespace}, configMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// remove a previously mounted bundle, the pod template of the non-admin controller is patched in place
+	volumes := []corev1.Volume{}
+	for _, volume := range podTemplate.Spec.Volumes {
+		if volume.Name != trustedCABundleVolumeName {
+			volumes = append(volumes, volume)
+		}
+	}
+	podTemplate.Spec.Volumes = volumes
+	delete(podTemplate.Annotations, trustedCABundleHashAnnotation)
+	for i := range podTemplate.Spec.Containers {
+		volumeMounts := []corev1.VolumeMount{}
+		for _, volumeMount := range podTemplate.Spec.Containers[i].VolumeMounts {
+			if volumeMount.Name != trustedCABundleVolumeName {
+				volumeMounts = append(volumeMounts, volumeMount)
+			}
+		}
+		podTemplate.Spec.Containers[i].VolumeMounts = volumeMounts
+	}
+
+	bundle := configMap.Data[trustedCABundleKey]
+	if bundle == "" {
+		return nil
+	}
+
+	podTemplate.Spec.Volumes = append(podTemplate.Spec.Volumes, corev1.Volume{
+		Name: trustedCABundleVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+				Items: []corev1.KeyToPath{
+					{Key: trustedCABundleKey, Path: trustedCABundleFileName},
+				},
+			},
+		},
+	})
+	for i := range podTemplate.Spec.Containers {
+		container := &podTemplate.Spec.Containers[i]
+		for _, name := range containerNames {
+			if container.Name == name {
+				container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+					Name:      trustedCABundleVolumeName,
+					MountPath: trustedCABundleMountPath,
+					ReadOnly:  true,
+				})
+			}
+		}
+	}
+	if podTemplate.Annotations == nil {
+		podTemplate.Annotations = map[string]string{}
+	}
+	podTemplate.Annotations[trustedCABundleHashAnnotation] = fmt.Sprintf("%x", sha256.Sum256([]byte(bundle)))
+	return nil
+}
+
+// clusterProxyRequests returns all DPAs to reconcile when the cluster-wide Proxy changes
+func (r *DataProtectionApplicationReconciler) clusterProxyRequests(ctx context.Context, clusterProxy *configv1.Proxy) []reconcile.Request {
+	if clusterProxy.Name != clusterProxyName {
+		return nil
+	}
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for i := range dpaList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dpaList.Items[i])})
+	}
+	return requests
+}
+
+func isTrustedCABundleConfigMap(configMap *corev1.ConfigMap) bool {
+	return configMap.Labels[trustedCABundleInjectLabel] == "true" && configMap.Labels[oadpv1alpha1.OadpOperatorLabel] != ""
+}
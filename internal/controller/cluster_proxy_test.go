@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDPAReconciler_getProxyEnvVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []client.Object
+		env     map[string]string
+		want    []corev1.EnvVar
+	}{
+		{
+			name: "no cluster proxy, operator environment is used",
+			env:  map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+			want: []corev1.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "http://env-proxy:3128"},
+				{Name: "https_proxy", Value: "http://env-proxy:3128"},
+			},
+		},
+		{
+			name: "cluster proxy status takes precedence over operator environment",
+			objects: []client.Object{
+				&configv1.Proxy{
+					ObjectMeta: metav1.ObjectMeta{Name: clusterProxyName},
+					Status: configv1.ProxyStatus{
+						HTTPSProxy: "http://cluster-proxy:3128",
+						NoProxy:    ".cluster.local",
+					},
+				},
+			},
+			env: map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+			want: []corev1.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "http://cluster-proxy:3128"},
+				{Name: "https_proxy", Value: "http://cluster-proxy:3128"},
+				{Name: "NO_PROXY", Value: ".cluster.local"},
+				{Name: "no_proxy", Value: ".cluster.local"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			fakeClient, err := getFakeClientFromObjects(tt.objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background()}
+			got, err := r.getProxyEnvVars()
+			if err != nil {
+				t.Fatalf("getProxyEnvVars() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getProxyEnvVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_appendTrustedCABundle(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+		},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa)
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		Context:       context.Background(),
+		dpa:           dpa,
+		EventRecorder: record.NewFakeRecorder(10),
+	}
+	podTemplate := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: common.Velero}, {Name: "sidecar"}}},
+	}
+
+	if _, err := r.ReconcileTrustedCABundle(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileTrustedCABundle() error = %v", err)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: common.TrustedCABundleCMPrefix + testDpaName, Namespace: testNamespaceName}, configMap); err != nil {
+		t.Fatalf("expected trusted CA bundle config map to be created, got %v", err)
+	}
+	if configMap.Labels[trustedCABundleInjectLabel] != "true" {
+		t.Errorf("expected trusted CA bundle config map to request injection, got labels %v", configMap.Labels)
+	}
+
+	// bundle not injected yet
+	if err := r.appendTrustedCABundle(podTemplate, common.Velero); err != nil {
+		t.Fatalf("appendTrustedCABundle() error = %v", err)
+	}
+	if len(podTemplate.Spec.Volumes) != 0 || podTemplate.Annotations[trustedCABundleHashAnnotation] != "" {
+		t.Errorf("expected no trusted CA bundle before injection, got %v", podTemplate)
+	}
+
+	// bundle injected, then rotated
+	var previousHash string
+	for _, bundle := range []string{"first bundle", "second bundle"} {
+		configMap.Data = map[string]string{trustedCABundleKey: bundle}
+		if err := r.Update(r.Context, configMap); err != nil {
+			t.Fatalf("failed to update config map: %v", err)
+		}
+		if err := r.appendTrustedCABundle(podTemplate, common.Velero); err != nil {
+			t.Fatalf("appendTrustedCABundle() error = %v", err)
+		}
+		if len(podTemplate.Spec.Volumes) != 1 || len(podTemplate.Spec.Containers[0].VolumeMounts) != 1 || len(podTemplate.Spec.Containers[1].VolumeMounts) != 0 {
+			t.Errorf("expected trusted CA bundle to be mounted once into the velero container, got %v", podTemplate.Spec)
+		}
+		hash := podTemplate.Annotations[trustedCABundleHashAnnotation]
+		if hash == "" || hash == previousHash {
+			t.Errorf("expected trusted CA bundle hash annotation to change, got %q", hash)
+		}
+		previousHash = hash
+	}
+}
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	snapshotv1api "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"github.com/vmware-tanzu/velero/pkg/nodeagent"
+	"github.com/vmware-tanzu/velero/pkg/util/kube"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// configMapValidationContext holds the cluster objects the ConfigMap settings are validated against
+type configMapValidationContext struct {
+	nodes                []corev1.Node
+	storageClasses       map[string]storagev1.StorageClass
+	snapshotClassDrivers map[string]bool
+	// snapshotClassesFound is false when the VolumeSnapshotClass API is not available in the cluster
+	snapshotClassesFound bool
+}
+
+// ReconcileConfigMapsValidation validates the settings serialized into the node-agent, backup-repository and
+// repository-maintenance ConfigMaps against the live cluster. Problems only show up in node-agent logs otherwise,
+// so they are reported with their field path in the ConfigMapsValid condition. They do not fail the reconcile,
+// as the referenced cluster objects may be created later.
+func (r *DataProtectionApplicationReconciler) ReconcileConfigMapsValidation(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	nodeAgentCM := isNodeAgentEnabled(dpa) && isNodeAgentCMRequired(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings)
+	if !nodeAgentCM && !isBackupRepositoryCmRequired(dpa.Spec.Configuration.NodeAgent) && !isRepositoryMaintenanceCmRequired(dpa.Spec.Configuration) {
+		apimeta.RemoveStatusCondition(&dpa.Status.Conditions, oadpv1alpha1.ConditionConfigMapsValid)
+		return true, nil
+	}
+
+	validationContext, err := r.getConfigMapValidationContext()
+	if err != nil {
+		return false, err
+	}
+	errs := validateConfigMapSettings(dpa, validationContext)
+
+	if len(errs) == 0 {
+		apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+			Type:    oadpv1alpha1.ConditionConfigMapsValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  oadpv1alpha1.ConfigMapsValidReasonValid,
+			Message: "node-agent, backup-repository and repository-maintenance configurations are valid",
+		})
+		return true, nil
+	}
+
+	message := errs.ToAggregate().Error()
+	previous := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionConfigMapsValid)
+	if previous == nil || previous.Message != message {
+		log.Info("invalid ConfigMap configuration", "errors", message)
+		r.EventRecorder.Event(dpa, corev1.EventTypeWarning, "ConfigMapsInvalid", message)
+	}
+	apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+		Type:    oadpv1alpha1.ConditionConfigMapsValid,
+		Status:  metav1.ConditionFalse,
+		Reason:  oadpv1alpha1.ConfigMapsValidReasonInvalid,
+		Message: message,
+	})
+	return true, nil
+}
+
+func (r *DataProtectionApplicationReconciler) getConfigMapValidationContext() (*configMapValidationContext, error) {
+	validationContext := &configMapValidationContext{
+		storageClasses:       map[string]storagev1.StorageClass{},
+		snapshotClassDrivers: map[string]bool{},
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.List(r.Context, nodeList); err != nil {
+		return nil, err
+	}
+	validationContext.nodes = nodeList.Items
+
+	storageClassList := &storagev1.StorageClassList{}
+	if err := r.List(r.Context, storageClassList); err != nil {
+		return nil, err
+	}
+	for _, storageClass := range storageClassList.Items {
+		validationContext.storageClasses[storageClass.Name] = storageClass
+	}
+
+	snapshotClassList := &snapshotv1api.VolumeSnapshotClassList{}
+	if err := r.List(r.Context, snapshotClassList); err != nil {
+		if !apimeta.IsNoMatchError(err) && !runtime.IsNotRegisteredError(err) {
+			return nil, err
+		}
+		return validationContext, nil
+	}
+	validationContext.snapshotClassesFound = true
+	for _, snapshotClass := range snapshotClassList.Items {
+		validationContext.snapshotClassDrivers[snapshotClass.Driver] = true
+	}
+	return validationContext, nil
+}
+
+func validateConfigMapSettings(dpa *oadpv1alpha1.DataProtectionApplication, validationContext *configMapValidationContext) field.ErrorList {
+	errs := field.ErrorList{}
+	configPath := field.NewPath("spec", "configuration")
+
+	if nodeAgent := dpa.Spec.Configuration.NodeAgent; nodeAgent != nil && isNodeAgentEnabled(dpa) {
+		nodeAgentPath := configPath.Child("nodeAgent")
+		errs = append(errs, validateLoadConcurrency(nodeAgent.LoadConcurrency, nodeAgentPath.Child("loadConcurrency"), validationContext)...)
+		for i, affinity := range nodeAgent.LoadAffinityConfig {
+			if affinity == nil {
+				continue
+			}
+			_, selectorErrs := validateNodeSelector(affinity.NodeSelector, nodeAgentPath.Child("loadAffinity").Index(i).Child("nodeSelector"), validationContext)
+			errs = append(errs, selectorErrs...)
+		}
+		errs = append(errs, validateBackupPVCConfig(nodeAgent.BackupPVCConfig, nodeAgentPath.Child("backupPVC"), validationContext)...)
+		errs = append(errs, validatePodResources(nodeAgent.PodResources, nodeAgentPath.Child("podResources"))...)
+	}
+
+	repositoryMaintenancePath := configPath.Child("repositoryMaintenance")
+	for _, key := range sortedKeys(dpa.Spec.Configuration.RepositoryMaintenance) {
+		config := dpa.Spec.Configuration.RepositoryMaintenance[key]
+		for i, affinity := range config.LoadAffinityConfig {
+			if affinity == nil {
+				continue
+			}
+			_, selectorErrs := validateNodeSelector(affinity.NodeSelector, repositoryMaintenancePath.Key(key).Child("loadAffinity").Index(i).Child("nodeSelector"), validationContext)
+			errs = append(errs, selectorErrs...)
+		}
+		errs = append(errs, validatePodResources(config.PodResources, repositoryMaintenancePath.Key(key).Child("podResources"))...)
+	}
+	return errs
+}
+
+// validateNodeSelector checks that the label selector is valid and matches at least one node.
+// It returns the names of the matched nodes.
+func validateNodeSelector(selector metav1.LabelSelector, path *field.Path, validationContext *configMapValidationContext) ([]string, field.ErrorList) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return nil, field.ErrorList{field.Invalid(path, selector.String(), err.Error())}
+	}
+	matched := []string{}
+	for _, node := range validationContext.nodes {
+		if labelSelector.Matches(labels.Set(node.Labels)) {
+			matched = append(matched, node.Name)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, field.ErrorList{field.Invalid(path, labelSelector.String(), "does not match any node")}
+	}
+	return matched, nil
+}
+
+// validateLoadConcurrency checks the per node rules. When a node matches more than one rule with a different
+// number, node-agent silently uses the smallest one, so overlapping rules are reported.
+func validateLoadConcurrency(loadConcurrency *oadpv1alpha1.LoadConcurrency, path *field.Path, validationContext *configMapValidationContext) field.ErrorList {
+	errs := field.ErrorList{}
+	if loadConcurrency == nil {
+		return errs
+	}
+	if loadConcurrency.GlobalConfig < 0 {
+		errs = append(errs, field.Invalid(path.Child("globalConfig"), loadConcurrency.GlobalConfig, "must be greater than or equal to 0"))
+	}
+
+	// node name to index of the first rule matching it
+	firstRule := map[string]int{}
+	reported := map[[2]int]bool{}
+	for i, rule := range loadConcurrency.PerNodeConfig {
+		rulePath := path.Child("perNodeConfig").Index(i)
+		if rule.Number <= 0 {
+			errs = append(errs, field.Invalid(rulePath.Child("number"), rule.Number, "must be greater than 0"))
+		}
+		matched, selectorErrs := validateNodeSelector(rule.NodeSelector, rulePath.Child("nodeSelector"), validationContext)
+		errs = append(errs, selectorErrs...)
+		for _, node := range matched {
+			j, found := firstRule[node]
+			if !found {
+				firstRule[node] = i
+				continue
+			}
+			if loadConcurrency.PerNodeConfig[j].Number == rule.Number || reported[[2]int{j, i}] {
+				continue
+			}
+			reported[[2]int{j, i}] = true
+			errs = append(errs, field.Invalid(rulePath.Child("nodeSelector"), metav1.FormatLabelSelector(&rule.NodeSelector),
+				fmt.Sprintf("overlaps with %s on node %s with a different number", path.Child("perNodeConfig").Index(j).String(), node)))
+		}
+	}
+	return errs
+}
+
+// validateBackupPVCConfig checks that the source storage classes, which are the map keys, and the backupPVC storage
+// classes exist, and that a VolumeSnapshotClass exists for the CSI driver of the source storage class.
+func validateBackupPVCConfig(backupPVCConfig map[string]nodeagent.BackupPVC, path *field.Path, validationContext *configMapValidationContext) field.ErrorList {
+	errs := field.ErrorList{}
+	for _, key := range sortedKeys(backupPVCConfig) {
+		config := backupPVCConfig[key]
+		storageClass, found := validationContext.storageClasses[key]
+		if !found {
+			errs = append(errs, field.NotFound(path.Key(key), key))
+		} else if validationContext.snapshotClassesFound && !validationContext.snapshotClassDrivers[storageClass.Provisioner] {
+			errs = append(errs, field.Invalid(path.Key(key), key, fmt.Sprintf("no VolumeSnapshotClass found for driver %s", storageClass.Provisioner)))
+		}
+		if config.StorageClass != "" {
+			if _, found := validationContext.storageClasses[config.StorageClass]; !found {
+				errs = append(errs, field.NotFound(path.Key(key).Child("storageClass"), config.StorageClass))
+			}
+		}
+	}
+	return errs
+}
+
+// validatePodResources checks that the resource quantities parse and that requests do not exceed limits
+func validatePodResources(podResources *kube.PodResources, path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if podResources == nil {
+		return errs
+	}
+	parse := func(name, value string) *resource.Quantity {
+		if value == "" {
+			return nil
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Child(name), value, err.Error()))
+			return nil
+		}
+		return &quantity
+	}
+	cpuRequest := parse("cpuRequest", podResources.CPURequest)
+	cpuLimit := parse("cpuLimit", podResources.CPULimit)
+	memoryRequest := parse("memoryRequest", podResources.MemoryRequest)
+	memoryLimit := parse("memoryLimit", podResources.MemoryLimit)
+	if cpuRequest != nil && cpuLimit != nil && cpuRequest.Cmp(*cpuLimit) > 0 {
+		errs = append(errs, field.Invalid(path.Child("cpuRequest"), podResources.CPURequest, "must be less than or equal to cpuLimit"))
+	}
+	if memoryRequest != nil && memoryLimit != nil && memoryRequest.Cmp(*memoryLimit) > 0 {
+		errs = append(errs, field.Invalid(path.Child("memoryRequest"), podResources.MemoryRequest, "must be less than or equal to memoryLimit"))
+	}
+	return errs
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
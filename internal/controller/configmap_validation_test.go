@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/velero/pkg/nodeagent"
+	"github.com/vmware-tanzu/velero/pkg/util/kube"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func testValidationContext() *configMapValidationContext {
+	return &configMapValidationContext{
+		nodes: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"node-role.kubernetes.io/worker": "", "zone": "a"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "worker-2", Labels: map[string]string{"node-role.kubernetes.io/worker": "", "zone": "b"}}},
+		},
+		storageClasses: map[string]storagev1.StorageClass{
+			"gp3-csi": {ObjectMeta: metav1.ObjectMeta{Name: "gp3-csi"}, Provisioner: "ebs.csi.aws.com"},
+			"nfs":     {ObjectMeta: metav1.ObjectMeta{Name: "nfs"}, Provisioner: "nfs.csi.k8s.io"},
+		},
+		snapshotClassDrivers: map[string]bool{"ebs.csi.aws.com": true},
+		snapshotClassesFound: true,
+	}
+}
+
+func testNodeAgentDpa(settings oadpv1alpha1.NodeAgentConfigMapSettings) *oadpv1alpha1.DataProtectionApplication {
+	return &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields:      oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+					UploaderType:               "kopia",
+					NodeAgentConfigMapSettings: settings,
+				},
+			},
+		},
+	}
+}
+
+func TestValidateConfigMapSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		dpa        *oadpv1alpha1.DataProtectionApplication
+		wantErrors []string
+	}{
+		{
+			name: "valid configuration",
+			dpa: testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+				LoadConcurrency: &oadpv1alpha1.LoadConcurrency{
+					GlobalConfig: 1,
+					PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}, Number: 2},
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "b"}}, Number: 3},
+					},
+				},
+				BackupPVCConfig: map[string]nodeagent.BackupPVC{
+					"gp3-csi": {StorageClass: "gp3-csi", ReadOnly: true},
+				},
+				PodResources: &kube.PodResources{CPURequest: "100m", CPULimit: "1", MemoryRequest: "1Gi", MemoryLimit: "2Gi"},
+			}),
+		},
+		{
+			name: "invalid and unmatched label selectors",
+			dpa: testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+				LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+					{NodeSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "zone", Operator: "Bogus"}}}},
+					{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "c"}}},
+				},
+			}),
+			wantErrors: []string{
+				"spec.configuration.nodeAgent.loadAffinity[0].nodeSelector: Invalid value",
+				"spec.configuration.nodeAgent.loadAffinity[1].nodeSelector: Invalid value: \"zone=c\": does not match any node",
+			},
+		},
+		{
+			name: "overlapping per node concurrency",
+			dpa: testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+				LoadConcurrency: &oadpv1alpha1.LoadConcurrency{
+					PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/worker": ""}}, Number: 2},
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}, Number: 3},
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "b"}}, Number: 0},
+					},
+				},
+			}),
+			wantErrors: []string{
+				"spec.configuration.nodeAgent.loadConcurrency.perNodeConfig[1].nodeSelector: Invalid value: \"zone=a\": overlaps with spec.configuration.nodeAgent.loadConcurrency.perNodeConfig[0] on node worker-1",
+				"spec.configuration.nodeAgent.loadConcurrency.perNodeConfig[2].number: Invalid value: 0",
+				"spec.configuration.nodeAgent.loadConcurrency.perNodeConfig[2].nodeSelector: Invalid value: \"zone=b\": overlaps with spec.configuration.nodeAgent.loadConcurrency.perNodeConfig[0] on node worker-2",
+			},
+		},
+		{
+			name: "missing storage classes and volume snapshot class",
+			dpa: testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+				BackupPVCConfig: map[string]nodeagent.BackupPVC{
+					"nfs":     {StorageClass: "gp3-csi"},
+					"missing": {StorageClass: "also-missing"},
+				},
+			}),
+			wantErrors: []string{
+				"spec.configuration.nodeAgent.backupPVC[missing]: Not found: \"missing\"",
+				"spec.configuration.nodeAgent.backupPVC[missing].storageClass: Not found: \"also-missing\"",
+				"spec.configuration.nodeAgent.backupPVC[nfs]: Invalid value: \"nfs\": no VolumeSnapshotClass found for driver nfs.csi.k8s.io",
+			},
+		},
+		{
+			name: "invalid pod resources in repository maintenance",
+			dpa: func() *oadpv1alpha1.DataProtectionApplication {
+				dpa := testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{})
+				dpa.Spec.Configuration.RepositoryMaintenance = map[string]oadpv1alpha1.RepositoryMaintenanceConfig{
+					"global": {PodResources: &kube.PodResources{CPURequest: "lots", MemoryRequest: "2Gi", MemoryLimit: "1Gi"}},
+				}
+				return dpa
+			}(),
+			wantErrors: []string{
+				"spec.configuration.repositoryMaintenance[global].podResources.cpuRequest: Invalid value: \"lots\"",
+				"spec.configuration.repositoryMaintenance[global].podResources.memoryRequest: Invalid value: \"2Gi\": must be less than or equal to memoryLimit",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateConfigMapSettings(tt.dpa, testValidationContext())
+			if len(errs) != len(tt.wantErrors) {
+				t.Fatalf("expected %d errors, got %v", len(tt.wantErrors), errs)
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errs[i].Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileConfigMapsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		dpa        *oadpv1alpha1.DataProtectionApplication
+		objects    []client.Object
+		wantStatus *metav1.ConditionStatus
+	}{
+		{
+			name: "no ConfigMap settings, no condition",
+			dpa:  testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{}),
+		},
+		{
+			name: "storage class exists",
+			dpa: testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+				BackupPVCConfig: map[string]nodeagent.BackupPVC{"gp3-csi": {}},
+			}),
+			objects: []client.Object{
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3-csi"}, Provisioner: "ebs.csi.aws.com"},
+			},
+			wantStatus: ptr.To(metav1.ConditionTrue),
+		},
+		{
+			name: "storage class does not exist",
+			dpa: testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+				BackupPVCConfig: map[string]nodeagent.BackupPVC{"gp3-csi": {}},
+			}),
+			wantStatus: ptr.To(metav1.ConditionFalse),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, tt.dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Context:       context.Background(),
+				dpa:           tt.dpa,
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+			if _, err := r.ReconcileConfigMapsValidation(logr.Discard()); err != nil {
+				t.Fatalf("ReconcileConfigMapsValidation() error = %v", err)
+			}
+			condition := apimeta.FindStatusCondition(tt.dpa.Status.Conditions, oadpv1alpha1.ConditionConfigMapsValid)
+			if tt.wantStatus == nil {
+				if condition != nil {
+					t.Errorf("expected no condition, got %v", condition)
+				}
+				return
+			}
+			if condition == nil || condition.Status != *tt.wantStatus {
+				t.Errorf("expected condition status %v, got %v", *tt.wantStatus, condition)
+			}
+		})
+	}
+}
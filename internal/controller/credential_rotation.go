@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// credentialsHashesAnnotation records the hash of each credential secret used by the Velero and node-agent pods,
+// so a secret rotation rolls the pods and the plugins drop the credentials they cached
+const credentialsHashesAnnotation = "oadp.openshift.io/credentials-hashes"
+
+// getCredentialSecretNames returns the sorted names of the secrets holding the credentials of the DPA: the BSL and
+// VSL secrets, the default plugin secrets created by the STS flow, and the registry secrets
+func (r *DataProtectionApplicationReconciler) getCredentialSecretNames() []string {
+	dpa := r.dpa
+	names := map[string]bool{}
+
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.CredentialSource != nil {
+			continue
+		}
+		if bslSpec.CloudStorage != nil && bslSpec.CloudStorage.Credential != nil {
+			names[bslSpec.CloudStorage.Credential.Name] = true
+		}
+		if bslSpec.Velero != nil {
+			names[getLocationSecretName(bslSpec.Velero.Config, bslSpec.Velero.Credential, bslSpec.Velero.Provider)] = true
+			if dpa.BackupImages() {
+				bslName := fmt.Sprintf("%s-%d", dpa.Name, i+1)
+				if bslSpec.Name != "" {
+					bslName = bslSpec.Name
+				}
+				names["oadp-"+bslName+"-"+bslSpec.Velero.Provider+"-registry-secret"] = true
+			}
+		}
+	}
+	for _, vslSpec := range dpa.Spec.SnapshotLocations {
+		if vslSpec.CredentialSource != nil || vslSpec.Velero == nil {
+			continue
+		}
+		names[getLocationSecretName(vslSpec.Velero.Config, vslSpec.Velero.Credential, vslSpec.Velero.Provider)] = true
+	}
+	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil {
+		for _, plugin := range dpa.Spec.Configuration.Velero.DefaultPlugins {
+			names[credentials.PluginSpecificFields[plugin].SecretName] = true
+		}
+	}
+	names[stsflow.AzureWorkloadIdentitySecretName] = true
+
+	delete(names, "")
+	secretNames := make([]string, 0, len(names))
+	for name := range names {
+		secretNames = append(secretNames, name)
+	}
+	sort.Strings(secretNames)
+	return secretNames
+}
+
+// getLocationSecretName returns the name of the secret of a location, following the precedence of getSecretNameAndKey
+func getLocationSecretName(config map[string]string, credential *corev1.SecretKeySelector, provider string) string {
+	if credentialsFile, ok := config[CredentialsFileKey]; ok {
+		if secretName, _, err := credentials.GetSecretNameKeyFromCredentialsFileConfigString(credentialsFile); err == nil {
+			return secretName
+		}
+	}
+	if credential != nil {
+		return credential.Name
+	}
+	return credentials.PluginSpecificFields[oadpv1alpha1.DefaultPlugin(strings.TrimPrefix(provider, veleroIOPrefix))].SecretName
+}
+
+// getCredentialsHashes returns the hash of the data of each existing credential secret, by secret name
+func (r *DataProtectionApplicationReconciler) getCredentialsHashes() (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, secretName := range r.getCredentialSecretNames() {
+		secret := &corev1.Secret{}
+		if err := r.Get(r.Context, types.NamespacedName{Name: secretName, Namespace: r.dpa.Namespace}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		hashes[secretName] = hashSecretData(secret)
+	}
+	return hashes, nil
+}
+
+// hashSecretData returns the hash of the keys and values of a secret
+func hashSecretData(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[key])
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func formatCredentialsHashes(hashes map[string]string) string {
+	entries := make([]string, 0, len(hashes))
+	for secretName, hash := range hashes {
+		entries = append(entries, secretName+"="+hash)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func parseCredentialsHashes(annotation string) map[string]string {
+	hashes := map[string]string{}
+	for _, entry := range strings.Split(annotation, ",") {
+		if secretName, hash, found := strings.Cut(entry, "="); found {
+			hashes[secretName] = hash
+		}
+	}
+	return hashes
+}
+
+// appendCredentialsHashes records the hashes of the credential secrets in the pod template
+func (r *DataProtectionApplicationReconciler) appendCredentialsHashes(podTemplate *corev1.PodTemplateSpec) error {
+	hashes, err := r.getCredentialsHashes()
+	if err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		delete(podTemplate.Annotations, credentialsHashesAnnotation)
+		return nil
+	}
+	if podTemplate.Annotations == nil {
+		podTemplate.Annotations = map[string]string{}
+	}
+	podTemplate.Annotations[credentialsHashesAnnotation] = formatCredentialsHashes(hashes)
+	return nil
+}
+
+// ReconcileCredentialsRotation compares the credential secrets with the hashes recorded in the Velero Deployment
+// and records a rotation in a DPA event and condition. The rollout itself is triggered by the changed hashes in the
+// Velero and node-agent pod templates.
+func (r *DataProtectionApplicationReconciler) ReconcileCredentialsRotation(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	veleroDeployment := &appsv1.Deployment{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: common.Velero, Namespace: dpa.Namespace}, veleroDeployment); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	annotation, ok := veleroDeployment.Spec.Template.Annotations[credentialsHashesAnnotation]
+	if !ok {
+		return true, nil
+	}
+
+	hashes, err := r.getCredentialsHashes()
+	if err != nil {
+		return false, err
+	}
+	rotated := []string{}
+	for secretName, previousHash := range parseCredentialsHashes(annotation) {
+		if hash, found := hashes[secretName]; found && hash != previousHash {
+			rotated = append(rotated, secretName)
+		}
+	}
+	if len(rotated) == 0 {
+		return true, nil
+	}
+
+	sort.Strings(rotated)
+	message := fmt.Sprintf("credential secrets %s rotated, restarting Velero and node-agent", strings.Join(rotated, ", "))
+	log.Info(message)
+	r.EventRecorder.Event(dpa, corev1.EventTypeNormal, "CredentialsRotated", message)
+	// remove the condition first so the last transition time records this rotation
+	apimeta.RemoveStatusCondition(&dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsRotated)
+	apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+		Type:    oadpv1alpha1.ConditionCredentialsRotated,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.CredentialsRotatedReasonRolloutTriggered,
+		Message: message,
+	})
+	return true, nil
+}
+
+// credentialSecretPredicate passes the secret data changes veleroPredicate drops, as they do not bump the generation
+func credentialSecretPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSecret, oldOk := e.ObjectOld.(*corev1.Secret)
+			newSecret, newOk := e.ObjectNew.(*corev1.Secret)
+			if !oldOk || !newOk {
+				return false
+			}
+			return !reflect.DeepEqual(oldSecret.Data, newSecret.Data) || !reflect.DeepEqual(oldSecret.Labels, newSecret.Labels)
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDPAReconciler_getCredentialSecretNames(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{
+					DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS, oadpv1alpha1.DefaultPluginOpenShift},
+				},
+			},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{
+					Velero: &velerov1.BackupStorageLocationSpec{
+						Provider:   "aws",
+						Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "bsl-credentials"}, Key: "cloud"},
+					},
+				},
+				{
+					Name: "from-file",
+					Velero: &velerov1.BackupStorageLocationSpec{
+						Provider: "aws",
+						Config:   map[string]string{CredentialsFileKey: "file-credentials/cloud"},
+					},
+				},
+				{
+					Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws"},
+					CredentialSource: &oadpv1alpha1.CredentialSource{
+						SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+					},
+				},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+				{Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "gcp"}},
+			},
+		},
+	}
+	r := &DataProtectionApplicationReconciler{dpa: dpa}
+	want := []string{
+		"azure-workload-identity-env",
+		"bsl-credentials",
+		"cloud-credentials",
+		"cloud-credentials-gcp",
+		"file-credentials",
+		"oadp-from-file-aws-registry-secret",
+		"oadp-" + testDpaName + "-1-aws-registry-secret",
+	}
+	if got := r.getCredentialSecretNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("getCredentialSecretNames() = %v, want %v", got, want)
+	}
+
+	dpa.Spec.BackupImages = ptr.To(false)
+	want = []string{"azure-workload-identity-env", "bsl-credentials", "cloud-credentials", "cloud-credentials-gcp", "file-credentials"}
+	if got := r.getCredentialSecretNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("getCredentialSecretNames() without registry = %v, want %v", got, want)
+	}
+}
+
+func TestDPAReconciler_ReconcileCredentialsRotation(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: testNamespaceName},
+		Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=first\n")},
+	}
+	veleroDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: common.Velero, Namespace: testNamespaceName},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa, secret, veleroDeployment)
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: dpa, EventRecorder: recorder}
+
+	// hashes recorded in the Velero pod template
+	if err := r.appendCredentialsHashes(&veleroDeployment.Spec.Template); err != nil {
+		t.Fatalf("appendCredentialsHashes() error = %v", err)
+	}
+	firstHashes := veleroDeployment.Spec.Template.Annotations[credentialsHashesAnnotation]
+	if firstHashes == "" {
+		t.Fatalf("expected credentials hashes annotation to be set")
+	}
+	if err := r.Update(r.Context, veleroDeployment); err != nil {
+		t.Fatalf("failed to update velero deployment: %v", err)
+	}
+
+	if _, err := r.ReconcileCredentialsRotation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsRotation() error = %v", err)
+	}
+	if len(recorder.Events) != 0 || apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsRotated) != nil {
+		t.Errorf("expected no rotation to be reported for unchanged credentials")
+	}
+
+	// rotate the secret
+	secret.Data["cloud"] = []byte("[default]\naws_access_key_id=second\n")
+	if err := r.Update(r.Context, secret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	if _, err := r.ReconcileCredentialsRotation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsRotation() error = %v", err)
+	}
+	condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsRotated)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != oadpv1alpha1.CredentialsRotatedReasonRolloutTriggered {
+		t.Errorf("expected CredentialsRotated condition, got %v", condition)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a CredentialsRotated event, got %d events", len(recorder.Events))
+	}
+
+	if err := r.appendCredentialsHashes(&veleroDeployment.Spec.Template); err != nil {
+		t.Fatalf("appendCredentialsHashes() error = %v", err)
+	}
+	if veleroDeployment.Spec.Template.Annotations[credentialsHashesAnnotation] == firstHashes {
+		t.Errorf("expected credentials hashes annotation to change with the secret")
+	}
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+)
+
+// secretProviderClassPodStatusGVK is the Secrets Store CSI driver resource reporting the
+// versions of the objects mounted into a pod for a SecretProviderClass
+var secretProviderClassPodStatusGVK = schema.GroupVersionKind{
+	Group:   "secrets-store.csi.x-k8s.io",
+	Version: "v1",
+	Kind:    "SecretProviderClassPodStatusList",
+}
+
+// validateBackupLocationCredentialSource validates a BSL using a credentialSource. The credentials are only
+// readable by the plugins inside the pods, so the registry and the bucket controller cannot use them.
+func (r *DataProtectionApplicationReconciler) validateBackupLocationCredentialSource(yamlPath string, bslSpec *oadpv1alpha1.BackupLocation) error {
+	if bslSpec.Velero == nil {
+		return fmt.Errorf("DPA %s.credentialSource is only supported with the velero configuration", yamlPath)
+	}
+	if r.dpa.BackupImages() {
+		return fmt.Errorf("DPA %s.credentialSource requires spec.backupImages to be set to false", yamlPath)
+	}
+	return validateCredentialSource(bslSpec.CredentialSource, yamlPath, bslSpec.Velero.Credential, bslSpec.Velero.Config)
+}
+
+// validateCredentialSource validates the credentialSource of the location at yamlPath does not conflict with
+// the credential and the config of the location
+func validateCredentialSource(source *oadpv1alpha1.CredentialSource, yamlPath string, credential *corev1.SecretKeySelector, config map[string]string) error {
+	sourcePath := yamlPath + ".credentialSource"
+	if credential != nil {
+		return fmt.Errorf("DPA %s and %s.velero.credential cannot both be set", sourcePath, yamlPath)
+	}
+	if config[CredentialsFileKey] != "" {
+		return fmt.Errorf("DPA %s and %s.velero.config.%s cannot both be set", sourcePath, yamlPath, CredentialsFileKey)
+	}
+
+	var fileName string
+	switch {
+	case source.SecretProviderClass != nil && source.File != nil:
+		return fmt.Errorf("DPA %s must set only one of secretProviderClass or file", sourcePath)
+	case source.SecretProviderClass != nil:
+		if source.SecretProviderClass.Name == "" {
+			return fmt.Errorf("DPA %s.secretProviderClass.name cannot be empty", sourcePath)
+		}
+		fileName = source.SecretProviderClass.FileName
+	case source.File != nil:
+		if path.Clean(source.File.Path) != source.File.Path || !strings.HasPrefix(source.File.Path, credentials.FileCredentialSourcePathPrefix+"/") {
+			return fmt.Errorf("DPA %s.file.path %q must be a directory under %s", sourcePath, source.File.Path, credentials.FileCredentialSourcePathPrefix)
+		}
+		fileName = source.File.FileName
+	default:
+		return fmt.Errorf("DPA %s must set one of secretProviderClass or file", sourcePath)
+	}
+	if fileName == "" || strings.Contains(fileName, "/") {
+		return fmt.Errorf("DPA %s fileName %q must be a file name without a directory", sourcePath, fileName)
+	}
+	return nil
+}
+
+// credentialSourceConfig returns a copy of the location config passing the mounted credentials file to the
+// plugin. The config map of the DPA spec is not modified.
+func credentialSourceConfig(config map[string]string, mount credentials.CredentialSourceMount) map[string]string {
+	config = maps.Clone(config)
+	if config == nil {
+		config = map[string]string{}
+	}
+	config[CredentialsFileKey] = mount.FilePath()
+	return config
+}
+
+// ReconcileCredentialSources reports in the DPA status whether the credential sources of the locations are
+// mounted in the Velero pod and, for SecretProviderClass sources, the mounted version, so secret rotation
+// by the Secrets Store CSI driver can be followed.
+func (r *DataProtectionApplicationReconciler) ReconcileCredentialSources(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	mounts := credentials.GetCredentialSourceMounts(dpa)
+	if len(mounts) == 0 {
+		dpa.Status.CredentialSources = nil
+		return true, nil
+	}
+
+	mountedVolumes := map[string]bool{}
+	runningPods := map[string]bool{}
+	veleroPods := &corev1.PodList{}
+	if err := r.List(r.Context, veleroPods, client.InNamespace(dpa.Namespace), client.MatchingLabels(getDpaAppLabels(dpa))); err != nil {
+		return false, err
+	}
+	for _, pod := range veleroPods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		runningPods[pod.Name] = true
+		for _, volume := range pod.Spec.Volumes {
+			mountedVolumes[volume.Name] = true
+		}
+	}
+
+	versions, err := r.getSecretProviderClassVersions(runningPods)
+	if err != nil {
+		return false, err
+	}
+
+	previous := map[string]oadpv1alpha1.CredentialSourceStatus{}
+	for _, status := range dpa.Status.CredentialSources {
+		previous[status.Kind+"/"+status.Location] = status
+	}
+
+	statuses := make([]oadpv1alpha1.CredentialSourceStatus, 0, len(mounts))
+	for _, mount := range mounts {
+		status := oadpv1alpha1.CredentialSourceStatus{
+			Location: mount.Location,
+			Kind:     mount.Kind,
+			Type:     mount.Type(),
+			Path:     mount.FilePath(),
+			Mounted:  mountedVolumes[mount.VolumeName()],
+		}
+		last := previous[mount.Kind+"/"+mount.Location]
+		status.LastRotationTime = last.LastRotationTime
+
+		if mount.Source.SecretProviderClass != nil {
+			status.Version = versions[mount.Source.SecretProviderClass.Name]
+			switch {
+			case status.Version == "":
+				status.Message = fmt.Sprintf("no version reported by the Secrets Store CSI driver for SecretProviderClass %s", mount.Source.SecretProviderClass.Name)
+			case last.Version != "" && last.Version != status.Version:
+				status.LastRotationTime = ptr.To(metav1.Now())
+				log.Info("credentials rotated", "kind", mount.Kind, "location", mount.Location)
+				r.EventRecorder.Event(dpa,
+					corev1.EventTypeNormal,
+					"CredentialsRotated",
+					fmt.Sprintf("credentials of %s %s/%s rotated by SecretProviderClass %s", mount.Kind, dpa.Namespace, mount.Location, mount.Source.SecretProviderClass.Name),
+				)
+			}
+		} else {
+			status.Message = "credentials file is rotated in place on the node, the plugins read it on each use"
+		}
+		statuses = append(statuses, status)
+	}
+	dpa.Status.CredentialSources = statuses
+	return true, nil
+}
+
+// getSecretProviderClassVersions returns the versions of the objects mounted into the given pods for each
+// SecretProviderClass. Nothing is returned when the Secrets Store CSI driver is not installed.
+func (r *DataProtectionApplicationReconciler) getSecretProviderClassVersions(pods map[string]bool) (map[string]string, error) {
+	podStatusList := &unstructured.UnstructuredList{}
+	podStatusList.SetGroupVersionKind(secretProviderClassPodStatusGVK)
+	if err := r.List(r.Context, podStatusList, client.InNamespace(r.dpa.Namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	objectVersions := map[string]map[string]string{}
+	for _, podStatus := range podStatusList.Items {
+		secretProviderClass, _, _ := unstructured.NestedString(podStatus.Object, "status", "secretProviderClassName")
+		podName, _, _ := unstructured.NestedString(podStatus.Object, "status", "podName")
+		mounted, _, _ := unstructured.NestedBool(podStatus.Object, "status", "mounted")
+		if secretProviderClass == "" || !mounted || !pods[podName] {
+			continue
+		}
+		objects, _, _ := unstructured.NestedSlice(podStatus.Object, "status", "objects")
+		for _, object := range objects {
+			fields, ok := object.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _, _ := unstructured.NestedString(fields, "id")
+			version, _, _ := unstructured.NestedString(fields, "version")
+			if objectVersions[secretProviderClass] == nil {
+				objectVersions[secretProviderClass] = map[string]string{}
+			}
+			objectVersions[secretProviderClass][id] = version
+		}
+	}
+
+	versions := map[string]string{}
+	for secretProviderClass, objects := range objectVersions {
+		entries := make([]string, 0, len(objects))
+		for id, version := range objects {
+			entries = append(entries, id+":"+version)
+		}
+		sort.Strings(entries)
+		versions[secretProviderClass] = strings.Join(entries, ",")
+	}
+	return versions, nil
+}
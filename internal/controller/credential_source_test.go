@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+)
+
+func TestValidateCredentialSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     *oadpv1alpha1.CredentialSource
+		credential *corev1.SecretKeySelector
+		config     map[string]string
+		wantErr    bool
+	}{
+		{
+			name: "valid secretProviderClass",
+			source: &oadpv1alpha1.CredentialSource{
+				SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+			},
+		},
+		{
+			name: "valid file",
+			source: &oadpv1alpha1.CredentialSource{
+				File: &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials/vault", FileName: "cloud"},
+			},
+		},
+		{
+			name:    "no source set",
+			source:  &oadpv1alpha1.CredentialSource{},
+			wantErr: true,
+		},
+		{
+			name: "both sources set",
+			source: &oadpv1alpha1.CredentialSource{
+				SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+				File:                &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials/vault", FileName: "cloud"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative file path",
+			source: &oadpv1alpha1.CredentialSource{
+				File: &oadpv1alpha1.FileCredentialSource{Path: "vault", FileName: "cloud"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file path outside of the credentials directory",
+			source: &oadpv1alpha1.CredentialSource{
+				File: &oadpv1alpha1.FileCredentialSource{Path: "/etc/kubernetes", FileName: "kubeconfig"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file path escaping the credentials directory",
+			source: &oadpv1alpha1.CredentialSource{
+				File: &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials/../../../etc", FileName: "shadow"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file path of the credentials directory itself",
+			source: &oadpv1alpha1.CredentialSource{
+				File: &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials", FileName: "cloud"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file name with a directory",
+			source: &oadpv1alpha1.CredentialSource{
+				SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "../cloud"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "credential secret also set",
+			source: &oadpv1alpha1.CredentialSource{
+				SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+			},
+			credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"}, Key: "cloud"},
+			wantErr:    true,
+		},
+		{
+			name: "credentialsFile config also set",
+			source: &oadpv1alpha1.CredentialSource{
+				SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+			},
+			config:  map[string]string{CredentialsFileKey: "cloud-credentials/cloud"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCredentialSource(tt.source, "spec.backupLocations[0]", tt.credential, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCredentialSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ValidateBackupStorageLocationsCredentialSource(t *testing.T) {
+	newDpa := func(backupImages bool) *oadpv1alpha1.DataProtectionApplication {
+		return &oadpv1alpha1.DataProtectionApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+			Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+				},
+				BackupImages: ptr.To(backupImages),
+				BackupLocations: []oadpv1alpha1.BackupLocation{
+					{
+						Velero: &velerov1.BackupStorageLocationSpec{
+							Provider: "aws",
+							Default:  true,
+							Config:   map[string]string{"region": "us-east-1"},
+							StorageType: velerov1.StorageType{
+								ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket", Prefix: "prefix"},
+							},
+						},
+						CredentialSource: &oadpv1alpha1.CredentialSource{
+							SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+						},
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		dpa     *oadpv1alpha1.DataProtectionApplication
+		wantErr bool
+	}{
+		{
+			name: "credential source without a secret",
+			dpa:  newDpa(false),
+		},
+		{
+			name:    "credential source requires backupImages false",
+			dpa:     newDpa(true),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.dpa)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: tt.dpa, Log: logr.Discard()}
+			if _, err := r.ValidateBackupStorageLocations(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBackupStorageLocations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCredentialSourceConfig(t *testing.T) {
+	config := map[string]string{"region": "us-east-1"}
+	mount := credentials.NewBackupLocationCredentialSourceMount("dpa-1", &oadpv1alpha1.CredentialSource{
+		SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+	})
+	got := credentialSourceConfig(config, mount)
+	if got[CredentialsFileKey] != "/credential-sources/bsl/dpa-1/cloud" || got["region"] != "us-east-1" {
+		t.Errorf("unexpected config %v", got)
+	}
+	if _, ok := config[CredentialsFileKey]; ok {
+		t.Errorf("expected DPA spec config to be left unchanged, got %v", config)
+	}
+}
+
+func TestDPAReconciler_ReconcileCredentialSources(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{
+					Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws"},
+					CredentialSource: &oadpv1alpha1.CredentialSource{
+						SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+					},
+				},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+				{
+					Name:   "snapshots",
+					Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "aws"},
+					CredentialSource: &oadpv1alpha1.CredentialSource{
+						File: &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials/vault", FileName: "cloud"},
+					},
+				},
+			},
+		},
+	}
+	podTemplate := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "velero"}}},
+	}
+	credentials.AppendCredentialSourceVolumes(dpa, podTemplate, "velero")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "velero-1", Namespace: testNamespaceName, Labels: getDpaAppLabels(dpa)},
+		Spec:       podTemplate.Spec,
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa, pod)
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: dpa, EventRecorder: recorder}
+
+	setVersion := func(version string) {
+		podStatus := &unstructured.Unstructured{}
+		podStatus.SetGroupVersionKind(schema.GroupVersionKind{Group: "secrets-store.csi.x-k8s.io", Version: "v1", Kind: "SecretProviderClassPodStatus"})
+		podStatus.SetName("velero-1-" + testNamespaceName + "-vault-aws")
+		podStatus.SetNamespace(testNamespaceName)
+		podStatus.Object["status"] = map[string]interface{}{
+			"podName":                 "velero-1",
+			"secretProviderClassName": "vault-aws",
+			"mounted":                 true,
+			"objects":                 []interface{}{map[string]interface{}{"id": "secret/aws", "version": version}},
+		}
+		existing := podStatus.DeepCopy()
+		if err := r.Get(r.Context, client.ObjectKeyFromObject(podStatus), existing); err == nil {
+			podStatus.SetResourceVersion(existing.GetResourceVersion())
+			if err := r.Update(r.Context, podStatus); err != nil {
+				t.Fatalf("failed to update pod status: %v", err)
+			}
+		} else if err := r.Create(r.Context, podStatus); err != nil {
+			t.Fatalf("failed to create pod status: %v", err)
+		}
+	}
+
+	setVersion("1")
+	if _, err := r.ReconcileCredentialSources(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialSources() error = %v", err)
+	}
+	if len(dpa.Status.CredentialSources) != 2 {
+		t.Fatalf("expected 2 credential sources, got %v", dpa.Status.CredentialSources)
+	}
+	bslStatus := dpa.Status.CredentialSources[0]
+	if !bslStatus.Mounted || bslStatus.Version != "secret/aws:1" || bslStatus.LastRotationTime != nil || bslStatus.Path != "/credential-sources/bsl/"+testDpaName+"-1/cloud" {
+		t.Errorf("unexpected backup location credential source status %v", bslStatus)
+	}
+	if vslStatus := dpa.Status.CredentialSources[1]; !vslStatus.Mounted || vslStatus.Type != credentials.CredentialSourceTypeFile || vslStatus.Location != "snapshots" {
+		t.Errorf("unexpected snapshot location credential source status %v", vslStatus)
+	}
+
+	setVersion("2")
+	if _, err := r.ReconcileCredentialSources(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialSources() error = %v", err)
+	}
+	if bslStatus := dpa.Status.CredentialSources[0]; bslStatus.Version != "secret/aws:2" || bslStatus.LastRotationTime == nil {
+		t.Errorf("expected rotation to be reported, got %v", bslStatus)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a CredentialsRotated event, got %d events", len(recorder.Events))
+	}
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+)
+
+const (
+	// credentialsHashAnnotation and credentialsChangedAnnotation record on a credentials secret the hash of its data
+	// and when the operator saw it change, so the key age survives operator restarts
+	credentialsHashAnnotation    = "oadp.openshift.io/credentials-hash"
+	credentialsChangedAnnotation = "oadp.openshift.io/credentials-changed"
+)
+
+// tokenRefreshError matches the errors of the cloud providers in the Velero backup location status when a
+// short-lived token can not be exchanged or refreshed
+var tokenRefreshError = regexp.MustCompile(`WebIdentityErr|InvalidIdentityToken|ExpiredToken|AADSTS\d+|invalid_grant|sts\.googleapis\.com`)
+
+// backupLocationCredentialsState is the state of the credentials of a backup location exported as metrics
+type backupLocationCredentialsState struct {
+	provider        string
+	credentialsType credentials.CredentialsType
+	// changed is when the operator saw the credentials secret change
+	changed time.Time
+	// lastSuccess is the last time Velero validated the backup location with the credentials
+	lastSuccess time.Time
+}
+
+// credentialsCollector exports the backup location credentials of each DPA. The age is computed when the metrics
+// are collected, so it keeps growing between two reconciles.
+type credentialsCollector struct {
+	mutex     sync.Mutex
+	locations map[types.NamespacedName]map[string]backupLocationCredentialsState
+}
+
+var (
+	credentialsInfoDesc = prometheus.NewDesc("oadp_backup_location_credentials_info",
+		"Type of the credentials of a backup location, static keys or short-lived tokens of a federated identity",
+		[]string{"namespace", "dpa", "location", "provider", "type"}, nil)
+	credentialsAgeDesc = prometheus.NewDesc("oadp_backup_location_credentials_age_seconds",
+		"Time since the operator saw the credentials secret of a backup location change",
+		[]string{"namespace", "dpa", "location"}, nil)
+	credentialsLastSuccessDesc = prometheus.NewDesc("oadp_backup_location_credentials_last_success_timestamp_seconds",
+		"Last time Velero validated a backup location with its credentials",
+		[]string{"namespace", "dpa", "location"}, nil)
+
+	credentialsMetrics = &credentialsCollector{locations: map[types.NamespacedName]map[string]backupLocationCredentialsState{}}
+)
+
+func init() {
+	metrics.Registry.MustRegister(credentialsMetrics)
+}
+
+func (c *credentialsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- credentialsInfoDesc
+	ch <- credentialsAgeDesc
+	ch <- credentialsLastSuccessDesc
+}
+
+func (c *credentialsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for dpa, locations := range c.locations {
+		for location, state := range locations {
+			ch <- prometheus.MustNewConstMetric(credentialsInfoDesc, prometheus.GaugeValue, 1,
+				dpa.Namespace, dpa.Name, location, state.provider, string(state.credentialsType))
+			if !state.changed.IsZero() {
+				ch <- prometheus.MustNewConstMetric(credentialsAgeDesc, prometheus.GaugeValue, time.Since(state.changed).Seconds(),
+					dpa.Namespace, dpa.Name, location)
+			}
+			if !state.lastSuccess.IsZero() {
+				ch <- prometheus.MustNewConstMetric(credentialsLastSuccessDesc, prometheus.GaugeValue, float64(state.lastSuccess.Unix()),
+					dpa.Namespace, dpa.Name, location)
+			}
+		}
+	}
+}
+
+// get returns the exported state of the backup locations of a DPA
+func (c *credentialsCollector) get(dpa types.NamespacedName) map[string]backupLocationCredentialsState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.locations[dpa]
+}
+
+// set replaces the exported state of the backup locations of a DPA, removing the DPA when locations is empty
+func (c *credentialsCollector) set(dpa types.NamespacedName, locations map[string]backupLocationCredentialsState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(locations) == 0 {
+		delete(c.locations, dpa)
+		return
+	}
+	c.locations[dpa] = locations
+}
+
+// ReconcileCredentialsPolicy exports the type, age and last successful use of the backup location credentials as
+// metrics and, when spec.configuration.credentialsPolicy is set, reports static keys older than the policy and
+// failing short-lived token refreshes in the CredentialsPolicyCompliant condition
+func (r *DataProtectionApplicationReconciler) ReconcileCredentialsPolicy(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	key := types.NamespacedName{Name: dpa.Name, Namespace: dpa.Namespace}
+	previous := credentialsMetrics.get(key)
+	locations := map[string]backupLocationCredentialsState{}
+	tooOld, failing := []string{}, []string{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.Velero == nil || bslSpec.CredentialSource != nil {
+			continue
+		}
+		bslName := fmt.Sprintf("%s-%d", dpa.Name, i+1)
+		if bslSpec.Name != "" {
+			bslName = bslSpec.Name
+		}
+		provider := strings.TrimPrefix(bslSpec.Velero.Provider, veleroIOPrefix)
+		secretName, secretKey := credentials.GetSecretNameAndKey(bslSpec.Velero, oadpv1alpha1.DefaultPlugin(provider))
+		secret := &corev1.Secret{}
+		if err := r.Get(r.Context, types.NamespacedName{Name: secretName, Namespace: dpa.Namespace}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		changed, err := r.getCredentialsChanged(secret)
+		if err != nil {
+			return false, err
+		}
+		state := backupLocationCredentialsState{
+			provider:        provider,
+			credentialsType: credentials.GetCredentialsType(oadpv1alpha1.DefaultPlugin(provider), secret.Data[secretKey], bslSpec.Velero.Config[AWSProfile]),
+			changed:         changed,
+			lastSuccess:     previous[bslName].lastSuccess,
+		}
+
+		bsl := &velerov1.BackupStorageLocation{}
+		if err := r.Get(r.Context, types.NamespacedName{Name: bslName, Namespace: dpa.Namespace}, bsl); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		switch {
+		case bsl.Status.Phase == velerov1.BackupStorageLocationPhaseAvailable && bsl.Status.LastValidationTime != nil:
+			state.lastSuccess = bsl.Status.LastValidationTime.Time
+		case bsl.Status.Phase == velerov1.BackupStorageLocationPhaseUnavailable && state.credentialsType == credentials.CredentialsTypeShortLived &&
+			tokenRefreshError.MatchString(bsl.Status.Message):
+			failing = append(failing, fmt.Sprintf("backup location %s: %s", bslName, bsl.Status.Message))
+		}
+
+		policy := dpa.Spec.Configuration.CredentialsPolicy
+		if policy != nil && policy.StaticKeyMaxAge != nil && state.credentialsType == credentials.CredentialsTypeStatic {
+			if age := time.Since(changed); age > policy.StaticKeyMaxAge.Duration {
+				tooOld = append(tooOld, fmt.Sprintf("backup location %s uses secret %s unchanged for %s", bslName, secretName, age.Round(time.Hour)))
+			}
+		}
+		locations[bslName] = state
+	}
+	credentialsMetrics.set(key, locations)
+
+	policy := dpa.Spec.Configuration.CredentialsPolicy
+	if policy == nil {
+		apimeta.RemoveStatusCondition(&dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsPolicyCompliant)
+		return true, nil
+	}
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionCredentialsPolicyCompliant,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.CredentialsPolicyCompliantReasonCompliant,
+		Message: "backup location credentials comply with the credentials policy",
+	}
+	switch {
+	case len(failing) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.CredentialsPolicyCompliantReasonTokenRefreshFailing
+		condition.Message = "short-lived token refresh is failing for " + strings.Join(failing, "; ")
+	case len(tooOld) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.CredentialsPolicyCompliantReasonStaticKeyTooOld
+		condition.Message = fmt.Sprintf("static keys are older than %s: %s", policy.StaticKeyMaxAge.Duration, strings.Join(tooOld, "; "))
+	}
+	if previous := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsPolicyCompliant); condition.Status == metav1.ConditionFalse &&
+		(previous == nil || previous.Reason != condition.Reason || previous.Message != condition.Message) {
+		log.Info(condition.Message)
+		r.EventRecorder.Event(dpa, corev1.EventTypeWarning, condition.Reason, condition.Message)
+	}
+	apimeta.SetStatusCondition(&dpa.Status.Conditions, condition)
+	return true, nil
+}
+
+// getCredentialsChanged returns when the operator saw the data of a credentials secret change, recording the hash
+// of the data on the secret. The creation time of the secret is used the first time it is seen.
+func (r *DataProtectionApplicationReconciler) getCredentialsChanged(secret *corev1.Secret) (time.Time, error) {
+	hash := hashSecretData(secret)
+	if secret.Annotations[credentialsHashAnnotation] == hash {
+		if changed, err := time.Parse(time.RFC3339, secret.Annotations[credentialsChangedAnnotation]); err == nil {
+			return changed, nil
+		}
+	}
+	changed := secret.CreationTimestamp.Time
+	if _, seen := secret.Annotations[credentialsHashAnnotation]; seen || changed.IsZero() {
+		changed = time.Now()
+	}
+	original := secret.DeepCopy()
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[credentialsHashAnnotation] = hash
+	secret.Annotations[credentialsChangedAnnotation] = changed.UTC().Format(time.RFC3339)
+	if err := r.Patch(r.Context, secret, client.MergeFrom(original)); err != nil {
+		return time.Time{}, err
+	}
+	return changed.Truncate(time.Second), nil
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+func TestDPAReconciler_ReconcileCredentialsPolicy(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero:            &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+				CredentialsPolicy: &oadpv1alpha1.CredentialsPolicy{StaticKeyMaxAge: &metav1.Duration{Duration: 90 * 24 * time.Hour}},
+			},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{Name: "static", Velero: &velerov1.BackupStorageLocationSpec{Provider: AWSProvider}},
+				{
+					Name: "sts",
+					Velero: &velerov1.BackupStorageLocationSpec{
+						Provider:   AWSProvider,
+						Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "sts-credentials"}, Key: "credentials"},
+					},
+				},
+			},
+		},
+	}
+	staticSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "cloud-credentials",
+			Namespace:         testNamespaceName,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-100 * 24 * time.Hour)),
+		},
+		Data: map[string][]byte{"cloud": []byte(testAWSCredentials)},
+	}
+	stsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts-credentials", Namespace: testNamespaceName},
+		Data:       map[string][]byte{"credentials": []byte(stsflow.AWSSecretData("arn:aws:iam::123456789012:role/velero")["credentials"])},
+	}
+	lastValidation := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	staticBSL := &velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "static", Namespace: testNamespaceName},
+		Status:     velerov1.BackupStorageLocationStatus{Phase: velerov1.BackupStorageLocationPhaseAvailable, LastValidationTime: &lastValidation},
+	}
+	stsBSL := &velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: testNamespaceName},
+		Status: velerov1.BackupStorageLocationStatus{
+			Phase:   velerov1.BackupStorageLocationPhaseUnavailable,
+			Message: "BackupStorageLocation \"sts\" is unavailable: WebIdentityErr: failed to retrieve credentials",
+		},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa, staticSecret, stsSecret, staticBSL, stsBSL)
+	if err != nil {
+		t.Fatalf("error in creating fake client, likely programmer error")
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: dpa, EventRecorder: recorder}
+	key := types.NamespacedName{Name: testDpaName, Namespace: testNamespaceName}
+	defer credentialsMetrics.set(key, nil)
+
+	if _, err := r.ReconcileCredentialsPolicy(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsPolicy() error = %v", err)
+	}
+	condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsPolicyCompliant)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != oadpv1alpha1.CredentialsPolicyCompliantReasonTokenRefreshFailing ||
+		!strings.Contains(condition.Message, "backup location sts: ") {
+		t.Errorf("expected TokenRefreshFailing condition, got %v", condition)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected one event, got %d", len(recorder.Events))
+	}
+	locations := credentialsMetrics.get(key)
+	if locations["static"].credentialsType != credentials.CredentialsTypeStatic || !locations["static"].lastSuccess.Equal(lastValidation.Time) ||
+		locations["sts"].credentialsType != credentials.CredentialsTypeShortLived || !locations["sts"].lastSuccess.IsZero() {
+		t.Errorf("unexpected exported credentials %v", locations)
+	}
+	if count := testutil.CollectAndCount(credentialsMetrics, "oadp_backup_location_credentials_info"); count != 2 {
+		t.Errorf("expected 2 credentials info metrics, got %d", count)
+	}
+
+	// the age of the secret is recorded from its creation
+	got := &corev1.Secret{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "cloud-credentials", Namespace: testNamespaceName}, got); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if got.Annotations[credentialsHashAnnotation] != hashSecretData(got) || got.Annotations[credentialsChangedAnnotation] != staticSecret.CreationTimestamp.UTC().Format(time.RFC3339) {
+		t.Errorf("unexpected secret annotations %v", got.Annotations)
+	}
+
+	// the token refresh recovers, the static key is still too old
+	stsBSL.Status = velerov1.BackupStorageLocationStatus{Phase: velerov1.BackupStorageLocationPhaseAvailable, LastValidationTime: &lastValidation}
+	if err := fakeClient.Update(context.Background(), stsBSL); err != nil {
+		t.Fatalf("failed to update backup location: %v", err)
+	}
+	if _, err := r.ReconcileCredentialsPolicy(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsPolicy() error = %v", err)
+	}
+	condition = apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsPolicyCompliant)
+	if condition == nil || condition.Reason != oadpv1alpha1.CredentialsPolicyCompliantReasonStaticKeyTooOld ||
+		!strings.Contains(condition.Message, "backup location static uses secret cloud-credentials unchanged for 2400h0m0s") {
+		t.Errorf("expected StaticKeyTooOld condition, got %v", condition)
+	}
+
+	// a rotated key restarts the age
+	got.Data["cloud"] = []byte(testAWSCredentials + "\n")
+	if err := fakeClient.Update(context.Background(), got); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	if _, err := r.ReconcileCredentialsPolicy(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsPolicy() error = %v", err)
+	}
+	condition = apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsPolicyCompliant)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != oadpv1alpha1.CredentialsPolicyCompliantReasonCompliant {
+		t.Errorf("expected Compliant condition, got %v", condition)
+	}
+	if age := time.Since(credentialsMetrics.get(key)["static"].changed); age > time.Minute {
+		t.Errorf("expected the age to restart, got %s", age)
+	}
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+const (
+	defaultCredentialsValidationInterval = time.Hour
+	minCredentialsValidationInterval     = 5 * time.Minute
+	// credentialsCheckTimeout bounds each call to the cloud provider, so an unreachable endpoint does not block the reconcile
+	credentialsCheckTimeout = 30 * time.Second
+)
+
+// errCredentialsSecretNotFound is reported for a backup location whose credentials secret does not exist
+var errCredentialsSecretNotFound = errors.New("credentials secret not found")
+
+// cloudCredentialsCheck is the credentials of a backup location checked with the cloud provider
+type cloudCredentialsCheck struct {
+	provider string
+	data     []byte
+	// config is the backup location config, holding the AWS profile and region and the Azure storage account
+	config map[string]string
+	// err is set when the credentials could not be read, the cloud provider is then not called
+	err error
+}
+
+// credentialsCheckRecord is the last credentials check of a DPA, kept to rate limit the calls to the cloud provider
+type credentialsCheckRecord struct {
+	time       time.Time
+	generation int64
+	hashes     string
+	condition  metav1.Condition
+}
+
+// checkCloudCredentials calls the cloud provider with the credentials, replaced in tests
+var checkCloudCredentials = func(ctx context.Context, check cloudCredentialsCheck) error {
+	switch check.provider {
+	case AWSProvider:
+		return cloudprovider.CheckAWSCredentials(ctx, check.data, check.config[AWSProfile], check.config[Region])
+	case GCPProvider:
+		return cloudprovider.CheckGCPCredentials(ctx, check.data)
+	case AzureProvider:
+		creds := cloudprovider.ParseAzureCredentialsFile(check.data)
+		if storageAccount := check.config[StorageAccount]; storageAccount != "" {
+			creds.StorageAccountName = storageAccount
+		}
+		tokenFilePath := stsflow.WebIdentityTokenPath
+		if creds.FederatedTokenFile != "" {
+			tokenFilePath = creds.FederatedTokenFile
+		}
+		return cloudprovider.CheckAzureCredentials(ctx, creds, tokenFilePath)
+	}
+	return cloudprovider.ErrCredentialsCheckUnsupported
+}
+
+// ReconcileCredentialsValidation checks the backup location credentials with the cloud provider when
+// spec.configuration.credentialsValidation is set, and reports the result in the CredentialsValid condition.
+// Unchanged credentials are checked at most once per interval.
+func (r *DataProtectionApplicationReconciler) ReconcileCredentialsValidation(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	key := types.NamespacedName{Name: dpa.Name, Namespace: dpa.Namespace}
+	credentialsValidation := dpa.Spec.Configuration.CredentialsValidation
+	if credentialsValidation == nil {
+		delete(r.credentialsChecks, key)
+		apimeta.RemoveStatusCondition(&dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid)
+		return true, nil
+	}
+
+	hashes, err := r.getCredentialsHashes()
+	if err != nil {
+		return false, err
+	}
+	formattedHashes := formatCredentialsHashes(hashes)
+	if record, found := r.credentialsChecks[key]; found && record.generation == dpa.Generation && record.hashes == formattedHashes &&
+		time.Since(record.time) < getCredentialsValidationInterval(dpa) {
+		// the status is read from the cluster on each reconcile, keep the result of the last check
+		apimeta.SetStatusCondition(&dpa.Status.Conditions, record.condition)
+		return true, nil
+	}
+
+	checks, err := r.getCloudCredentialsChecks()
+	if err != nil {
+		return false, err
+	}
+	condition := r.checkCloudCredentials(log, checks)
+	previous := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid)
+	if condition.Status == metav1.ConditionFalse && (previous == nil || previous.Message != condition.Message) {
+		r.EventRecorder.Event(dpa, corev1.EventTypeWarning, "CredentialsInvalid", condition.Message)
+	}
+	apimeta.SetStatusCondition(&dpa.Status.Conditions, condition)
+	if r.credentialsChecks == nil {
+		r.credentialsChecks = map[types.NamespacedName]credentialsCheckRecord{}
+	}
+	r.credentialsChecks[key] = credentialsCheckRecord{
+		time:       time.Now(),
+		generation: dpa.Generation,
+		hashes:     formattedHashes,
+		condition:  condition,
+	}
+	return true, nil
+}
+
+// getCloudCredentialsChecks returns the credentials to check by backup location name. Locations using a
+// credentialSource, a CloudStorage or an S3 compatible storage are left out. A missing credentials secret is
+// reported for its location instead of failing the reconcile.
+func (r *DataProtectionApplicationReconciler) getCloudCredentialsChecks() (map[string]cloudCredentialsCheck, error) {
+	dpa := r.dpa
+	checks := map[string]cloudCredentialsCheck{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.Velero == nil || bslSpec.CredentialSource != nil {
+			continue
+		}
+		provider := strings.TrimPrefix(bslSpec.Velero.Provider, veleroIOPrefix)
+		if provider == AWSProvider && bslSpec.Velero.Config[S3URL] != "" && !strings.Contains(bslSpec.Velero.Config[S3URL], "amazonaws.com") {
+			continue
+		}
+		bslName := fmt.Sprintf("%s-%d", dpa.Name, i+1)
+		if bslSpec.Name != "" {
+			bslName = bslSpec.Name
+		}
+		secretName, secretKey := credentials.GetSecretNameAndKey(bslSpec.Velero, oadpv1alpha1.DefaultPlugin(provider))
+		secret := &corev1.Secret{}
+		if err := r.Get(r.Context, types.NamespacedName{Name: secretName, Namespace: dpa.Namespace}, secret); err != nil {
+			if !k8serror.IsNotFound(err) {
+				return nil, err
+			}
+			checks[bslName] = cloudCredentialsCheck{provider: provider, err: fmt.Errorf("%w: %s/%s", errCredentialsSecretNotFound, dpa.Namespace, secretName)}
+			continue
+		}
+		checks[bslName] = cloudCredentialsCheck{provider: provider, data: secret.Data[secretKey], config: bslSpec.Velero.Config}
+	}
+	return checks, nil
+}
+
+// checkCloudCredentials checks the credentials of each backup location and returns the CredentialsValid condition,
+// with the error code of the cloud provider for rejected credentials
+func (r *DataProtectionApplicationReconciler) checkCloudCredentials(log logr.Logger, checks map[string]cloudCredentialsCheck) metav1.Condition {
+	valid, invalid, failed := []string{}, []string{}, []string{}
+	for _, bslName := range sortedKeys(checks) {
+		err := checks[bslName].err
+		if err == nil {
+			ctx, cancel := context.WithTimeout(r.Context, credentialsCheckTimeout)
+			err = checkCloudCredentials(ctx, checks[bslName])
+			cancel()
+		}
+		var credentialsError *cloudprovider.CredentialsError
+		switch {
+		case err == nil:
+			valid = append(valid, bslName)
+		case errors.Is(err, cloudprovider.ErrCredentialsCheckUnsupported):
+			log.Info("credentials of backup location not checked", "location", bslName, "reason", err.Error())
+		case errors.As(err, &credentialsError), errors.Is(err, errCredentialsSecretNotFound):
+			invalid = append(invalid, fmt.Sprintf("backup location %s: %v", bslName, err))
+		default:
+			failed = append(failed, fmt.Sprintf("backup location %s: %v", bslName, err))
+		}
+	}
+
+	condition := metav1.Condition{Type: oadpv1alpha1.ConditionCredentialsValid}
+	switch {
+	case len(invalid) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.CredentialsValidReasonInvalid
+		condition.Message = strings.Join(append(invalid, failed...), "; ")
+	case len(failed) > 0:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = oadpv1alpha1.CredentialsValidReasonCheckFailed
+		condition.Message = strings.Join(failed, "; ")
+	case len(valid) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = oadpv1alpha1.CredentialsValidReasonValid
+		condition.Message = fmt.Sprintf("credentials of backup locations %s are valid", strings.Join(valid, ", "))
+	default:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = oadpv1alpha1.CredentialsValidReasonNotChecked
+		condition.Message = "no backup location credentials can be checked with the cloud provider"
+	}
+	return condition
+}
+
+// getCredentialsValidationInterval returns the minimum time between two checks of unchanged credentials
+func getCredentialsValidationInterval(dpa *oadpv1alpha1.DataProtectionApplication) time.Duration {
+	credentialsValidation := dpa.Spec.Configuration.CredentialsValidation
+	if credentialsValidation == nil || credentialsValidation.Interval == nil {
+		return defaultCredentialsValidationInterval
+	}
+	return credentialsValidation.Interval.Duration
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+)
+
+func TestDPAReconciler_ReconcileCredentialsValidation(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero:                &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+				CredentialsValidation: &oadpv1alpha1.CredentialsValidation{},
+			},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{Name: "aws", Velero: &velerov1.BackupStorageLocationSpec{Provider: AWSProvider, Config: map[string]string{Region: "us-east-1"}}},
+				// S3 compatible storage has no STS
+				{Name: "minio", Velero: &velerov1.BackupStorageLocationSpec{Provider: AWSProvider, Config: map[string]string{S3URL: "https://minio.example.com"}}},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: testNamespaceName},
+		Data:       map[string][]byte{"cloud": []byte(testAWSCredentials)},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa, secret)
+	if err != nil {
+		t.Fatalf("error in creating fake client, likely programmer error")
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: dpa, EventRecorder: recorder}
+
+	calls := 0
+	checkErr := error(&cloudprovider.CredentialsError{Provider: "aws", Code: "InvalidClientTokenId", Err: errors.New("the security token included in the request is invalid")})
+	defer func(check func(context.Context, cloudCredentialsCheck) error) { checkCloudCredentials = check }(checkCloudCredentials)
+	checkCloudCredentials = func(ctx context.Context, check cloudCredentialsCheck) error {
+		calls++
+		if check.provider != AWSProvider || check.config[Region] != "us-east-1" || !strings.HasPrefix(string(check.data), testAWSCredentials) {
+			t.Errorf("unexpected credentials check %v", check)
+		}
+		return checkErr
+	}
+
+	if _, err := r.ReconcileCredentialsValidation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsValidation() error = %v", err)
+	}
+	condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid)
+	wantMessage := "backup location aws: aws error InvalidClientTokenId: the security token included in the request is invalid"
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != oadpv1alpha1.CredentialsValidReasonInvalid || condition.Message != wantMessage {
+		t.Errorf("expected invalid CredentialsValid condition, got %v", condition)
+	}
+	if calls != 1 || len(recorder.Events) != 1 {
+		t.Errorf("expected one credentials check and one event, got %d checks and %d events", calls, len(recorder.Events))
+	}
+
+	// unchanged credentials are not checked again before the interval
+	checkErr = nil
+	dpa.Status.Conditions = nil
+	if _, err := r.ReconcileCredentialsValidation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsValidation() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the credentials check to be rate limited, got %d checks", calls)
+	}
+	if condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("expected the last CredentialsValid condition to be kept, got %v", condition)
+	}
+
+	// rotated credentials are checked in the next reconcile
+	secret.Data["cloud"] = []byte(testAWSCredentials + "\n")
+	if err := r.Update(r.Context, secret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	if _, err := r.ReconcileCredentialsValidation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsValidation() error = %v", err)
+	}
+	condition = apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid)
+	if calls != 2 || condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != oadpv1alpha1.CredentialsValidReasonValid {
+		t.Errorf("expected valid CredentialsValid condition after %d checks, got %v", calls, condition)
+	}
+
+	// a missing secret is reported for its location instead of failing the reconcile
+	if err := r.Delete(r.Context, secret); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	if _, err := r.ReconcileCredentialsValidation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsValidation() error = %v", err)
+	}
+	condition = apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid)
+	wantMessage = "backup location aws: credentials secret not found: " + testNamespaceName + "/cloud-credentials"
+	if calls != 2 || condition == nil || condition.Status != metav1.ConditionFalse || condition.Message != wantMessage {
+		t.Errorf("expected invalid CredentialsValid condition for the missing secret without checks, got %d checks and %v", calls, condition)
+	}
+
+	// disabling the check removes the condition
+	dpa.Spec.Configuration.CredentialsValidation = nil
+	if _, err := r.ReconcileCredentialsValidation(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileCredentialsValidation() error = %v", err)
+	}
+	if apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsValid) != nil {
+		t.Errorf("expected CredentialsValid condition to be removed")
+	}
+}
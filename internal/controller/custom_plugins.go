@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cosign"
+)
+
+// validateCustomPlugins checks the custom plugins against their catalogue entry: the image digest, the cosign
+// signature and the supported Velero versions
+func validateCustomPlugins(dpa *oadpv1alpha1.DataProtectionApplication) error {
+	veleroVersion, veleroVersionKnown := getVeleroVersion(dpa)
+	for _, plugin := range dpa.Spec.Configuration.Velero.CustomPlugins {
+		repository, imageDigest := splitImageReference(plugin.Image)
+		if plugin.Digest != "" && imageDigest != "" && imageDigest != plugin.Digest {
+			return fmt.Errorf("custom plugin %s image %s does not match digest %s", plugin.Name, plugin.Image, plugin.Digest)
+		}
+		if plugin.Signature != nil {
+			if plugin.Digest == "" {
+				return fmt.Errorf("custom plugin %s signature requires digest", plugin.Name)
+			}
+			if err := cosign.VerifySignature(plugin.Signature.PublicKey, plugin.Signature.Payload, plugin.Signature.Signature, repository, plugin.Digest); err != nil {
+				return fmt.Errorf("custom plugin %s signature verification failed: %w", plugin.Name, err)
+			}
+		}
+		if plugin.VeleroVersions != "" {
+			// the range is still validated when the deployed version is unknown
+			supported, err := semver.ParseRange(plugin.VeleroVersions)
+			if err != nil {
+				return fmt.Errorf("custom plugin %s veleroVersions %q is not a version range: %w", plugin.Name, plugin.VeleroVersions, err)
+			}
+			if veleroVersionKnown && !supported(veleroVersion) {
+				return fmt.Errorf("custom plugin %s supports Velero %s, the deployed version is %s", plugin.Name, plugin.VeleroVersions, veleroVersion)
+			}
+		}
+	}
+	return nil
+}
+
+// getVeleroVersion returns the version of the deployed Velero, read from the velero image tag, such as v1.16.0. The
+// version is unknown when the image is referenced by digest, like the release images, or its tag is not a version.
+func getVeleroVersion(dpa *oadpv1alpha1.DataProtectionApplication) (semver.Version, bool) {
+	version, err := semver.Parse(strings.TrimPrefix(getImageTag(getVeleroImage(dpa)), "v"))
+	if err != nil {
+		return semver.Version{}, false
+	}
+	return version, true
+}
+
+// getImageTag returns the tag of an image reference, or an empty string when it has none
+func getImageTag(image string) string {
+	repository, _, _ := strings.Cut(image, "@")
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		return repository[colon+1:]
+	}
+	return ""
+}
+
+// getCustomPluginImage returns the image of a custom plugin, referenced by digest when the digest is pinned
+func getCustomPluginImage(plugin oadpv1alpha1.CustomPlugin) string {
+	if plugin.Digest == "" {
+		return plugin.Image
+	}
+	repository, _ := splitImageReference(plugin.Image)
+	return repository + "@" + plugin.Digest
+}
+
+// splitImageReference returns the repository of an image reference, without tag and digest, and its digest
+func splitImageReference(image string) (string, string) {
+	repository, digest, _ := strings.Cut(image, "@")
+	// a colon after the last slash separates the tag, a colon before is the registry port
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository = repository[:colon]
+	}
+	return repository, digest
+}
+
+// validateCustomPluginRequirements checks that the environment variables and volume mounts required by the custom
+// plugins are set in the velero container
+func validateCustomPluginRequirements(dpa *oadpv1alpha1.DataProtectionApplication, veleroContainer *corev1.Container) error {
+	env := map[string]bool{}
+	for _, envVar := range veleroContainer.Env {
+		env[envVar.Name] = true
+	}
+	for _, plugin := range dpa.Spec.Configuration.Velero.CustomPlugins {
+		for _, name := range plugin.RequiredEnv {
+			if !env[name] {
+				return fmt.Errorf("custom plugin %s requires environment variable %s, which is not set in the velero container", plugin.Name, name)
+			}
+		}
+		for _, path := range plugin.RequiredVolumeMounts {
+			if !isPathMounted(veleroContainer.VolumeMounts, path) {
+				return fmt.Errorf("custom plugin %s requires %s, which is not mounted in the velero container", plugin.Name, path)
+			}
+		}
+	}
+	return nil
+}
+
+// isPathMounted returns true when a volume is mounted on the path or one of its parent directories
+func isPathMounted(volumeMounts []corev1.VolumeMount, path string) bool {
+	path = filepath.Clean(path)
+	for _, volumeMount := range volumeMounts {
+		mountPath := filepath.Clean(volumeMount.MountPath)
+		if path == mountPath || strings.HasPrefix(path, strings.TrimSuffix(mountPath, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
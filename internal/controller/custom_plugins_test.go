@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const testPluginDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestValidateCustomPlugins(t *testing.T) {
+	tests := []struct {
+		name        string
+		plugin      oadpv1alpha1.CustomPlugin
+		veleroImage string
+		wantErr     string
+	}{
+		{
+			name:   "plugin without catalogue entry",
+			plugin: oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest"},
+		},
+		{
+			name:   "supported Velero version",
+			plugin: oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", VeleroVersions: ">=1.14.0 <2.0.0"},
+		},
+		{
+			name:        "unsupported Velero version",
+			plugin:      oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", VeleroVersions: "<1.14.0"},
+			veleroImage: "quay.io/konveyor/velero:v1.16.0",
+			wantErr:     "custom plugin my-plugin supports Velero <1.14.0, the deployed version is 1.16.0",
+		},
+		{
+			name:   "unknown Velero version of default image",
+			plugin: oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", VeleroVersions: "<1.14.0"},
+		},
+		{
+			name:        "Velero version from overridden image tag",
+			plugin:      oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", VeleroVersions: ">=1.16.0"},
+			veleroImage: "quay.io/example/velero:v1.15.2",
+			wantErr:     "custom plugin my-plugin supports Velero >=1.16.0, the deployed version is 1.15.2",
+		},
+		{
+			name:        "unknown Velero version of overridden image",
+			plugin:      oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", VeleroVersions: "<1.14.0"},
+			veleroImage: "quay.io/example/velero@" + testPluginDigest,
+		},
+		{
+			name:        "invalid range with unknown Velero version",
+			plugin:      oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", VeleroVersions: "latest"},
+			veleroImage: "quay.io/example/velero:main",
+			wantErr:     `custom plugin my-plugin veleroVersions "latest" is not a version range: Could not get version from string: "latest"`,
+		},
+		{
+			name: "image digest differs from pinned digest",
+			plugin: oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin@sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210",
+				Digest: testPluginDigest},
+			wantErr: "custom plugin my-plugin image quay.io/example/my-plugin@sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210 does not match digest " + testPluginDigest,
+		},
+		{
+			name:    "signature without digest",
+			plugin:  oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", Signature: &oadpv1alpha1.CustomPluginSignature{PublicKey: "key"}},
+			wantErr: "custom plugin my-plugin signature requires digest",
+		},
+		{
+			name: "unsigned image",
+			plugin: oadpv1alpha1.CustomPlugin{Name: "my-plugin", Image: "quay.io/example/my-plugin:latest", Digest: testPluginDigest,
+				Signature: &oadpv1alpha1.CustomPluginSignature{PublicKey: "key"}},
+			wantErr: "custom plugin my-plugin signature verification failed: image is not signed, payload and signature are required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{CustomPlugins: []oadpv1alpha1.CustomPlugin{tt.plugin}},
+					},
+				},
+			}
+			if tt.veleroImage != "" {
+				dpa.Spec.UnsupportedOverrides = map[oadpv1alpha1.UnsupportedImageKey]string{oadpv1alpha1.VeleroImageKey: tt.veleroImage}
+			}
+			err := validateCustomPlugins(dpa)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("validateCustomPlugins() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetCustomPluginImage(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin oadpv1alpha1.CustomPlugin
+		want   string
+	}{
+		{
+			name:   "no digest",
+			plugin: oadpv1alpha1.CustomPlugin{Image: "quay.io/example/my-plugin:latest"},
+			want:   "quay.io/example/my-plugin:latest",
+		},
+		{
+			name:   "tag replaced by digest",
+			plugin: oadpv1alpha1.CustomPlugin{Image: "quay.io/example/my-plugin:latest", Digest: testPluginDigest},
+			want:   "quay.io/example/my-plugin@" + testPluginDigest,
+		},
+		{
+			name:   "registry port kept",
+			plugin: oadpv1alpha1.CustomPlugin{Image: "registry.local:5000/my-plugin", Digest: testPluginDigest},
+			want:   "registry.local:5000/my-plugin@" + testPluginDigest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getCustomPluginImage(tt.plugin); got != tt.want {
+				t.Errorf("getCustomPluginImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCustomPluginRequirements(t *testing.T) {
+	container := &corev1.Container{
+		Env:          []corev1.EnvVar{{Name: "PLUGIN_ENDPOINT", Value: "https://example.com"}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "plugin-config", MountPath: "/etc/plugin/"}},
+	}
+	tests := []struct {
+		name    string
+		plugin  oadpv1alpha1.CustomPlugin
+		wantErr string
+	}{
+		{
+			name:   "requirements met",
+			plugin: oadpv1alpha1.CustomPlugin{Name: "my-plugin", RequiredEnv: []string{"PLUGIN_ENDPOINT"}, RequiredVolumeMounts: []string{"/etc/plugin", "/etc/plugin/config.yaml"}},
+		},
+		{
+			name:    "missing environment variable",
+			plugin:  oadpv1alpha1.CustomPlugin{Name: "my-plugin", RequiredEnv: []string{"PLUGIN_TOKEN"}},
+			wantErr: "custom plugin my-plugin requires environment variable PLUGIN_TOKEN, which is not set in the velero container",
+		},
+		{
+			name:    "missing volume mount",
+			plugin:  oadpv1alpha1.CustomPlugin{Name: "my-plugin", RequiredVolumeMounts: []string{"/etc/plugin-certs"}},
+			wantErr: "custom plugin my-plugin requires /etc/plugin-certs, which is not mounted in the velero container",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{CustomPlugins: []oadpv1alpha1.CustomPlugin{tt.plugin}},
+					},
+				},
+			}
+			err := validateCustomPluginRequirements(dpa, container)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("validateCustomPluginRequirements() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -21,11 +21,13 @@ import (
 	"os"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	security "github.com/openshift/api/security/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -35,8 +37,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	oadpclient "github.com/openshift/oadp-operator/pkg/client"
@@ -52,6 +57,8 @@ type DataProtectionApplicationReconciler struct {
 	EventRecorder     record.EventRecorder
 	dpa               *oadpv1alpha1.DataProtectionApplication
 	ClusterWideClient client.Client
+	// credentialsChecks holds the last credentials check of each DPA
+	credentialsChecks map[types.NamespacedName]credentialsCheckRecord
 }
 
 var debugMode = os.Getenv("DEBUG") == "true"
@@ -60,7 +67,7 @@ var debugMode = os.Getenv("DEBUG") == "true"
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=dataprotectionapplications/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=dataprotectionapplications/finalizers,verbs=update
 
-//+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures;proxies,verbs=get;list;watch
 //+kubebuilder:rbac:groups=cloudcredential.openshift.io,resources=credentialsrequests,verbs=get;create;update
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=corev1;coordination.k8s.io,resources=secrets,verbs=get;list;watch;create;update;patch;delete
@@ -69,9 +76,12 @@ var debugMode = os.Getenv("DEBUG") == "true"
 //+kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=use,resourceNames=privileged
 //+kubebuilder:rbac:groups="",resources=secrets;configmaps;pods;services;serviceaccounts;endpoints;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=nodes;persistentvolumes,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=secrets-store.csi.x-k8s.io,resources=secretproviderclasspodstatuses,verbs=get;list;watch
 
 // Reconcile is part of the main Kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -86,6 +96,10 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 
 	if err := r.Get(ctx, req.NamespacedName, r.dpa); err != nil {
 		logger.Error(err, "unable to fetch DataProtectionApplication CR")
+		if errors.IsNotFound(err) {
+			credentialsMetrics.set(req.NamespacedName, nil)
+			delete(r.credentialsChecks, req.NamespacedName)
+		}
 		return result, nil
 	}
 
@@ -93,6 +107,7 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 	oadpclient.SetClient(r.Client)
 
 	_, err := ReconcileBatch(r.Log,
+		r.ReconcileBackupLocationSTSSecrets,
 		r.ValidateDataProtectionCR,
 		r.ReconcileFsRestoreHelperConfig,
 		r.ReconcileBackupStorageLocations,
@@ -104,10 +119,19 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 		r.LabelVSLSecrets,
 		r.ReconcileVolumeSnapshotLocations,
 		r.ReconcileAzureWorkloadIdentitySecret,
+		r.ReconcileTrustedCABundle,
+		r.ReconcileResourceAutoSizing,
+		r.ReconcileCredentialsRotation,
+		r.ReconcileCredentialsValidation,
+		r.ReconcileCredentialsPolicy,
 		r.ReconcileVeleroDeployment,
+		r.ReconcileCredentialSources,
+		r.ReconcileVeleroFailover,
 		r.ReconcileNodeAgentConfigMap,
 		r.ReconcileBackupRepositoryConfigMap,
 		r.ReconcileRepositoryMaintenanceConfigMap,
+		r.ReconcileConfigMapsValidation,
+		r.ReconcileNodeAgentRules,
 		r.ReconcileNodeAgentDaemonset,
 		r.ReconcileVeleroMetricsSVC,
 		r.ReconcileNonAdminController,
@@ -138,7 +162,18 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 		err = statusErr
 	}
 
-	return ctrl.Result{}, err
+	if err == nil && isVeleroHAEnabled(r.dpa) {
+		// pods and nodes are not watched, check the active Velero server periodically
+		result.RequeueAfter = veleroHAResyncPeriod
+	}
+	if err == nil && r.dpa.Spec.Configuration != nil && r.dpa.Spec.Configuration.CredentialsValidation != nil {
+		// credentials expire or are revoked without any change in the cluster, check them again after the interval
+		if interval := getCredentialsValidationInterval(r.dpa); result.RequeueAfter == 0 || interval < result.RequeueAfter {
+			result.RequeueAfter = interval
+		}
+	}
+
+	return result, err
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -153,7 +188,18 @@ func (r *DataProtectionApplicationReconciler) SetupWithManager(mgr ctrl.Manager)
 		Owns(&corev1.Service{}).
 		Owns(&routev1.Route{}).
 		Owns(&corev1.ConfigMap{}).
-		Watches(&corev1.Secret{}, &labelHandler{}).
+		// secret data changes bypass veleroPredicate, so a credential rotation rolls the Velero and node-agent pods
+		WatchesRawSource(source.Kind[client.Object](mgr.GetCache(), &corev1.Secret{}, &labelHandler{}, credentialSecretPredicate())).
+		// node events bypass veleroPredicate, nodes are not ours and label changes do not bump their generation
+		WatchesRawSource(source.Kind(mgr.GetCache(), &corev1.Node{},
+			handler.TypedEnqueueRequestsFromMapFunc(r.nodeAgentRulesRequests),
+			predicate.TypedLabelChangedPredicate[*corev1.Node]{})).
+		WatchesRawSource(source.Kind(mgr.GetCache(), &configv1.Proxy{},
+			handler.TypedEnqueueRequestsFromMapFunc(r.clusterProxyRequests))).
+		// the trusted CA bundle is injected by updating the ConfigMap data, which does not bump its generation
+		WatchesRawSource(source.Kind(mgr.GetCache(), &corev1.ConfigMap{},
+			handler.TypedEnqueueRequestForOwner[*corev1.ConfigMap](mgr.GetScheme(), mgr.GetRESTMapper(), &oadpv1alpha1.DataProtectionApplication{}, handler.OnlyControllerOwner()),
+			predicate.NewTypedPredicateFuncs(isTrustedCABundleConfigMap))).
 		WithEventFilter(veleroPredicate(r.Scheme)).
 		Complete(r)
 }
@@ -9,7 +9,6 @@ import (
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
-	"github.com/operator-framework/operator-lib/proxy"
 	"github.com/vmware-tanzu/velero/pkg/install"
 	"github.com/vmware-tanzu/velero/pkg/util/kube"
 	appsv1 "k8s.io/api/apps/v1"
@@ -547,8 +546,12 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 				nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, dpa.Spec.Configuration.NodeAgent.PodConfig.Env)
 			}
 
-			// append env vars to the nodeAgent container
-			nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, proxy.ReadProxyVarsFromEnv())
+			// append proxy env vars from the cluster-wide proxy to the nodeAgent container
+			proxyEnvVars, err := r.getProxyEnvVars()
+			if err != nil {
+				return nil, err
+			}
+			nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, proxyEnvVars)
 
 			// Add Azure workload identity environment variables if configured
 			azureClientID := os.Getenv(stsflow.ClientIDEnvKey)
@@ -616,6 +619,14 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 	}
 
 	credentials.AppendCloudProviderVolumes(dpa, ds, providerNeedsDefaultCreds)
+	credentials.AppendCredentialSourceVolumes(dpa, &ds.Spec.Template, common.NodeAgent)
+
+	if err := r.appendTrustedCABundle(&ds.Spec.Template, common.NodeAgent); err != nil {
+		return nil, err
+	}
+	if err := r.appendCredentialsHashes(&ds.Spec.Template); err != nil {
+		return nil, err
+	}
 
 	setPodTemplateSpecDefaults(&ds.Spec.Template)
 	if ds.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType {
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// defaultDataPathConcurrentNum is the number of concurrent data path loads node-agent uses
+// when neither globalConfig nor a perNodeConfig rule applies
+// https://github.com/openshift/velero/blob/584cf1148a746838ee67aa27e3e4e0ded1f5c069/pkg/cmd/cli/nodeagent/server.go#L87
+const defaultDataPathConcurrentNum = 1
+
+// ReconcileNodeAgentRules reports in the DPA status the loadConcurrency and loadAffinity rules of the
+// node-agent ConfigMap that apply to each node
+func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentRules(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	if !hasNodeAgentRules(dpa) {
+		dpa.Status.NodeAgentRules = nil
+		return true, nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.List(r.Context, nodeList); err != nil {
+		return false, err
+	}
+	dpa.Status.NodeAgentRules = getNodeAgentRules(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings, nodeList.Items)
+	return true, nil
+}
+
+func hasNodeAgentRules(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	if dpa.Spec.Configuration == nil || !isNodeAgentEnabled(dpa) {
+		return false
+	}
+	settings := dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings
+	return settings.LoadConcurrency != nil || len(settings.LoadAffinityConfig) > 0
+}
+
+// getNodeAgentRules evaluates the node-agent ConfigMap settings against each node the same way node-agent does:
+// the smallest number of the matching perNodeConfig rules wins over globalConfig, rules with an invalid selector
+// or number are skipped, and only the first loadAffinity rule constrains where data mover pods run.
+func getNodeAgentRules(settings oadpv1alpha1.NodeAgentConfigMapSettings, nodes []corev1.Node) []oadpv1alpha1.NodeAgentRules {
+	globalNum := defaultDataPathConcurrentNum
+	if settings.LoadConcurrency != nil && settings.LoadConcurrency.GlobalConfig > 0 {
+		globalNum = settings.LoadConcurrency.GlobalConfig
+	}
+
+	nodeRules := make([]oadpv1alpha1.NodeAgentRules, 0, len(nodes))
+	for _, node := range nodes {
+		rules := oadpv1alpha1.NodeAgentRules{
+			Node:              node.Name,
+			LoadConcurrency:   globalNum,
+			DataMoverEligible: true,
+		}
+
+		if settings.LoadConcurrency != nil {
+			for i, rule := range settings.LoadConcurrency.PerNodeConfig {
+				if rule.Number <= 0 || !nodeMatchesSelector(rule.NodeSelector, node) {
+					continue
+				}
+				if len(rules.LoadConcurrencyRules) == 0 || rule.Number < rules.LoadConcurrency {
+					rules.LoadConcurrency = rule.Number
+				}
+				rules.LoadConcurrencyRules = append(rules.LoadConcurrencyRules, i)
+			}
+		}
+
+		for i, affinity := range settings.LoadAffinityConfig {
+			matched := affinity == nil || nodeMatchesSelector(affinity.NodeSelector, node)
+			if affinity != nil && matched {
+				rules.LoadAffinityRules = append(rules.LoadAffinityRules, i)
+			}
+			if i == 0 {
+				rules.DataMoverEligible = matched
+			}
+		}
+
+		nodeRules = append(nodeRules, rules)
+	}
+
+	sort.Slice(nodeRules, func(i, j int) bool {
+		return nodeRules[i].Node < nodeRules[j].Node
+	})
+	return nodeRules
+}
+
+// nodeMatchesSelector returns false for an invalid selector, as node-agent skips those rules
+func nodeMatchesSelector(selector metav1.LabelSelector, node corev1.Node) bool {
+	labelSelector, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return false
+	}
+	return labelSelector.Matches(labels.Set(node.Labels))
+}
+
+// nodeAgentRulesRequests returns the DPAs to reconcile when node labels change, so the reported rules stay current
+func (r *DataProtectionApplicationReconciler) nodeAgentRulesRequests(ctx context.Context, node *corev1.Node) []reconcile.Request {
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for i := range dpaList.Items {
+		dpa := &dpaList.Items[i]
+		if !hasNodeAgentRules(dpa) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(dpa)})
+	}
+	return requests
+}
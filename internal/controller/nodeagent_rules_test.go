@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestGetNodeAgentRules(t *testing.T) {
+	nodes := testValidationContext().nodes
+	tests := []struct {
+		name     string
+		settings oadpv1alpha1.NodeAgentConfigMapSettings
+		want     []oadpv1alpha1.NodeAgentRules
+	}{
+		{
+			name: "global concurrency only",
+			settings: oadpv1alpha1.NodeAgentConfigMapSettings{
+				LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 3},
+			},
+			want: []oadpv1alpha1.NodeAgentRules{
+				{Node: "worker-1", LoadConcurrency: 3, DataMoverEligible: true},
+				{Node: "worker-2", LoadConcurrency: 3, DataMoverEligible: true},
+			},
+		},
+		{
+			name: "smallest matching per node rule wins, invalid rules are skipped",
+			settings: oadpv1alpha1.NodeAgentConfigMapSettings{
+				LoadConcurrency: &oadpv1alpha1.LoadConcurrency{
+					PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/worker": ""}}, Number: 4},
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}, Number: 2},
+						{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "b"}}, Number: 0},
+						{NodeSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "zone", Operator: "Bogus"}}}, Number: 1},
+					},
+				},
+			},
+			want: []oadpv1alpha1.NodeAgentRules{
+				{Node: "worker-1", LoadConcurrency: 2, LoadConcurrencyRules: []int{0, 1}, DataMoverEligible: true},
+				{Node: "worker-2", LoadConcurrency: 4, LoadConcurrencyRules: []int{0}, DataMoverEligible: true},
+			},
+		},
+		{
+			name: "only the first load affinity rule restricts data mover pods",
+			settings: oadpv1alpha1.NodeAgentConfigMapSettings{
+				LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+					{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "b"}}},
+					{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}},
+				},
+			},
+			want: []oadpv1alpha1.NodeAgentRules{
+				{Node: "worker-1", LoadConcurrency: 1, LoadAffinityRules: []int{1}, DataMoverEligible: false},
+				{Node: "worker-2", LoadConcurrency: 1, LoadAffinityRules: []int{0}, DataMoverEligible: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getNodeAgentRules(tt.settings, nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getNodeAgentRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileNodeAgentRules(t *testing.T) {
+	dpa := testNodeAgentDpa(oadpv1alpha1.NodeAgentConfigMapSettings{
+		LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 2},
+	})
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}
+	fakeClient, err := getFakeClientFromObjects(dpa, node)
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: dpa}
+
+	if _, err := r.ReconcileNodeAgentRules(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileNodeAgentRules() error = %v", err)
+	}
+	want := []oadpv1alpha1.NodeAgentRules{{Node: "worker-1", LoadConcurrency: 2, DataMoverEligible: true}}
+	if !reflect.DeepEqual(dpa.Status.NodeAgentRules, want) {
+		t.Errorf("expected node-agent rules %v, got %v", want, dpa.Status.NodeAgentRules)
+	}
+	if requests := r.nodeAgentRulesRequests(context.Background(), node); len(requests) != 1 || requests[0].Name != testDpaName {
+		t.Errorf("expected node event to enqueue the DPA, got %v", requests)
+	}
+
+	dpa.Spec.Configuration.NodeAgent.Enable = ptr.To(false)
+	if _, err := r.ReconcileNodeAgentRules(logr.Discard()); err != nil {
+		t.Fatalf("ReconcileNodeAgentRules() error = %v", err)
+	}
+	if dpa.Status.NodeAgentRules != nil {
+		t.Errorf("expected node-agent rules to be removed, got %v", dpa.Status.NodeAgentRules)
+	}
+}
@@ -134,7 +134,17 @@ func (r *DataProtectionApplicationReconciler) buildNonAdminDeployment(deployment
 	if err != nil {
 		return err
 	}
-	return nil
+	proxyEnvVars, err := r.getProxyEnvVars()
+	if err != nil {
+		return err
+	}
+	for i := range deploymentObject.Spec.Template.Spec.Containers {
+		if deploymentObject.Spec.Template.Spec.Containers[i].Name == nonAdminObjectName {
+			nonAdminContainer := &deploymentObject.Spec.Template.Spec.Containers[i]
+			nonAdminContainer.Env = common.AppendUniqueEnvVars(nonAdminContainer.Env, proxyEnvVars)
+		}
+	}
+	return r.appendTrustedCABundle(&deploymentObject.Spec.Template, nonAdminObjectName)
 }
 
 func ensureRequiredLabels(deploymentObject *appsv1.Deployment) {
@@ -244,7 +244,11 @@ var _ = ginkgo.Describe("Test ReconcileNonAdminController function", func() {
 })
 
 func TestDPAReconcilerBuildNonAdminDeployment(t *testing.T) {
-	r := &DataProtectionApplicationReconciler{dpa: &oadpv1alpha1.DataProtectionApplication{
+	fakeClient, err := getFakeClientFromObjects()
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, dpa: &oadpv1alpha1.DataProtectionApplication{
 		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
 			NonAdmin: &oadpv1alpha1.NonAdmin{
 				Enable: ptr.To(true),
@@ -256,7 +260,7 @@ func TestDPAReconcilerBuildNonAdminDeployment(t *testing.T) {
 	}}
 	t.Setenv("RELATED_IMAGE_NON_ADMIN_CONTROLLER", defaultNonAdminImage)
 	deployment := createTestDeployment("test-build-deployment")
-	err := r.buildNonAdminDeployment(deployment)
+	err = r.buildNonAdminDeployment(deployment)
 	if err != nil {
 		t.Errorf("buildNonAdminDeployment() errored out: %v", err)
 	}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// namedPluginConfig is the configuration of a default or custom plugin
+type namedPluginConfig struct {
+	name   string
+	config oadpv1alpha1.PluginConfig
+}
+
+// getPluginConfigs returns the configuration of the default plugins, in the order of spec.configuration.velero.defaultPlugins,
+// followed by the custom plugins
+func getPluginConfigs(dpa *oadpv1alpha1.DataProtectionApplication) []namedPluginConfig {
+	configs := []namedPluginConfig{}
+	for _, plugin := range dpa.Spec.Configuration.Velero.DefaultPlugins {
+		if config, ok := dpa.Spec.Configuration.Velero.DefaultPluginConfigs[plugin]; ok {
+			configs = append(configs, namedPluginConfig{name: string(plugin), config: config})
+		}
+	}
+	for _, plugin := range dpa.Spec.Configuration.Velero.CustomPlugins {
+		configs = append(configs, namedPluginConfig{name: plugin.Name, config: plugin.PluginConfig})
+	}
+	return configs
+}
+
+// validatePluginConfigs checks that the default plugin configurations refer to enabled plugins and that each plugin
+// volume has exactly one source
+func validatePluginConfigs(dpa *oadpv1alpha1.DataProtectionApplication) error {
+	for plugin := range dpa.Spec.Configuration.Velero.DefaultPluginConfigs {
+		if !slices.Contains(dpa.Spec.Configuration.Velero.DefaultPlugins, plugin) {
+			return fmt.Errorf("spec.configuration.velero.defaultPluginConfigs contains %s, which is not in spec.configuration.velero.defaultPlugins", plugin)
+		}
+	}
+	for _, plugin := range getPluginConfigs(dpa) {
+		for _, volume := range plugin.config.Volumes {
+			sources := 0
+			for _, set := range []bool{volume.ConfigMap != nil, volume.Secret != nil, volume.EmptyDir != nil} {
+				if set {
+					sources++
+				}
+			}
+			if sources != 1 {
+				return fmt.Errorf("plugin %s volume %s must set exactly one of configMap, secret and emptyDir", plugin.name, volume.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// appendPluginConfigs adds the env, volumes and volumeMounts of the plugins to the velero pod. A plugin can not
+// override what the operator or another plugin set: an env var with another value, a volume with the same name or a
+// volume mounted on the same path is a conflict.
+func appendPluginConfigs(dpa *oadpv1alpha1.DataProtectionApplication, podSpec *corev1.PodSpec, veleroContainer *corev1.Container) error {
+	for _, plugin := range getPluginConfigs(dpa) {
+		for _, envVar := range plugin.config.Env {
+			i := slices.IndexFunc(veleroContainer.Env, func(e corev1.EnvVar) bool { return e.Name == envVar.Name })
+			if i < 0 {
+				veleroContainer.Env = append(veleroContainer.Env, envVar)
+				continue
+			}
+			existing := veleroContainer.Env[i]
+			if existing.Value != envVar.Value || existing.ValueFrom != nil || envVar.ValueFrom != nil {
+				return fmt.Errorf("plugin %s env %s conflicts with the env of the velero container", plugin.name, envVar.Name)
+			}
+		}
+		for _, volume := range plugin.config.Volumes {
+			if slices.ContainsFunc(podSpec.Volumes, func(v corev1.Volume) bool { return v.Name == volume.Name }) {
+				return fmt.Errorf("plugin %s volume %s conflicts with a volume of the velero pod", plugin.name, volume.Name)
+			}
+			podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+				Name: volume.Name,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: volume.ConfigMap,
+					Secret:    volume.Secret,
+					EmptyDir:  volume.EmptyDir,
+				},
+			})
+		}
+		for _, volumeMount := range plugin.config.VolumeMounts {
+			if !slices.ContainsFunc(podSpec.Volumes, func(v corev1.Volume) bool { return v.Name == volumeMount.Name }) {
+				return fmt.Errorf("plugin %s volumeMount %s refers to a volume not in the velero pod", plugin.name, volumeMount.Name)
+			}
+			if slices.ContainsFunc(veleroContainer.VolumeMounts, func(m corev1.VolumeMount) bool {
+				return filepath.Clean(m.MountPath) == filepath.Clean(volumeMount.MountPath)
+			}) {
+				return fmt.Errorf("plugin %s volumeMount %s conflicts with the mount path %s of the velero container", plugin.name, volumeMount.Name, volumeMount.MountPath)
+			}
+			veleroContainer.VolumeMounts = append(veleroContainer.VolumeMounts, volumeMount)
+		}
+	}
+	return nil
+}
+
+// applyPluginInitContainerConfig sets the resources and imagePullPolicy of a plugin init container from its configuration
+func applyPluginInitContainerConfig(initContainer *corev1.Container, config oadpv1alpha1.PluginConfig) {
+	if config.Resources != nil {
+		initContainer.Resources = *config.Resources
+	}
+	if config.ImagePullPolicy != nil {
+		initContainer.ImagePullPolicy = *config.ImagePullPolicy
+	}
+}
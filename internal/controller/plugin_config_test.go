@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestValidatePluginConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		velero  *oadpv1alpha1.VeleroConfig
+		wantErr string
+	}{
+		{
+			name: "configuration of an enabled default plugin",
+			velero: &oadpv1alpha1.VeleroConfig{
+				DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginKubeVirt},
+				DefaultPluginConfigs: map[oadpv1alpha1.DefaultPlugin]oadpv1alpha1.PluginConfig{
+					oadpv1alpha1.DefaultPluginKubeVirt: {Volumes: []oadpv1alpha1.PluginVolume{{Name: "cache", EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+				},
+			},
+		},
+		{
+			name: "configuration of a disabled default plugin",
+			velero: &oadpv1alpha1.VeleroConfig{
+				DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS},
+				DefaultPluginConfigs: map[oadpv1alpha1.DefaultPlugin]oadpv1alpha1.PluginConfig{
+					oadpv1alpha1.DefaultPluginKubeVirt: {},
+				},
+			},
+			wantErr: "spec.configuration.velero.defaultPluginConfigs contains kubevirt, which is not in spec.configuration.velero.defaultPlugins",
+		},
+		{
+			name: "custom plugin volume without source",
+			velero: &oadpv1alpha1.VeleroConfig{
+				CustomPlugins: []oadpv1alpha1.CustomPlugin{{
+					Name:         "my-plugin",
+					PluginConfig: oadpv1alpha1.PluginConfig{Volumes: []oadpv1alpha1.PluginVolume{{Name: "config"}}},
+				}},
+			},
+			wantErr: "plugin my-plugin volume config must set exactly one of configMap, secret and emptyDir",
+		},
+		{
+			name: "custom plugin volume with two sources",
+			velero: &oadpv1alpha1.VeleroConfig{
+				CustomPlugins: []oadpv1alpha1.CustomPlugin{{
+					Name: "my-plugin",
+					PluginConfig: oadpv1alpha1.PluginConfig{Volumes: []oadpv1alpha1.PluginVolume{{
+						Name:      "config",
+						ConfigMap: &corev1.ConfigMapVolumeSource{},
+						Secret:    &corev1.SecretVolumeSource{},
+					}}},
+				}},
+			},
+			wantErr: "plugin my-plugin volume config must set exactly one of configMap, secret and emptyDir",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{Configuration: &oadpv1alpha1.ApplicationConfig{Velero: tt.velero}},
+			}
+			err := validatePluginConfigs(dpa)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("validatePluginConfigs() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAppendPluginConfigs(t *testing.T) {
+	configVolume := oadpv1alpha1.PluginVolume{Name: "plugin-config", ConfigMap: &corev1.ConfigMapVolumeSource{}}
+	tests := []struct {
+		name    string
+		plugins []oadpv1alpha1.CustomPlugin
+		wantErr string
+	}{
+		{
+			name: "plugins share an env var with the same value",
+			plugins: []oadpv1alpha1.CustomPlugin{
+				{Name: "first", PluginConfig: oadpv1alpha1.PluginConfig{Env: []corev1.EnvVar{{Name: "REGION", Value: "us-east-1"}}}},
+				{Name: "second", PluginConfig: oadpv1alpha1.PluginConfig{Env: []corev1.EnvVar{{Name: "REGION", Value: "us-east-1"}}}},
+			},
+		},
+		{
+			name: "plugins set an env var to different values",
+			plugins: []oadpv1alpha1.CustomPlugin{
+				{Name: "first", PluginConfig: oadpv1alpha1.PluginConfig{Env: []corev1.EnvVar{{Name: "REGION", Value: "us-east-1"}}}},
+				{Name: "second", PluginConfig: oadpv1alpha1.PluginConfig{Env: []corev1.EnvVar{{Name: "REGION", Value: "eu-west-1"}}}},
+			},
+			wantErr: "plugin second env REGION conflicts with the env of the velero container",
+		},
+		{
+			name: "plugin overrides an env var of the operator",
+			plugins: []oadpv1alpha1.CustomPlugin{
+				{Name: "first", PluginConfig: oadpv1alpha1.PluginConfig{Env: []corev1.EnvVar{{Name: "VELERO_NAMESPACE", Value: "other"}}}},
+			},
+			wantErr: "plugin first env VELERO_NAMESPACE conflicts with the env of the velero container",
+		},
+		{
+			name: "plugins add volumes with the same name",
+			plugins: []oadpv1alpha1.CustomPlugin{
+				{Name: "first", PluginConfig: oadpv1alpha1.PluginConfig{Volumes: []oadpv1alpha1.PluginVolume{configVolume}}},
+				{Name: "second", PluginConfig: oadpv1alpha1.PluginConfig{Volumes: []oadpv1alpha1.PluginVolume{configVolume}}},
+			},
+			wantErr: "plugin second volume plugin-config conflicts with a volume of the velero pod",
+		},
+		{
+			name: "plugin mounts an unknown volume",
+			plugins: []oadpv1alpha1.CustomPlugin{
+				{Name: "first", PluginConfig: oadpv1alpha1.PluginConfig{VolumeMounts: []corev1.VolumeMount{{Name: "missing", MountPath: "/etc/plugin"}}}},
+			},
+			wantErr: "plugin first volumeMount missing refers to a volume not in the velero pod",
+		},
+		{
+			name: "plugin mounts a volume on a path used by the operator",
+			plugins: []oadpv1alpha1.CustomPlugin{
+				{Name: "first", PluginConfig: oadpv1alpha1.PluginConfig{
+					Volumes:      []oadpv1alpha1.PluginVolume{configVolume},
+					VolumeMounts: []corev1.VolumeMount{{Name: "plugin-config", MountPath: "/plugins/"}},
+				}},
+			},
+			wantErr: "plugin first volumeMount plugin-config conflicts with the mount path /plugins/ of the velero container",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{CustomPlugins: tt.plugins}},
+				},
+			}
+			podSpec := &corev1.PodSpec{Volumes: []corev1.Volume{{Name: "plugins"}}}
+			veleroContainer := &corev1.Container{
+				Env:          []corev1.EnvVar{{Name: "VELERO_NAMESPACE", Value: testNamespaceName}},
+				VolumeMounts: []corev1.VolumeMount{{Name: "plugins", MountPath: "/plugins"}},
+			}
+			err := appendPluginConfigs(dpa, podSpec, veleroContainer)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("appendPluginConfigs() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
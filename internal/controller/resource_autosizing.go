@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	oomKilledReason = "OOMKilled"
+
+	// Velero memory grows with the number of items it backs up, persistent volumes are used as a proxy
+	veleroBaseMemoryMi          = 128
+	veleroMemoryPerPVBatchMi    = 128
+	veleroPVBatchSize           = 100
+	veleroMaxMemoryMi           = 4096
+	veleroBaseCPUMilli          = 500
+	veleroLargeClusterCPUMilli  = 1000
+	veleroLargeClusterPVCount   = 500
+	nodeAgentBaseMemoryMi       = 256
+	nodeAgentMaxMemoryMi        = 16384
+	nodeAgentCPUPerStreamMilli  = 500
+	nodeAgentMaxCPUMilli        = 4000
+	maxOOMKilledMemoryDoublings = 3
+)
+
+var (
+	// Kopia memory usage per concurrent data path grows with the size of the volume it uploads
+	smallVolumeSize  = resource.MustParse("100Gi")
+	mediumVolumeSize = resource.MustParse("1Ti")
+)
+
+// autoSizingInputs are the cluster observations used to compute the resource recommendations
+type autoSizingInputs struct {
+	pvCount            int
+	largestPV          resource.Quantity
+	cacheLimitMB       *int64
+	loadConcurrency    int
+	veleroOOMKilled    int32
+	nodeAgentOOMKilled int32
+}
+
+func isResourceAutoSizingEnabled(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	return dpa.Spec.Configuration != nil && dpa.Spec.Configuration.ResourceAutoSizing != nil
+}
+
+// hasUserResourceAllocations returns true if the user set resource requests or limits in the pod config
+func hasUserResourceAllocations(podConfig *oadpv1alpha1.PodConfig) bool {
+	return podConfig != nil && (len(podConfig.ResourceAllocations.Requests) > 0 || len(podConfig.ResourceAllocations.Limits) > 0)
+}
+
+// getAppliedResourceRecommendation returns the recommended resource requirements of a component
+// if they were applied, nil otherwise
+func getAppliedResourceRecommendation(dpa *oadpv1alpha1.DataProtectionApplication, component string) *corev1.ResourceRequirements {
+	for _, recommendation := range dpa.Status.ResourceRecommendations {
+		if recommendation.Component == component && recommendation.Applied {
+			return recommendation.Resources.DeepCopy()
+		}
+	}
+	return nil
+}
+
+func getPreviousOOMKilled(dpa *oadpv1alpha1.DataProtectionApplication, component string) int32 {
+	for _, recommendation := range dpa.Status.ResourceRecommendations {
+		if recommendation.Component == component {
+			return recommendation.OOMKilled
+		}
+	}
+	return 0
+}
+
+// getMaxLoadConcurrency returns the highest number of concurrent data paths a node can run
+func getMaxLoadConcurrency(dpa *oadpv1alpha1.DataProtectionApplication) int {
+	concurrency := 1
+	if dpa.Spec.Configuration.NodeAgent == nil || dpa.Spec.Configuration.NodeAgent.LoadConcurrency == nil {
+		return concurrency
+	}
+	loadConcurrency := dpa.Spec.Configuration.NodeAgent.LoadConcurrency
+	if loadConcurrency.GlobalConfig > concurrency {
+		concurrency = loadConcurrency.GlobalConfig
+	}
+	for _, rule := range loadConcurrency.PerNodeConfig {
+		if rule.Number > concurrency {
+			concurrency = rule.Number
+		}
+	}
+	return concurrency
+}
+
+// ReconcileResourceAutoSizing computes the Velero and NodeAgent resource requirements and records them,
+// with the reasoning, in the DPA status. In Apply mode the recommendations are used by
+// getVeleroResourceReqs and getNodeAgentResourceReqs for components without user resource allocations.
+func (r *DataProtectionApplicationReconciler) ReconcileResourceAutoSizing(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	if !isResourceAutoSizingEnabled(dpa) {
+		dpa.Status.ResourceRecommendations = nil
+		return true, nil
+	}
+
+	inputs, err := r.getAutoSizingInputs()
+	if err != nil {
+		return false, err
+	}
+
+	apply := dpa.Spec.Configuration.ResourceAutoSizing.Mode == oadpv1alpha1.ResourceAutoSizingApply
+	veleroRecommendation := recommendVeleroResources(inputs)
+	veleroRecommendation.Applied = apply && !hasUserResourceAllocations(dpa.Spec.Configuration.Velero.PodConfig)
+	if apply && !veleroRecommendation.Applied {
+		veleroRecommendation.Reasons = append(veleroRecommendation.Reasons, "not applied, resourceAllocations are set in velero podConfig")
+	}
+	recommendations := []oadpv1alpha1.ResourceRecommendation{veleroRecommendation}
+
+	if isNodeAgentEnabled(dpa) {
+		nodeAgentRecommendation := recommendNodeAgentResources(inputs)
+		nodeAgentRecommendation.Applied = apply && !hasUserResourceAllocations(dpa.Spec.Configuration.NodeAgent.PodConfig)
+		if apply && !nodeAgentRecommendation.Applied {
+			nodeAgentRecommendation.Reasons = append(nodeAgentRecommendation.Reasons, "not applied, resourceAllocations are set in nodeAgent podConfig")
+		}
+		recommendations = append(recommendations, nodeAgentRecommendation)
+	}
+
+	log.V(1).Info("computed resource recommendations", "recommendations", recommendations)
+	dpa.Status.ResourceRecommendations = recommendations
+	return true, nil
+}
+
+func (r *DataProtectionApplicationReconciler) getAutoSizingInputs() (autoSizingInputs, error) {
+	dpa := r.dpa
+	inputs := autoSizingInputs{
+		loadConcurrency: getMaxLoadConcurrency(dpa),
+	}
+	if dpa.Spec.Configuration.NodeAgent != nil {
+		inputs.cacheLimitMB = dpa.Spec.Configuration.NodeAgent.CacheLimitMB
+	}
+
+	pvList := &corev1.PersistentVolumeList{}
+	if err := r.List(r.Context, pvList); err != nil {
+		return inputs, err
+	}
+	for _, pv := range pvList.Items {
+		if pv.Status.Phase != corev1.VolumeBound {
+			continue
+		}
+		inputs.pvCount++
+		if size, ok := pv.Spec.Capacity[corev1.ResourceStorage]; ok && size.Cmp(inputs.largestPV) > 0 {
+			inputs.largestPV = size
+		}
+	}
+
+	// OOMKilled containers are no longer visible once the pods are replaced,
+	// keep the highest count observed so the recommendation does not go back down
+	veleroOOMKilled, err := r.countOOMKilledContainers(getDpaAppLabels(dpa))
+	if err != nil {
+		return inputs, err
+	}
+	inputs.veleroOOMKilled = max(veleroOOMKilled, getPreviousOOMKilled(dpa, common.Velero))
+	nodeAgentOOMKilled, err := r.countOOMKilledContainers(nodeAgentMatchLabels)
+	if err != nil {
+		return inputs, err
+	}
+	inputs.nodeAgentOOMKilled = max(nodeAgentOOMKilled, getPreviousOOMKilled(dpa, common.NodeAgent))
+	return inputs, nil
+}
+
+// countOOMKilledContainers returns the number of containers whose last termination was an OOMKill
+func (r *DataProtectionApplicationReconciler) countOOMKilledContainers(labels map[string]string) (int32, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(r.Context, podList, client.InNamespace(r.dpa.Namespace), client.MatchingLabels(labels)); err != nil {
+		return 0, err
+	}
+	count := int32(0)
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.LastTerminationState.Terminated != nil && status.LastTerminationState.Terminated.Reason == oomKilledReason {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// applyOOMKilled doubles memory for each OOMKilled container observed, up to maxOOMKilledMemoryDoublings times
+func applyOOMKilled(memoryMi int64, oomKilled int32, maxMemoryMi int64, reasons []string) (int64, []string) {
+	if oomKilled == 0 {
+		return memoryMi, reasons
+	}
+	doublings := min(oomKilled, maxOOMKilledMemoryDoublings)
+	memoryMi = min(memoryMi<<doublings, maxMemoryMi)
+	return memoryMi, append(reasons, fmt.Sprintf("%d OOMKilled container(s) observed: memory raised to %dMi", oomKilled, memoryMi))
+}
+
+func recommendVeleroResources(inputs autoSizingInputs) oadpv1alpha1.ResourceRecommendation {
+	reasons := []string{}
+	batches := int64((inputs.pvCount + veleroPVBatchSize - 1) / veleroPVBatchSize)
+	memoryMi := min(veleroBaseMemoryMi+batches*veleroMemoryPerPVBatchMi, veleroMaxMemoryMi)
+	reasons = append(reasons, fmt.Sprintf("%d bound persistent volume(s): %dMi memory", inputs.pvCount, memoryMi))
+	memoryMi, reasons = applyOOMKilled(memoryMi, inputs.veleroOOMKilled, veleroMaxMemoryMi, reasons)
+
+	cpuMilli := int64(veleroBaseCPUMilli)
+	if inputs.pvCount > veleroLargeClusterPVCount {
+		cpuMilli = veleroLargeClusterCPUMilli
+		reasons = append(reasons, fmt.Sprintf("more than %d persistent volumes: %dm cpu", veleroLargeClusterPVCount, cpuMilli))
+	}
+	reasons = append(reasons, "limits are not set, a memory limit would OOMKill the pod on spikes above the request")
+
+	return oadpv1alpha1.ResourceRecommendation{
+		Component: common.Velero,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(cpuMilli, resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(memoryMi<<20, resource.BinarySI),
+			},
+		},
+		OOMKilled: inputs.veleroOOMKilled,
+		Reasons:   reasons,
+	}
+}
+
+func recommendNodeAgentResources(inputs autoSizingInputs) oadpv1alpha1.ResourceRecommendation {
+	reasons := []string{}
+	streamMemoryMi := int64(256)
+	switch {
+	case inputs.largestPV.Cmp(mediumVolumeSize) > 0:
+		streamMemoryMi = 1024
+	case inputs.largestPV.Cmp(smallVolumeSize) > 0:
+		streamMemoryMi = 512
+	}
+	memoryMi := min(nodeAgentBaseMemoryMi+int64(inputs.loadConcurrency)*streamMemoryMi, nodeAgentMaxMemoryMi)
+	reasons = append(reasons, fmt.Sprintf("largest persistent volume %s: %dMi memory per data path, load concurrency %d: %dMi memory",
+		inputs.largestPV.String(), streamMemoryMi, inputs.loadConcurrency, memoryMi))
+	memoryMi, reasons = applyOOMKilled(memoryMi, inputs.nodeAgentOOMKilled, nodeAgentMaxMemoryMi, reasons)
+
+	cpuMilli := min(int64(inputs.loadConcurrency)*nodeAgentCPUPerStreamMilli, nodeAgentMaxCPUMilli)
+	reasons = append(reasons, fmt.Sprintf("load concurrency %d: %dm cpu", inputs.loadConcurrency, cpuMilli))
+
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(cpuMilli, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(memoryMi<<20, resource.BinarySI),
+	}
+	if inputs.cacheLimitMB != nil && *inputs.cacheLimitMB > 0 {
+		requests[corev1.ResourceEphemeralStorage] = *resource.NewQuantity(*inputs.cacheLimitMB<<20, resource.BinarySI)
+		reasons = append(reasons, fmt.Sprintf("kopia cache limit %dMB: %dMi ephemeral-storage", *inputs.cacheLimitMB, *inputs.cacheLimitMB))
+	}
+	reasons = append(reasons, "limits are not set, a memory limit would OOMKill the pod on spikes above the request")
+
+	return oadpv1alpha1.ResourceRecommendation{
+		Component: common.NodeAgent,
+		Resources: corev1.ResourceRequirements{
+			Requests: requests,
+		},
+		OOMKilled: inputs.nodeAgentOOMKilled,
+		Reasons:   reasons,
+	}
+}
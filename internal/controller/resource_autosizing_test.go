@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func boundPV(name, size string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+	}
+}
+
+func TestRecommendResources(t *testing.T) {
+	tests := []struct {
+		name                string
+		inputs              autoSizingInputs
+		wantVeleroMemory    string
+		wantVeleroCPU       string
+		wantNodeAgentMemory string
+		wantNodeAgentCPU    string
+		wantEphemeral       string
+	}{
+		{
+			name:                "empty cluster",
+			inputs:              autoSizingInputs{loadConcurrency: 1},
+			wantVeleroMemory:    "128Mi",
+			wantVeleroCPU:       "500m",
+			wantNodeAgentMemory: "512Mi",
+			wantNodeAgentCPU:    "500m",
+		},
+		{
+			name: "large volumes with concurrency and cache limit",
+			inputs: autoSizingInputs{
+				pvCount:         250,
+				largestPV:       resource.MustParse("2Ti"),
+				loadConcurrency: 4,
+				cacheLimitMB:    ptr.To(int64(5120)),
+			},
+			wantVeleroMemory:    "512Mi",
+			wantVeleroCPU:       "500m",
+			wantNodeAgentMemory: "4352Mi",
+			wantNodeAgentCPU:    "2",
+			wantEphemeral:       "5Gi",
+		},
+		{
+			name: "OOMKilled containers double memory",
+			inputs: autoSizingInputs{
+				pvCount:            600,
+				largestPV:          resource.MustParse("200Gi"),
+				loadConcurrency:    1,
+				veleroOOMKilled:    1,
+				nodeAgentOOMKilled: 5,
+			},
+			wantVeleroMemory:    "1792Mi",
+			wantVeleroCPU:       "1",
+			wantNodeAgentMemory: "6Gi",
+			wantNodeAgentCPU:    "500m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			velero := recommendVeleroResources(tt.inputs)
+			if got := velero.Resources.Requests[corev1.ResourceMemory]; got.Cmp(resource.MustParse(tt.wantVeleroMemory)) != 0 {
+				t.Errorf("velero memory = %s, want %s", got.String(), tt.wantVeleroMemory)
+			}
+			if got := velero.Resources.Requests[corev1.ResourceCPU]; got.Cmp(resource.MustParse(tt.wantVeleroCPU)) != 0 {
+				t.Errorf("velero cpu = %s, want %s", got.String(), tt.wantVeleroCPU)
+			}
+			nodeAgent := recommendNodeAgentResources(tt.inputs)
+			if got := nodeAgent.Resources.Requests[corev1.ResourceMemory]; got.Cmp(resource.MustParse(tt.wantNodeAgentMemory)) != 0 {
+				t.Errorf("node-agent memory = %s, want %s", got.String(), tt.wantNodeAgentMemory)
+			}
+			if got := nodeAgent.Resources.Requests[corev1.ResourceCPU]; got.Cmp(resource.MustParse(tt.wantNodeAgentCPU)) != 0 {
+				t.Errorf("node-agent cpu = %s, want %s", got.String(), tt.wantNodeAgentCPU)
+			}
+			got, ok := nodeAgent.Resources.Requests[corev1.ResourceEphemeralStorage]
+			if ok != (tt.wantEphemeral != "") || (ok && got.Cmp(resource.MustParse(tt.wantEphemeral)) != 0) {
+				t.Errorf("node-agent ephemeral-storage = %s, want %s", got.String(), tt.wantEphemeral)
+			}
+			if len(velero.Reasons) == 0 || len(nodeAgent.Reasons) == 0 {
+				t.Errorf("expected recommendations to have reasons")
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileResourceAutoSizing(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        oadpv1alpha1.ResourceAutoSizingMode
+		userVelero  bool
+		objects     []client.Object
+		wantApplied map[string]bool
+	}{
+		{
+			name: "recommend mode does not apply",
+			mode: oadpv1alpha1.ResourceAutoSizingRecommend,
+			objects: []client.Object{
+				boundPV("pv-1", "10Gi"),
+			},
+			wantApplied: map[string]bool{common.Velero: false, common.NodeAgent: false},
+		},
+		{
+			name: "apply mode applies to components without resource allocations",
+			mode: oadpv1alpha1.ResourceAutoSizingApply,
+			objects: []client.Object{
+				boundPV("pv-1", "10Gi"),
+			},
+			userVelero:  true,
+			wantApplied: map[string]bool{common.Velero: false, common.NodeAgent: true},
+		},
+		{
+			name: "apply mode with observed OOMKilled node-agent",
+			mode: oadpv1alpha1.ResourceAutoSizingApply,
+			objects: []client.Object{
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-agent-1", Namespace: testNamespaceName, Labels: nodeAgentMatchLabels},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{{
+							Name: common.NodeAgent,
+							LastTerminationState: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{Reason: oomKilledReason},
+							},
+						}},
+					},
+				},
+			},
+			wantApplied: map[string]bool{common.Velero: true, common.NodeAgent: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+							UploaderType:          "kopia",
+						},
+						ResourceAutoSizing: &oadpv1alpha1.ResourceAutoSizing{Mode: tt.mode},
+					},
+				},
+			}
+			if tt.userVelero {
+				dpa.Spec.Configuration.Velero.PodConfig = &oadpv1alpha1.PodConfig{
+					ResourceAllocations: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				}
+			}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: context.Background(), dpa: dpa}
+			if _, err := r.ReconcileResourceAutoSizing(logr.Discard()); err != nil {
+				t.Fatalf("ReconcileResourceAutoSizing() error = %v", err)
+			}
+			if len(dpa.Status.ResourceRecommendations) != len(tt.wantApplied) {
+				t.Fatalf("expected %d recommendations, got %v", len(tt.wantApplied), dpa.Status.ResourceRecommendations)
+			}
+			for _, recommendation := range dpa.Status.ResourceRecommendations {
+				if recommendation.Applied != tt.wantApplied[recommendation.Component] {
+					t.Errorf("%s applied = %v, want %v", recommendation.Component, recommendation.Applied, tt.wantApplied[recommendation.Component])
+				}
+			}
+
+			veleroReqs, _ := r.getVeleroResourceReqs()
+			if tt.userVelero {
+				if got := veleroReqs.Requests[corev1.ResourceMemory]; got.Cmp(resource.MustParse("1Gi")) != 0 {
+					t.Errorf("expected user velero resource allocations to be kept, got %s", got.String())
+				}
+			}
+			nodeAgentReqs, _ := getNodeAgentResourceReqs(dpa)
+			if tt.wantApplied[common.NodeAgent] {
+				if _, ok := nodeAgentReqs.Requests[corev1.ResourceMemory]; !ok {
+					t.Errorf("expected node-agent recommendation to be applied, got %v", nodeAgentReqs)
+				}
+				if len(nodeAgentReqs.Limits) != 0 {
+					t.Errorf("expected no limits in the applied node-agent recommendation, got %v", nodeAgentReqs.Limits)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// stsTokenFileRequeueInterval is how often a missing projected service account token file is checked again
+const stsTokenFileRequeueInterval = 5 * time.Minute
+
+// STSCredentialsReconciler owns the cloud-credentials secret created by the STS standardized flow. It restores the
+// secret when it is deleted or its content drifts from the operator environment variables, and reports the state of
+// the secret and of the projected service account token file in the STSCredentialsReady condition of the DPAs.
+type STSCredentialsReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+	// TokenFilePath is the projected service account token file read by the STS credentials, defaults to stsflow.WebIdentityTokenPath
+	TokenFilePath string
+}
+
+// Reconcile restores the STS secret and sets the STSCredentialsReady condition
+func (r *STSCredentialsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("secret", req.NamespacedName)
+	secretName, credStringData := stsflow.GetSTSSecretDataFromEnv()
+	if secretName == "" || req.Name != secretName {
+		return ctrl.Result{}, nil
+	}
+
+	restored, err := r.restoreSTSSecret(ctx, req.NamespacedName, credStringData)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if restored != "" {
+		logger.Info("restored STS credentials secret", "reason", restored)
+	}
+
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionSTSCredentialsReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.STSCredentialsReadyReasonReady,
+		Message: fmt.Sprintf("secret %s matches the STS configuration of the operator", secretName),
+	}
+	if restored != "" {
+		condition.Reason = oadpv1alpha1.STSCredentialsReadyReasonRestored
+		condition.Message = fmt.Sprintf("secret %s was restored after it was %s", secretName, restored)
+	}
+	result := ctrl.Result{}
+	tokenFilePath := r.TokenFilePath
+	if tokenFilePath == "" {
+		tokenFilePath = stsflow.WebIdentityTokenPath
+	}
+	if _, err := os.Stat(tokenFilePath); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.STSCredentialsReadyReasonTokenFileMissing
+		condition.Message = fmt.Sprintf("projected service account token file %s of secret %s is not readable: %v", tokenFilePath, secretName, err)
+		result.RequeueAfter = stsTokenFileRequeueInterval
+	}
+
+	if err := r.setSTSCredentialsCondition(ctx, req.Namespace, condition); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// restoreSTSSecret creates the secret when it is deleted and resets the drifted keys, returning how the secret
+// drifted or "" when it is unchanged. The backup location reconcile appends the region and resource group again.
+func (r *STSCredentialsReconciler) restoreSTSSecret(ctx context.Context, key types.NamespacedName, credStringData map[string]string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, key, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{stsflow.STSSecretTypeLabel: stsflow.STSSecretTypeValue},
+			},
+			StringData: credStringData,
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return "", err
+		}
+		r.EventRecorder.Event(secret, corev1.EventTypeWarning, "STSCredentialsRestored", "secret was deleted and has been recreated from the STS configuration of the operator")
+		return "deleted", nil
+	}
+	if !stsflow.HasSTSSecretDrifted(secret, credStringData) {
+		return "", nil
+	}
+
+	original := secret.DeepCopy()
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[stsflow.STSSecretTypeLabel] = stsflow.STSSecretTypeValue
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for dataKey, value := range credStringData {
+		if !strings.HasPrefix(string(secret.Data[dataKey]), value) {
+			secret.Data[dataKey] = []byte(value)
+		}
+	}
+	if err := r.Client.Patch(ctx, secret, client.MergeFrom(original)); err != nil {
+		return "", err
+	}
+	r.EventRecorder.Event(secret, corev1.EventTypeWarning, "STSCredentialsRestored", "secret content drifted from the STS configuration of the operator and has been restored")
+	return "modified", nil
+}
+
+// setSTSCredentialsCondition sets the condition on the DPAs of the namespace
+func (r *STSCredentialsReconciler) setSTSCredentialsCondition(ctx context.Context, namespace string, condition metav1.Condition) error {
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.Client.List(ctx, dpaList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for _, item := range dpaList.Items {
+		key := types.NamespacedName{Name: item.Name, Namespace: item.Namespace}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			dpa := &oadpv1alpha1.DataProtectionApplication{}
+			if err := r.Client.Get(ctx, key, dpa); err != nil {
+				return err
+			}
+			if !apimeta.SetStatusCondition(&dpa.Status.Conditions, condition) {
+				return nil
+			}
+			return r.Client.Status().Update(ctx, dpa)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *STSCredentialsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isSTSSecret := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		secretName, _ := stsflow.GetSTSSecretDataFromEnv()
+		return secretName != "" && object.GetName() == secretName
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("sts-credentials").
+		For(&corev1.Secret{}, builder.WithPredicates(isSTSSecret)).
+		// a new DPA gets the condition of the secret in its namespace
+		Watches(&oadpv1alpha1.DataProtectionApplication{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, object client.Object) []reconcile.Request {
+			secretName, _ := stsflow.GetSTSSecretDataFromEnv()
+			if secretName == "" {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: secretName, Namespace: object.GetNamespace()}}}
+		}), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
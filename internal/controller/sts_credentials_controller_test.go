@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+func TestSTSCredentialsReconciler_Reconcile(t *testing.T) {
+	t.Setenv(stsflow.RoleARNEnvKey, "arn:aws:iam::123456789012:role/velero")
+	wantCredentials := stsflow.AWSSecretData("arn:aws:iam::123456789012:role/velero")["credentials"]
+	tokenFilePath := filepath.Join(t.TempDir(), "token")
+
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: testDpaName, Namespace: testNamespaceName},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stsflow.VeleroAWSSecretName,
+			Namespace: testNamespaceName,
+			Labels:    map[string]string{stsflow.STSSecretTypeLabel: stsflow.STSSecretTypeValue},
+		},
+		// the backup location reconcile appends the region
+		Data: map[string][]byte{"credentials": []byte(wantCredentials + "\nregion = us-east-1\n")},
+	}
+	schemeForFakeClient, err := getSchemeForFakeClient()
+	if err != nil {
+		t.Fatalf("error in creating fake client scheme, likely programmer error")
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(schemeForFakeClient).WithObjects(dpa, secret).WithStatusSubresource(dpa).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &STSCredentialsReconciler{Client: fakeClient, EventRecorder: recorder, TokenFilePath: tokenFilePath}
+	key := types.NamespacedName{Name: stsflow.VeleroAWSSecretName, Namespace: testNamespaceName}
+
+	reconcileAndGetCondition := func(wantRequeue bool) *metav1.Condition {
+		t.Helper()
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if (result.RequeueAfter > 0) != wantRequeue {
+			t.Errorf("expected requeue %v, got %v", wantRequeue, result)
+		}
+		got := &oadpv1alpha1.DataProtectionApplication{}
+		if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: testDpaName, Namespace: testNamespaceName}, got); err != nil {
+			t.Fatalf("failed to get DPA: %v", err)
+		}
+		return apimeta.FindStatusCondition(got.Status.Conditions, oadpv1alpha1.ConditionSTSCredentialsReady)
+	}
+
+	// the token file is not projected
+	condition := reconcileAndGetCondition(true)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != oadpv1alpha1.STSCredentialsReadyReasonTokenFileMissing {
+		t.Errorf("expected TokenFileMissing condition, got %v", condition)
+	}
+
+	if err := os.WriteFile(tokenFilePath, []byte("token"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	condition = reconcileAndGetCondition(false)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != oadpv1alpha1.STSCredentialsReadyReasonReady {
+		t.Errorf("expected Ready condition, got %v", condition)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no event for the secret with the appended region, got %d", len(recorder.Events))
+	}
+
+	// an edited role ARN is restored
+	secret.Data["credentials"] = []byte(strings.Replace(wantCredentials, "role/velero", "role/other", 1))
+	if err := fakeClient.Update(context.Background(), secret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	condition = reconcileAndGetCondition(false)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != oadpv1alpha1.STSCredentialsReadyReasonRestored {
+		t.Errorf("expected Restored condition, got %v", condition)
+	}
+	got := &corev1.Secret{}
+	if err := fakeClient.Get(context.Background(), key, got); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if string(got.Data["credentials"]) != wantCredentials {
+		t.Errorf("expected restored credentials %q, got %q", wantCredentials, got.Data["credentials"])
+	}
+
+	// a deleted secret is recreated
+	if err := fakeClient.Delete(context.Background(), got); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	reconcileAndGetCondition(false)
+	got = &corev1.Secret{}
+	if err := fakeClient.Get(context.Background(), key, got); err != nil {
+		t.Fatalf("expected the secret to be recreated: %v", err)
+	}
+	if got.Labels[stsflow.STSSecretTypeLabel] != stsflow.STSSecretTypeValue {
+		t.Errorf("expected the recreated secret to be labeled, got %v", got.Labels)
+	}
+	if len(recorder.Events) != 2 {
+		t.Errorf("expected two restore events, got %d", len(recorder.Events))
+	}
+
+	// other secrets are ignored
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "other", Namespace: testNamespaceName}}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "other", Namespace: testNamespaceName}, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Errorf("expected other secret not to be created, got %v", err)
+	}
+}
@@ -92,6 +92,11 @@ func (r *DataProtectionApplicationReconciler) ValidateDataProtectionCR(log logr.
 		}
 	}
 
+	if credentialsValidation := r.dpa.Spec.Configuration.CredentialsValidation; credentialsValidation != nil &&
+		credentialsValidation.Interval != nil && credentialsValidation.Interval.Duration < minCredentialsValidationInterval {
+		return false, fmt.Errorf("spec.configuration.credentialsValidation.interval must be at least %s", minCredentialsValidationInterval)
+	}
+
 	// ENSURE UPGRADES --------------------------------------------------------
 	// check for VSM/Volsync DataMover (OADP 1.2 or below) syntax
 	if r.dpa.Spec.Features != nil && r.dpa.Spec.Features.DataMover != nil {
@@ -383,5 +388,13 @@ func (r *DataProtectionApplicationReconciler) ValidateVeleroPlugins() (bool, err
 		return false, fmt.Errorf("%s and %s can not be both specified in DPA spec.configuration.velero.defaultPlugins", oadpv1alpha1.DefaultPluginAWS, oadpv1alpha1.DefaultPluginLegacyAWS)
 	}
 
+	if err := validateCustomPlugins(dpa); err != nil {
+		return false, err
+	}
+
+	if err := validatePluginConfigs(dpa); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
@@ -9,7 +9,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
-	"github.com/operator-framework/operator-lib/proxy"
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/velero/pkg/install"
 	"github.com/vmware-tanzu/velero/pkg/util/boolptr"
@@ -433,7 +432,23 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 	if veleroDeployment.Spec.ProgressDeadlineSeconds == nil {
 		veleroDeployment.Spec.ProgressDeadlineSeconds = ptr.To(int32(600))
 	}
+	if isVeleroHAEnabled(dpa) {
+		customizeVeleroDeploymentForHA(veleroDeployment)
+	}
 	r.appendPluginSpecificSpecs(veleroDeployment, veleroContainer, providerNeedsDefaultCreds)
+	credentials.AppendCredentialSourceVolumes(dpa, &veleroDeployment.Spec.Template, common.Velero)
+	if err := r.appendTrustedCABundle(&veleroDeployment.Spec.Template, common.Velero); err != nil {
+		return err
+	}
+	if err := appendPluginConfigs(dpa, &veleroDeployment.Spec.Template.Spec, veleroContainer); err != nil {
+		return err
+	}
+	if err := validateCustomPluginRequirements(dpa, veleroContainer); err != nil {
+		return err
+	}
+	if err := r.appendCredentialsHashes(&veleroDeployment.Spec.Template); err != nil {
+		return err
+	}
 	setPodTemplateSpecDefaults(&veleroDeployment.Spec.Template)
 	if configMapName, ok := dpa.Annotations[common.UnsupportedVeleroServerArgsAnnotation]; ok {
 		if configMapName != "" {
@@ -481,6 +496,8 @@ func (r *DataProtectionApplicationReconciler) appendPluginSpecificSpecs(veleroDe
 						AllowPrivilegeEscalation: ptr.To(false),
 					},
 				})
+			initContainers := veleroDeployment.Spec.Template.Spec.InitContainers
+			applyPluginInitContainerConfig(&initContainers[len(initContainers)-1], dpa.Spec.Configuration.Velero.DefaultPluginConfigs[plugin])
 
 			pluginNeedsCheck := providerNeedsDefaultCreds[pluginSpecificMap.ProviderName]
 
@@ -526,14 +543,15 @@ func (r *DataProtectionApplicationReconciler) appendPluginSpecificSpecs(veleroDe
 	// append custom plugin init containers
 	if dpa.Spec.Configuration.Velero.CustomPlugins != nil {
 		for _, plugin := range dpa.Spec.Configuration.Velero.CustomPlugins {
-			imagePullPolicy, err := common.GetImagePullPolicy(dpa.Spec.ImagePullPolicy, plugin.Image)
+			image := getCustomPluginImage(plugin)
+			imagePullPolicy, err := common.GetImagePullPolicy(dpa.Spec.ImagePullPolicy, image)
 			if err != nil {
 				r.Log.Error(err, "imagePullPolicy regex failed")
 			}
 			veleroDeployment.Spec.Template.Spec.InitContainers = append(
 				veleroDeployment.Spec.Template.Spec.InitContainers,
 				corev1.Container{
-					Image:                    plugin.Image,
+					Image:                    image,
 					Name:                     plugin.Name,
 					ImagePullPolicy:          imagePullPolicy,
 					Resources:                init_container_resources,
@@ -551,6 +569,8 @@ func (r *DataProtectionApplicationReconciler) appendPluginSpecificSpecs(veleroDe
 						AllowPrivilegeEscalation: ptr.To(false),
 					},
 				})
+			initContainers := veleroDeployment.Spec.Template.Spec.InitContainers
+			applyPluginInitContainerConfig(&initContainers[len(initContainers)-1], plugin.PluginConfig)
 		}
 	}
 }
@@ -613,8 +633,12 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroContainer(veleroCon
 	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil && dpa.Spec.Configuration.Velero.PodConfig != nil && dpa.Spec.Configuration.Velero.PodConfig.Env != nil {
 		veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, dpa.Spec.Configuration.Velero.PodConfig.Env)
 	}
-	// Append proxy settings to the container from environment variables
-	veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, proxy.ReadProxyVarsFromEnv())
+	// Append proxy settings to the container from the cluster-wide proxy
+	proxyEnvVars, err := r.getProxyEnvVars()
+	if err != nil {
+		return err
+	}
+	veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, proxyEnvVars)
 	if dpa.BackupImages() {
 		veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, []corev1.EnvVar{{
 			Name:  "OPENSHIFT_IMAGESTREAM_BACKUP",
@@ -807,6 +831,9 @@ func getResourceReqs(dpa *corev1.ResourceRequirements) (corev1.ResourceRequireme
 // Get Velero Resource Requirements
 func (r *DataProtectionApplicationReconciler) getVeleroResourceReqs() (corev1.ResourceRequirements, error) {
 	dpa := r.dpa
+	if resources := getAppliedResourceRecommendation(dpa, common.Velero); resources != nil {
+		return *resources, nil
+	}
 	if dpa.Spec.Configuration.Velero != nil && dpa.Spec.Configuration.Velero.PodConfig != nil {
 		return getResourceReqs(&dpa.Spec.Configuration.Velero.PodConfig.ResourceAllocations)
 	}
@@ -815,6 +842,9 @@ func (r *DataProtectionApplicationReconciler) getVeleroResourceReqs() (corev1.Re
 
 // Get NodeAgent Resource Requirements
 func getNodeAgentResourceReqs(dpa *oadpv1alpha1.DataProtectionApplication) (corev1.ResourceRequirements, error) {
+	if resources := getAppliedResourceRecommendation(dpa, common.NodeAgent); resources != nil {
+		return *resources, nil
+	}
 	if dpa.Spec.Configuration.NodeAgent != nil && dpa.Spec.Configuration.NodeAgent.PodConfig != nil {
 		return getResourceReqs(&dpa.Spec.Configuration.NodeAgent.PodConfig.ResourceAllocations)
 	}
@@ -841,11 +871,11 @@ func (r DataProtectionApplicationReconciler) noDefaultCredentials() (map[string]
 		}
 	} else {
 		for _, bsl := range dpa.Spec.BackupLocations {
-			if bsl.Velero != nil && bsl.Velero.Credential == nil {
+			if bsl.Velero != nil && bsl.Velero.Credential == nil && bsl.CredentialSource == nil {
 				bslProvider := strings.TrimPrefix(bsl.Velero.Provider, veleroIOPrefix)
 				providerNeedsDefaultCreds[bslProvider] = true
 			}
-			if bsl.Velero != nil && bsl.Velero.Credential != nil {
+			if bsl.Velero != nil && (bsl.Velero.Credential != nil || bsl.CredentialSource != nil) {
 				bslProvider := strings.TrimPrefix(bsl.Velero.Provider, veleroIOPrefix)
 				if _, found := providerNeedsDefaultCreds[bslProvider]; !found {
 					providerNeedsDefaultCreds[bslProvider] = false
@@ -870,7 +900,7 @@ func (r DataProtectionApplicationReconciler) noDefaultCredentials() (map[string]
 			// To handle the case where we want to manually hand the credentials for a cloud storage created
 			// Bucket credentials via configuration. Only AWS is supported
 			provider := strings.TrimPrefix(vsl.Velero.Provider, veleroIOPrefix)
-			if vsl.Velero.Credential != nil || provider == string(oadpv1alpha1.AWSBucketProvider) && hasCloudStorage {
+			if vsl.Velero.Credential != nil || vsl.CredentialSource != nil || provider == string(oadpv1alpha1.AWSBucketProvider) && hasCloudStorage {
 				if _, found := providerNeedsDefaultCreds[provider]; !found {
 					providerNeedsDefaultCreds[provider] = false
 				}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// defaultVeleroFailoverTimeout matches the default node monitor grace period plus some slack,
+	// so only a node NotReady for longer is reported as unreachable
+	defaultVeleroFailoverTimeout = 2 * time.Minute
+	// veleroHAResyncPeriod is how often the DPA is requeued to check the active Velero server when
+	// high availability is enabled, pod and node changes are not watched by the DPA controller
+	veleroHAResyncPeriod = 30 * time.Second
+)
+
+// isVeleroHAEnabled returns true if the Velero server high availability is enabled in the DPA
+func isVeleroHAEnabled(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	return dpa.Spec.Configuration != nil &&
+		dpa.Spec.Configuration.Velero != nil &&
+		dpa.Spec.Configuration.Velero.HighAvailability != nil &&
+		dpa.Spec.Configuration.Velero.HighAvailability.Enabled
+}
+
+func getVeleroFailoverTimeout(dpa *oadpv1alpha1.DataProtectionApplication) time.Duration {
+	if isVeleroHAEnabled(dpa) && dpa.Spec.Configuration.Velero.HighAvailability.FailoverTimeout != nil {
+		return dpa.Spec.Configuration.Velero.HighAvailability.FailoverTimeout.Duration
+	}
+	return defaultVeleroFailoverTimeout
+}
+
+// customizeVeleroDeploymentForHA makes sure two Velero servers never run at the same time.
+// Velero has no leader election, so a rolling update would briefly run two servers which
+// would both process schedules and create duplicated backups.
+func customizeVeleroDeploymentForHA(veleroDeployment *appsv1.Deployment) {
+	veleroDeployment.Spec.Strategy = appsv1.DeploymentStrategy{
+		Type: appsv1.RecreateDeploymentStrategyType,
+	}
+}
+
+// ReconcileVeleroFailover deletes the Velero server pod when the node running it is confirmed down, so the Velero
+// Deployment schedules a replacement, and reports the active pod in the DPA status.
+func (r *DataProtectionApplicationReconciler) ReconcileVeleroFailover(log logr.Logger) (bool, error) {
+	if !isVeleroHAEnabled(r.dpa) {
+		r.dpa.Status.Velero = nil
+		return true, nil
+	}
+	return r.failOverVeleroServer(log)
+}
+
+// failOverVeleroServer deletes the active Velero server pod when its node is confirmed down, that is the node was
+// deleted or carries the node.kubernetes.io/out-of-service taint. A pod on an unreachable node stays Terminating
+// forever and the Recreate strategy would wait for it, stalling scheduled backups and item operations. A node that is
+// only NotReady may be partitioned with the Velero server still running, and Velero has no leader election, so the
+// pod is kept and the unreachable node is reported until an administrator confirms it is down.
+func (r *DataProtectionApplicationReconciler) failOverVeleroServer(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	previousMessage := ""
+	status := &oadpv1alpha1.VeleroServerStatus{}
+	if dpa.Status.Velero != nil {
+		status.LastFailoverTime = dpa.Status.Velero.LastFailoverTime
+		previousMessage = dpa.Status.Velero.Message
+	}
+
+	veleroPods := &corev1.PodList{}
+	if err := r.List(r.Context, veleroPods, client.InNamespace(dpa.Namespace), client.MatchingLabels(getDpaAppLabels(dpa))); err != nil {
+		return false, err
+	}
+	for i := range veleroPods.Items {
+		pod := &veleroPods.Items[i]
+		nodeState, err := r.getPodNodeState(pod, getVeleroFailoverTimeout(dpa))
+		if err != nil {
+			return false, err
+		}
+		switch nodeState {
+		case podNodeDown:
+			log.Info("node of active Velero server is down, deleting the pod so a replacement is scheduled", "pod", pod.Name, "node", pod.Spec.NodeName)
+			if err := r.Delete(r.Context, pod, client.GracePeriodSeconds(0)); err != nil && !k8serror.IsNotFound(err) {
+				return false, err
+			}
+			status.LastFailoverTime = ptr.To(metav1.Now())
+			r.EventRecorder.Event(dpa,
+				corev1.EventTypeWarning,
+				"VeleroServerFailover",
+				fmt.Sprintf("node %s of velero pod %s/%s is deleted or out of service, pod deleted so the velero deployment schedules a replacement", pod.Spec.NodeName, pod.Namespace, pod.Name),
+			)
+			continue
+		case podNodeUnreachable:
+			status.Message = fmt.Sprintf(
+				"node %s of velero pod %s is NotReady for more than %s, the pod is not deleted because the velero server may still be running. If the node is shut down, delete the node or add the %s taint to it so a replacement is scheduled",
+				pod.Spec.NodeName, pod.Name, getVeleroFailoverTimeout(dpa), corev1.TaintNodeOutOfService,
+			)
+		}
+		if pod.DeletionTimestamp == nil && (status.ActivePod == "" || isPodReady(pod)) {
+			status.ActivePod = pod.Name
+			status.ActiveNode = pod.Spec.NodeName
+		}
+	}
+	if status.Message != "" && status.Message != previousMessage {
+		r.EventRecorder.Event(dpa, corev1.EventTypeWarning, "VeleroServerNodeUnreachable", status.Message)
+	}
+
+	dpa.Status.Velero = status
+	return true, nil
+}
+
+type podNodeState int
+
+const (
+	podNodeAvailable podNodeState = iota
+	// podNodeUnreachable is a node NotReady for longer than the failover timeout, which can be down or partitioned
+	podNodeUnreachable
+	// podNodeDown is a deleted node, or a node an administrator marked out of service
+	podNodeDown
+)
+
+// getPodNodeState returns whether the node the pod is scheduled on is confirmed down, or has not been Ready for longer
+// than timeout
+func (r *DataProtectionApplicationReconciler) getPodNodeState(pod *corev1.Pod, timeout time.Duration) (podNodeState, error) {
+	if pod.Spec.NodeName == "" {
+		return podNodeAvailable, nil
+	}
+	node := &corev1.Node{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		if k8serror.IsNotFound(err) {
+			return podNodeDown, nil
+		}
+		return podNodeAvailable, err
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeOutOfService {
+			return podNodeDown, nil
+		}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady && condition.Status != corev1.ConditionTrue && time.Since(condition.LastTransitionTime.Time) > timeout {
+			return podNodeUnreachable, nil
+		}
+	}
+	return podNodeAvailable, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
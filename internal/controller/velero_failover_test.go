@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func testHADpa(enabled bool) *oadpv1alpha1.DataProtectionApplication {
+	return &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testDpaName,
+			Namespace: testNamespaceName,
+		},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{
+					NoDefaultBackupLocation: true,
+					HighAvailability: &oadpv1alpha1.VeleroHighAvailability{
+						Enabled: enabled,
+					},
+				},
+			},
+		},
+	}
+}
+
+func testNode(name string, ready corev1.ConditionStatus, since time.Duration) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             ready,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-since)),
+				},
+			},
+		},
+	}
+}
+
+func testOutOfServiceNode(name string) *corev1.Node {
+	node := testNode(name, corev1.ConditionUnknown, 10*time.Second)
+	node.Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeOutOfService, Value: "nodeshutdown", Effect: corev1.TaintEffectNoExecute}}
+	return node
+}
+
+func testPod(name, node string, labels map[string]string, ready bool) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespaceName,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus}},
+		},
+	}
+}
+
+func TestDPAReconciler_ReconcileVeleroFailover(t *testing.T) {
+	tests := []struct {
+		name           string
+		dpa            *oadpv1alpha1.DataProtectionApplication
+		objects        []client.Object
+		wantStatus     *oadpv1alpha1.VeleroServerStatus
+		wantDeleted    string
+		wantFailedOver bool
+		wantMessage    bool
+	}{
+		{
+			name: "high availability disabled, no velero status",
+			dpa:  testHADpa(false),
+			objects: []client.Object{
+				testOutOfServiceNode("node-a"),
+				testPod("velero-1", "node-a", getAppLabels(testDpaName), true),
+			},
+		},
+		{
+			name: "high availability enabled, ready pod is reported as active",
+			dpa:  testHADpa(true),
+			objects: []client.Object{
+				testNode("node-a", corev1.ConditionTrue, time.Hour),
+				testNode("node-b", corev1.ConditionTrue, time.Hour),
+				testPod("velero-1", "node-a", getAppLabels(testDpaName), false),
+				testPod("velero-2", "node-b", getAppLabels(testDpaName), true),
+			},
+			wantStatus: &oadpv1alpha1.VeleroServerStatus{
+				ActivePod:  "velero-2",
+				ActiveNode: "node-b",
+			},
+		},
+		{
+			name: "high availability enabled, node recently NotReady, no failover",
+			dpa:  testHADpa(true),
+			objects: []client.Object{
+				testNode("node-a", corev1.ConditionUnknown, 10*time.Second),
+				testPod("velero-1", "node-a", getAppLabels(testDpaName), false),
+			},
+			wantStatus: &oadpv1alpha1.VeleroServerStatus{
+				ActivePod:  "velero-1",
+				ActiveNode: "node-a",
+			},
+		},
+		{
+			name: "high availability enabled, node NotReady past failover timeout, pod is kept and node reported",
+			dpa:  testHADpa(true),
+			objects: []client.Object{
+				testNode("node-a", corev1.ConditionUnknown, 10*time.Minute),
+				testPod("velero-1", "node-a", getAppLabels(testDpaName), true),
+			},
+			wantStatus: &oadpv1alpha1.VeleroServerStatus{
+				ActivePod:  "velero-1",
+				ActiveNode: "node-a",
+			},
+			wantMessage: true,
+		},
+		{
+			name: "high availability enabled, node out of service, pod is removed",
+			dpa:  testHADpa(true),
+			objects: []client.Object{
+				testOutOfServiceNode("node-a"),
+				testPod("velero-1", "node-a", getAppLabels(testDpaName), true),
+			},
+			wantStatus:     &oadpv1alpha1.VeleroServerStatus{},
+			wantDeleted:    "velero-1",
+			wantFailedOver: true,
+		},
+		{
+			name: "high availability enabled, node deleted, pod is removed",
+			dpa:  testHADpa(true),
+			objects: []client.Object{
+				testPod("velero-1", "node-a", getAppLabels(testDpaName), true),
+			},
+			wantStatus:     &oadpv1alpha1.VeleroServerStatus{},
+			wantDeleted:    "velero-1",
+			wantFailedOver: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, tt.dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Context:       context.Background(),
+				dpa:           tt.dpa,
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+			if _, err := r.ReconcileVeleroFailover(logr.Discard()); err != nil {
+				t.Fatalf("ReconcileVeleroFailover() error = %v", err)
+			}
+
+			if tt.wantDeleted != "" {
+				err := r.Get(r.Context, types.NamespacedName{Name: tt.wantDeleted, Namespace: testNamespaceName}, &corev1.Pod{})
+				if !k8serror.IsNotFound(err) {
+					t.Errorf("expected pod %s to be deleted, got error %v", tt.wantDeleted, err)
+				}
+			}
+
+			status := tt.dpa.Status.Velero
+			if tt.wantStatus == nil {
+				if status != nil {
+					t.Errorf("expected no velero status, got %v", status)
+				}
+				return
+			}
+			if status == nil {
+				t.Fatalf("expected velero status %v, got nil", tt.wantStatus)
+			}
+			if (status.LastFailoverTime != nil) != tt.wantFailedOver {
+				t.Errorf("expected failover %v, got last failover time %v", tt.wantFailedOver, status.LastFailoverTime)
+			}
+			if (status.Message != "") != tt.wantMessage {
+				t.Errorf("expected message %v, got %q", tt.wantMessage, status.Message)
+			}
+			status.LastFailoverTime = nil
+			status.Message = ""
+			if *status != *tt.wantStatus {
+				t.Errorf("expected velero status %v, got %v", tt.wantStatus, status)
+			}
+		})
+	}
+}
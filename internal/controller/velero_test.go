@@ -387,6 +387,7 @@ type TestBuiltVeleroDeploymentOptions struct {
 	toleration       []corev1.Toleration
 	nodeSelector     map[string]string
 	loadAffinity     []corev1.NodeSelectorTerm
+	recreateStrategy bool
 }
 
 func createTestBuiltVeleroDeployment(options TestBuiltVeleroDeploymentOptions) *appsv1.Deployment {
@@ -526,6 +527,12 @@ func createTestBuiltVeleroDeployment(options TestBuiltVeleroDeploymentOptions) *
 		testBuiltVeleroDeployment.Spec.Template.Spec.DNSConfig = options.dnsConfig
 	}
 
+	if options.recreateStrategy {
+		testBuiltVeleroDeployment.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+
 	return testBuiltVeleroDeployment
 }
 
@@ -567,6 +574,30 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name: "valid DPA CR with high availability, Velero Deployment is built with Recreate strategy",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							HighAvailability: &oadpv1alpha1.VeleroHighAvailability{
+								Enabled: true,
+							},
+						},
+					},
+				},
+			),
+			veleroDeployment: testVeleroDeployment.DeepCopy(),
+			wantVeleroDeployment: createTestBuiltVeleroDeployment(TestBuiltVeleroDeploymentOptions{
+				args: []string{
+					defaultFileSystemBackupTimeout,
+					defaultRestoreResourcePriorities,
+					defaultDisableInformerCache,
+				},
+				recreateStrategy: true,
+			}),
+		},
 		{
 			name: "valid DPA CR, Velero Deployment is built with custom labels",
 			dpa: createTestDpaWith(
@@ -1719,6 +1750,63 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name: "valid DPA CR with kubevirt plugin configuration, Velero Deployment is built with kubevirt plugin env, volumes and resources",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							DefaultPlugins: []oadpv1alpha1.DefaultPlugin{
+								oadpv1alpha1.DefaultPluginAWS,
+								oadpv1alpha1.DefaultPluginKubeVirt,
+							},
+							DefaultPluginConfigs: map[oadpv1alpha1.DefaultPlugin]oadpv1alpha1.PluginConfig{
+								oadpv1alpha1.DefaultPluginKubeVirt: {
+									Env: []corev1.EnvVar{{Name: "KUBEVIRT_CONFIG", Value: "/etc/kubevirt-plugin/config.yaml"}},
+									Volumes: []oadpv1alpha1.PluginVolume{{
+										Name:      "kubevirt-plugin-config",
+										ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "kubevirt-plugin-config"}},
+									}},
+									VolumeMounts: []corev1.VolumeMount{{Name: "kubevirt-plugin-config", MountPath: "/etc/kubevirt-plugin"}},
+									Resources: &corev1.ResourceRequirements{
+										Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+									},
+									ImagePullPolicy: ptr.To(corev1.PullIfNotPresent),
+								},
+							},
+						},
+					},
+				},
+			),
+			veleroDeployment: testVeleroDeployment.DeepCopy(),
+			wantVeleroDeployment: createTestBuiltVeleroDeployment(TestBuiltVeleroDeploymentOptions{
+				initContainers: []corev1.Container{
+					pluginContainer(common.VeleroPluginForAWS, common.AWSPluginImage),
+					func() corev1.Container {
+						container := pluginContainer(common.KubeVirtPlugin, common.KubeVirtPluginImage)
+						container.ImagePullPolicy = corev1.PullIfNotPresent
+						container.Resources = corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+						}
+						return container
+					}(),
+				},
+				volumes: []corev1.Volume{{
+					Name: "kubevirt-plugin-config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "kubevirt-plugin-config"}},
+					},
+				}},
+				volumeMounts: []corev1.VolumeMount{{Name: "kubevirt-plugin-config", MountPath: "/etc/kubevirt-plugin"}},
+				env:          append(slices.Clone(baseEnvVars), corev1.EnvVar{Name: "KUBEVIRT_CONFIG", Value: "/etc/kubevirt-plugin/config.yaml"}),
+				args: []string{
+					defaultFileSystemBackupTimeout,
+					defaultRestoreResourcePriorities,
+					defaultDisableInformerCache,
+				},
+			}),
+		},
 		{
 			name: "valid DPA CR with aws and hypershift plugin, Velero Deployment is built with aws and hypershift plugin",
 			dpa: createTestDpaWith(
@@ -2528,14 +2616,20 @@ func TestDPAReconciler_buildVeleroDeploymentWithAzureWorkloadIdentity(t *testing
 				defer os.Unsetenv(key)
 			}
 
+			fakeClient, err := getFakeClientFromObjects()
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+
 			// Create reconciler
 			r := &DataProtectionApplicationReconciler{
-				dpa: tt.dpa,
-				Log: logr.Discard(),
+				Client: fakeClient,
+				dpa:    tt.dpa,
+				Log:    logr.Discard(),
 			}
 
 			// Build the deployment
-			err := r.buildVeleroDeployment(tt.veleroDeployment)
+			err = r.buildVeleroDeployment(tt.veleroDeployment)
 			if err != nil {
 				t.Errorf("buildVeleroDeployment() error = %v", err)
 				return
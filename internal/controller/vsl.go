@@ -54,6 +54,10 @@ var validAzureKeys = map[string]bool{
 func (r *DataProtectionApplicationReconciler) LabelVSLSecrets(log logr.Logger) (bool, error) {
 	dpa := r.dpa
 	for _, vsl := range dpa.Spec.SnapshotLocations {
+		// Locations using a credential source have no secret
+		if vsl.CredentialSource != nil {
+			continue
+		}
 		provider := strings.TrimPrefix(vsl.Velero.Provider, veleroIOPrefix)
 		switch provider {
 		case "aws":
@@ -175,7 +179,11 @@ func (r *DataProtectionApplicationReconciler) ValidateVolumeSnapshotLocations()
 			}
 		}
 
-		if err := r.ensureVslSecretDataExists(&vslSpec); err != nil {
+		if vslSpec.CredentialSource != nil {
+			if err := validateCredentialSource(vslSpec.CredentialSource, vslYAMLPath, vslSpec.Velero.Credential, vslSpec.Velero.Config); err != nil {
+				return false, err
+			}
+		} else if err := r.ensureVslSecretDataExists(&vslSpec); err != nil {
 			return false, err
 		}
 
@@ -228,6 +236,11 @@ func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(l
 			}
 
 			vsl.Spec = *vslSpec.Velero
+			if vslSpec.CredentialSource != nil {
+				mount := credentials.NewSnapshotLocationCredentialSourceMount(vslName, vslSpec.CredentialSource)
+				vsl.Spec.Credential = nil
+				vsl.Spec.Config = credentialSourceConfig(vsl.Spec.Config, mount)
+			}
 			return nil
 		})
 		if err != nil {
@@ -304,10 +317,17 @@ func (r *DataProtectionApplicationReconciler) ensureVslSecretDataExists(vsl *oad
 			if vsl.Velero.Config[CredentialsFileKey] != "" {
 				return nil
 			}
-			_, _, err := r.getSecretNameAndKey(vsl.Velero.Config, vsl.Velero.Credential, oadpv1alpha1.DefaultPlugin(vsl.Velero.Provider))
+			secretName, secretKey, err := r.getSecretNameAndKey(vsl.Velero.Config, vsl.Velero.Credential, oadpv1alpha1.DefaultPlugin(vsl.Velero.Provider))
+			if err != nil {
+				return err
+			}
+			secret, err := r.getProviderSecret(secretName)
 			if err != nil {
 				return err
 			}
+			if err := validateCredentialsFormat(vsl.Velero.Provider, secret, secretKey, vsl.Velero.Config[AWSProfile]); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -10,11 +10,30 @@ import (
 
 func init() {
 	pkg.CLI.Flags().DurationVarP(&pkg.RequestTimeout, "request-timeout", "r", pkg.DefaultRequestTimeout, "Timeout per OADP server request (like collecting logs from a backup)")
+	pkg.CLI.Flags().DurationVarP(&pkg.Timeout, "timeout", "t", pkg.DefaultTimeout, "Timeout per gather section (like collecting all Backups describe and logs), the requests not finished are reported as errors")
+	pkg.CLI.Flags().IntVarP(&pkg.Workers, "workers", "w", pkg.DefaultWorkers, "Number of OADP server and cluster requests run at the same time")
 	pkg.CLI.Flags().BoolVarP(&pkg.SkipTLS, "skip-tls", "s", false, "Run OADP server requests with insecure TLS connections (recommended if a custom CA certificate is used) (default false)")
+	pkg.CLI.Flags().DurationVar(&pkg.Since, "since", 0, "Only gather Backups, Restores and related resources created in the last duration, like 1h or 24h (default all)")
+	pkg.CLI.Flags().StringVar(&pkg.SinceTime, "since-time", "", "Only gather Backups, Restores and related resources created after a RFC3339 date, like 2025-05-05T10:00:00Z (default all)")
+	pkg.CLI.Flags().BoolVarP(&pkg.Essential, "essential", "e", false, "Only gather Backups, Restores and related resources that did not complete successfully (default false)")
+	pkg.CLI.Flags().StringVar(&pkg.Target.Backup, "backup", "", "Only gather a Backup and its related resources, like DataUploads, PodVolumeBackups, BackupRepositories and node-agent logs")
+	pkg.CLI.Flags().StringVar(&pkg.Target.Restore, "restore", "", "Only gather a Restore, its Backup and their related resources, like DataDownloads, PodVolumeRestores and node-agent logs")
+	pkg.CLI.Flags().StringVar(&pkg.Target.Schedule, "schedule", "", "Only gather a Schedule, its Backups and their related resources")
+	pkg.CLI.Flags().BoolVar(&pkg.Metrics, "metrics", false, "Scrape the velero and node-agent Prometheus metrics through the API server proxy (default false)")
+	pkg.CLI.Flags().BoolVar(&pkg.Kopia, "kopia", false, "Inspect kopia BackupRepositories with read-only kopia commands, run in a short-lived pod with the BackupStorageLocation credentials (default false)")
+	pkg.CLI.Flags().StringVar(&pkg.KopiaImage, "kopia-image", "", "Image with kopia CLI of the kopia inspection pods, required with --kopia, velero and node-agent images have no kopia CLI")
+	pkg.CLI.Flags().DurationVar(&pkg.KopiaTimeout, "kopia-timeout", pkg.DefaultKopiaTimeout, "Timeout per kopia inspection command")
+	pkg.CLI.Flags().BoolVar(&pkg.RedactNames, "redact-names", false, "Replace namespaces and object names by stable pseudonyms in all gathered files (default false)")
+	pkg.CLI.Flags().StringVar(&pkg.RedactKey, "redact-key", "", "Key the --redact-names pseudonyms are derived from, so must-gathers redacted with the same key can be compared with diff (default random key)")
+	pkg.CLI.Flags().StringVar(&pkg.RedactRules, "redact-rules", "", "Path to a yaml file overriding the redaction rules, like keys, patterns and names (default Secret data, credential-like keys and presigned URL signatures)")
 	// TODO caCertFile?
 	pkg.CLI.Flags().BoolP("help", "h", false, "Show OADP Must-gather help message.")
 
 	pkg.CLI.SetHelpCommand(&cobra.Command{Hidden: true, Use: ""})
+	pkg.CLI.AddCommand(pkg.Analyze)
+
+	pkg.Diff.Flags().StringVarP(&pkg.DiffOutput, "output", "o", ".", "Directory the diff files oadp-must-gather-diff.md and oadp-must-gather-diff.json are written to")
+	pkg.CLI.AddCommand(pkg.Diff)
 }
 
 func main() {
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openshift/oadp-operator/must-gather/pkg/gather"
+	"github.com/openshift/oadp-operator/must-gather/pkg/templates"
+)
+
+var Analyze = &cobra.Command{
+	Use:   "analyze <must-gather-directory>",
+	Short: "Regenerate the summary of an existing OADP Must-gather directory, without cluster connection",
+	Long: `Regenerate the summary of an existing OADP Must-gather directory, without cluster connection.
+
+The objects written under must-gather/clusters/<id>/ by OADP Must-gather and oc adm inspect are loaded, and the summary is written to oadp-must-gather-analysis.md and oadp-must-gather-analysis.json with the current templates and diagnosis rules, so older must-gathers can be analyzed with newer versions of this tool. The gathered files and the summary written by the gather are kept, and the diagnosis uses the time the must-gather was gathered.`,
+	Example: `  # analyzing the directory created by oc adm must-gather
+  gather analyze must-gather.local.123456789
+
+  # analyzing a cluster directory of an OADP Must-gather
+  gather analyze must-gather/clusters/1a2b3c4d`,
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		clusterPaths, err := findClusterPaths(args[0])
+		if err != nil {
+			fmt.Printf("Exiting OADP must-gather analyze: %v\n", err)
+			return err
+		}
+		if len(clusterPaths) != 1 {
+			err = fmt.Errorf("found %d OADP must-gather cluster directories in %s, pass one of them: %v", len(clusterPaths), args[0], clusterPaths)
+			fmt.Printf("Exiting OADP must-gather analyze: %v\n", err)
+			return err
+		}
+		outputPath := clusterPaths[0] + "/"
+
+		scheme := runtime.NewScheme()
+		err = addToScheme(scheme)
+		if err != nil {
+			return err
+		}
+		resources := newClusterResources()
+		err = gather.Load(scheme, outputPath, append(resources.lists(), resources.clusterVersionList, resources.downloadRequestList)...)
+		if err != nil {
+			fmt.Printf("Exiting OADP must-gather analyze, an error happened while loading %s: %v\n", outputPath, err)
+			return err
+		}
+		clusterVersion, major, minor, err := getClusterVersion(resources.clusterVersionList)
+		if err != nil {
+			return err
+		}
+
+		metrics, err := gather.LoadMetrics(outputPath)
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		templates.SetAnalysis(true)
+		writeSummary(outputPath, filepath.Base(clusterPaths[0]), clusterVersion, resources, getOperatorInstallation(resources, major, minor), metrics, nil, nil, nil, nil, getGatherTime(outputPath, resources))
+		// do not tar!
+		err = templates.Write(outputPath, templates.AnalysisName)
+		if err != nil {
+			fmt.Printf("Error occurred: %v\n", err)
+			return err
+		}
+		fmt.Printf("OADP must-gather analysis written to %[1]s%[2]s.md and %[1]s%[2]s.json\n", outputPath, templates.AnalysisName)
+		return nil
+	},
+}
+
+// getGatherTime returns the time a must-gather directory was gathered: the modification time of the summary written
+// at the end of the gather, or the newest creation timestamp of the objects when there is no summary
+func getGatherTime(outputPath string, resources *clusterResources) time.Time {
+	info, err := os.Stat(outputPath + templates.SummaryName + ".md")
+	if err == nil {
+		return info.ModTime()
+	}
+	gatherTime := time.Time{}
+	for _, resource := range resources.lists() {
+		items, err := meta.ExtractList(resource)
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			object, ok := item.(metav1.Object)
+			if ok && object.GetCreationTimestamp().After(gatherTime) {
+				gatherTime = object.GetCreationTimestamp().Time
+			}
+		}
+	}
+	if gatherTime.IsZero() {
+		return time.Now()
+	}
+	return gatherTime
+}
+
+// findClusterPaths returns the must-gather/clusters/<id> directories in directory, which can be the directory created by
+// `oc adm must-gather`, the must-gather directory or a cluster directory
+func findClusterPaths(directory string) ([]string, error) {
+	_, err := os.Stat(directory)
+	if err != nil {
+		return nil, err
+	}
+	clusterPaths := []string{}
+	for _, pattern := range []string{"", "clusters/*", "*/clusters/*", "*/*/clusters/*"} {
+		matches, err := filepath.Glob(filepath.Join(directory, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			_, err := os.Stat(filepath.Join(match, "cluster-scoped-resources/config.openshift.io/clusterversions.yaml"))
+			if err == nil && !slices.Contains(clusterPaths, filepath.Clean(match)) {
+				clusterPaths = append(clusterPaths, filepath.Clean(match))
+			}
+		}
+	}
+	return clusterPaths, nil
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/oadp-operator/must-gather/pkg/templates"
+)
+
+func TestGetGatherTime(t *testing.T) {
+	outputPath := t.TempDir() + "/"
+	created := time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)
+	resources := newClusterResources()
+	resources.backupList.Items = []velerov1.Backup{
+		{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(created.Add(-time.Hour))}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.NewTime(created)}},
+	}
+
+	if got := getGatherTime(outputPath, resources); !got.Equal(created) {
+		t.Errorf("got %v without summary, want newest creation timestamp %v", got, created)
+	}
+
+	gathered := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	summaryPath := filepath.Join(outputPath, templates.SummaryName+".md")
+	if err := os.WriteFile(summaryPath, []byte("summary"), templates.FilePermission); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(summaryPath, gathered, gathered); err != nil {
+		t.Fatal(err)
+	}
+	if got := getGatherTime(outputPath, resources); !got.Equal(gathered) {
+		t.Errorf("got %v, want summary modification time %v", got, gathered)
+	}
+}
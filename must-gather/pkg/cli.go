@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"fmt"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
@@ -18,11 +19,18 @@ import (
 	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
+	"github.com/openshift/oadp-operator/must-gather/pkg/diagnosis"
 	"github.com/openshift/oadp-operator/must-gather/pkg/gather"
+	"github.com/openshift/oadp-operator/must-gather/pkg/redact"
+	"github.com/openshift/oadp-operator/must-gather/pkg/report"
 	"github.com/openshift/oadp-operator/must-gather/pkg/templates"
 )
 
@@ -33,12 +41,27 @@ const (
 	addToSchemeError = "Exiting OADP must-gather, an error happened while adding %s to scheme: %v\n"
 
 	DefaultRequestTimeout = 5 * time.Second
+	DefaultTimeout        = 10 * time.Minute
+	DefaultWorkers        = 8
+	DefaultKopiaTimeout   = time.Minute
 )
 
 var (
 	Timeout        time.Duration
 	RequestTimeout time.Duration
+	Workers        int
 	SkipTLS        bool
+	Since          time.Duration
+	SinceTime      string
+	Essential      bool
+	Target         gather.Target
+	RedactNames    bool
+	RedactRules    string
+	RedactKey      string
+	Metrics        bool
+	Kopia          bool
+	KopiaImage     string
+	KopiaTimeout   time.Duration
 
 	CLI = &cobra.Command{
 		Use: fmt.Sprintf("oc adm must-gather --image=%[1]s -- /usr/bin/gather", mustGatherImage),
@@ -53,308 +76,255 @@ For more information, check OADP must-gather documentation: https://docs.redhat.
   oc adm must-gather --image=%[1]s -- /usr/bin/gather --request-timeout 1m
 
   # running OADP Must-gather with timeout of 15 seconds per OADP server request and with insecure TLS connections
-  oc adm must-gather --image=%[1]s -- /usr/bin/gather --request-timeout 15s --skip-tls`, mustGatherImage),
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --request-timeout 15s --skip-tls
+
+  # running OADP Must-gather with 16 requests at the same time and timeout of 30 minutes per section, like Backups
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --workers 16 --timeout 30m
+
+  # running OADP Must-gather only for Backups, Restores and related resources created in the last 24 hours
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --since 24h
+
+  # running OADP Must-gather only for Backups, Restores and related resources that did not complete successfully
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --essential
+
+  # running OADP Must-gather only for a Backup and its related resources, like DataUploads, PodVolumeBackups and node-agent logs
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --backup my-backup
+
+  # running OADP Must-gather only for the Backups of a Schedule and their related resources
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --schedule my-schedule
+
+  # running OADP Must-gather with a snapshot of the velero and node-agent Prometheus metrics
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --metrics
+
+  # running OADP Must-gather with read-only kopia inspection of the BackupRepositories, in pods of an image with the kopia CLI, with timeout of 2 minutes per kopia command
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --kopia --kopia-image <image> --kopia-timeout 2m
+
+  # running OADP Must-gather with namespaces and object names replaced by pseudonyms
+  oc adm must-gather --image=%[1]s -- /usr/bin/gather --redact-names
+
+  # regenerating the summary of an existing OADP Must-gather directory, without cluster connection
+  gather analyze must-gather.local.123456789
+
+  # comparing the OADP configuration of two existing OADP Must-gather directories, without cluster connection
+  gather diff must-gather.local.123456789 must-gather.local.987654321`, mustGatherImage),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			if RequestTimeout <= 0 {
-				err := fmt.Errorf("--request-timeout value must be greater than zero")
+			if Kopia && len(KopiaImage) == 0 {
+				err := fmt.Errorf("--kopia-image is required with --kopia, velero and node-agent images have no kopia CLI")
 				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-
-			clusterConfig := config.GetConfigOrDie()
-			// https://github.com/openshift/oc/blob/46db7c2bce5a57e3c3d9347e7e1e107e61dbd306/pkg/cli/admin/inspect/inspect.go#L142
-			clusterConfig.QPS = 999999
-			clusterConfig.Burst = 999999
-
-			clusterClient, err := client.New(clusterConfig, client.Options{})
-			if err != nil {
-				fmt.Printf("Exiting OADP must-gather, an error happened while creating Go client: %v\n", err)
-				return err
-			}
-
-			err = openshiftconfigv1.AddToScheme(clusterClient.Scheme())
-			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/openshift/api/config/v1", err)
+			if KopiaTimeout <= 0 {
+				err := fmt.Errorf("--kopia-timeout value must be greater than zero")
+				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-			err = operatorsv1alpha1.AddToScheme(clusterClient.Scheme())
-			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/operator-framework/api/pkg/operators/v1alpha1", err)
+			if RequestTimeout <= 0 {
+				err := fmt.Errorf("--request-timeout value must be greater than zero")
+				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-			err = storagev1.AddToScheme(clusterClient.Scheme())
-			if err != nil {
-				fmt.Printf(addToSchemeError, "k8s.io/api/storage/v1", err)
+			if Timeout <= 0 {
+				err := fmt.Errorf("--timeout value must be greater than zero")
+				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-			err = volumesnapshotv1.AddToScheme(clusterClient.Scheme())
-			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1", err)
+			if Workers <= 0 {
+				err := fmt.Errorf("--workers value must be greater than zero")
+				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-			err = corev1.AddToScheme(clusterClient.Scheme())
+			since, err := getSince()
 			if err != nil {
-				fmt.Printf(addToSchemeError, "k8s.io/api/core/v1", err)
+				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-			// OADP CRDs
-			err = oadpv1alpha1.AddToScheme(clusterClient.Scheme())
+			err = Target.Validate()
 			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/openshift/oadp-operator/api/v1alpha1", err)
+				fmt.Printf("Exiting OADP must-gather: %v\n", err)
 				return err
 			}
-			err = nac1alpha1.AddToScheme(clusterClient.Scheme())
+			redactor, err := getRedactor()
 			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/migtools/oadp-non-admin/api/v1alpha1", err)
+				fmt.Printf("Exiting OADP must-gather, an error happened while reading redaction rules: %v\n", err)
 				return err
 			}
-			err = velerov1.AddToScheme(clusterClient.Scheme())
+			templates.SetRedactor(redactor)
+
+			clusterConfig := config.GetConfigOrDie()
+			// https://github.com/openshift/oc/blob/46db7c2bce5a57e3c3d9347e7e1e107e61dbd306/pkg/cli/admin/inspect/inspect.go#L142
+			clusterConfig.QPS = 999999
+			clusterConfig.Burst = 999999
+
+			clusterClient, err := client.New(clusterConfig, client.Options{})
 			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/vmware-tanzu/velero/pkg/apis/velero/v1", err)
+				fmt.Printf("Exiting OADP must-gather, an error happened while creating Go client: %v\n", err)
 				return err
 			}
-			err = velerov2alpha1.AddToScheme(clusterClient.Scheme())
+
+			err = addToScheme(clusterClient.Scheme())
 			if err != nil {
-				fmt.Printf(addToSchemeError, "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1", err)
 				return err
 			}
 
-			clusterVersionList := &openshiftconfigv1.ClusterVersionList{}
-			err = gather.AllResources(clusterClient, clusterVersionList)
+			resources := newClusterResources()
+			err = gather.AllResources(clusterClient, resources.clusterVersionList)
 			if err != nil {
 				fmt.Printf("Exiting OADP must-gather, an error happened while gathering ClusterVersion: %v\n", err)
 				return err
 			}
-			if len(clusterVersionList.Items) == 0 {
-				err = fmt.Errorf("no ClusterVersion found in cluster")
-				fmt.Printf("Exiting OADP must-gather, an error happened while gathering ClusterVersion: %v\n", err)
-				return err
-			}
-			clusterVersion := &clusterVersionList.Items[0]
-			clusterID := string(clusterVersion.Spec.ClusterID[:8])
-			versionParts := strings.Split(clusterVersion.Status.Desired.Version, ".")
-			major, err := strconv.Atoi(versionParts[0])
+			clusterVersion, major, minor, err := getClusterVersion(resources.clusterVersionList)
 			if err != nil {
-				fmt.Printf("Exiting OADP must-gather, an error happened while parsing OpenShift major version: %v\n", err)
-				return err
-			}
-			minor, err := strconv.Atoi(versionParts[1])
-			if err != nil {
-				fmt.Printf("Exiting OADP must-gather, an error happened while parsing OpenShift minor version: %v\n", err)
 				return err
 			}
+			clusterID := string(clusterVersion.Spec.ClusterID[:8])
 
 			// be careful about folder structure, otherwise may break `omg` usage
 			outputPath := fmt.Sprintf("must-gather/clusters/%s/", clusterID)
 
-			var resourcesToGather []client.ObjectList
-			infrastructureList := &openshiftconfigv1.InfrastructureList{}
-			nodeList := &corev1.NodeList{}
-			clusterServiceVersionList := &operatorsv1alpha1.ClusterServiceVersionList{}
-			subscriptionList := &operatorsv1alpha1.SubscriptionList{}
-
-			dataProtectionApplicationList := &oadpv1alpha1.DataProtectionApplicationList{}
-			dataProtectionTestList := &oadpv1alpha1.DataProtectionTestList{}
-			cloudStorageList := &oadpv1alpha1.CloudStorageList{}
-			backupStorageLocationList := &velerov1.BackupStorageLocationList{}
-			volumeSnapshotLocationList := &velerov1.VolumeSnapshotLocationList{}
-			backupList := &velerov1.BackupList{}
-			restoreList := &velerov1.RestoreList{}
-			scheduleList := &velerov1.ScheduleList{}
-			backupRepositoryList := &velerov1.BackupRepositoryList{}
-			dataUploadList := &velerov2alpha1.DataUploadList{}
-			dataDownloadList := &velerov2alpha1.DataDownloadList{}
-			podVolumeBackupList := &velerov1.PodVolumeBackupList{}
-			podVolumeRestoreList := &velerov1.PodVolumeRestoreList{}
-
-			deleteBackupRequestList := &velerov1.DeleteBackupRequestList{}
-			serverStatusRequestList := &velerov1.ServerStatusRequestList{}
-			nonAdminBackupStorageLocationRequestList := &nac1alpha1.NonAdminBackupStorageLocationRequestList{}
-			nonAdminBackupStorageLocationList := &nac1alpha1.NonAdminBackupStorageLocationList{}
-			nonAdminBackupList := &nac1alpha1.NonAdminBackupList{}
-			nonAdminRestoreList := &nac1alpha1.NonAdminRestoreList{}
-			nonAdminDownloadRequestList := &nac1alpha1.NonAdminDownloadRequestList{}
-
-			storageClassList := &storagev1.StorageClassList{}
-			volumeSnapshotClassList := &volumesnapshotv1.VolumeSnapshotClassList{}
-			csiDriverList := &storagev1.CSIDriverList{}
-			resourcesToGather = append(resourcesToGather,
-				infrastructureList,
-				nodeList,
-				clusterServiceVersionList,
-				subscriptionList,
-
-				dataProtectionApplicationList,
-				dataProtectionTestList,
-				cloudStorageList,
-				backupStorageLocationList,
-				volumeSnapshotLocationList,
-				backupList,
-				restoreList,
-				scheduleList,
-				backupRepositoryList,
-				dataUploadList,
-				dataDownloadList,
-				podVolumeBackupList,
-				podVolumeRestoreList,
-
-				deleteBackupRequestList,
-				serverStatusRequestList,
-				nonAdminBackupStorageLocationRequestList,
-				nonAdminBackupStorageLocationList,
-				nonAdminBackupList,
-				nonAdminRestoreList,
-				nonAdminDownloadRequestList,
-
-				storageClassList,
-				volumeSnapshotClassList,
-				csiDriverList,
-			)
-			for _, resource := range resourcesToGather {
-				// TODO  do this part in parallel?
-				err = gather.AllResources(clusterClient, resource)
-				if err != nil {
-					fmt.Println(err)
-				}
+			resourcesTasks := []gather.Task{}
+			for _, resource := range resources.lists() {
+				resourcesTasks = append(resourcesTasks, gather.AllResourcesTask(clusterClient, resource))
 			}
-
-			// get namespaces with OADP installs
-			if len(clusterServiceVersionList.Items) == 0 {
-				fmt.Println(fmt.Errorf("no ClusterServiceVersion found in cluster"))
+			resourcesResult := gather.RunSection("resources", Timeout, Workers, resourcesTasks)
+			for _, err := range resourcesResult.Errors {
+				fmt.Println(err)
 			}
-			oadpOperatorsText := ""
-			foundOADP := false
-			foundRelatedProducts := false
-			importantCSVsByNamespace := map[string][]operatorsv1alpha1.ClusterServiceVersion{}
-			importantSubscriptionsByNamespace := map[string][]operatorsv1alpha1.Subscription{}
-			oldOADPError := ""
-
-			// ?Managed Velero operator? only available in ROSA? https://github.com/openshift/managed-velero-operator
-			//
-			// ?Dell Power Protect?
-			// labels:
-			//       app: ppdm-controller
-			//       app.kubernetes.io/name: powerprotect
-			// name: powerprotect-controller-c8dcf8648-nlg85
-			//
-			// upstream velero?
-			relatedProducts := []string{
-				"OpenShift Virtualization",
-				"Advanced Cluster Management for Kubernetes",
-				"Submariner",
-				"IBM Storage Fusion",
+			templates.AddSectionTimeoutError(resourcesResult)
+
+			// filter before the summary is created and the DownloadRequests are requested
+			filteredResources := []client.ObjectList{
+				resources.backupList,
+				resources.restoreList,
+				resources.dataUploadList,
+				resources.dataDownloadList,
+				resources.podVolumeBackupList,
+				resources.podVolumeRestoreList,
+				resources.deleteBackupRequestList,
+				resources.serverStatusRequestList,
+				resources.nonAdminBackupList,
+				resources.nonAdminRestoreList,
+				resources.nonAdminDownloadRequestList,
 			}
-			communityProducts := []string{"KubeVirt HyperConverged Cluster Operator"}
-
-			for _, csv := range clusterServiceVersionList.Items {
-				// OADP dev, community and prod operators have same spec.displayName
-				if csv.Spec.DisplayName == "OADP Operator" {
-					oadpOperatorsText += fmt.Sprintf("Found **%v** version **%v** installed in **%v** namespace\n\n", csv.Spec.DisplayName, csv.Spec.Version, csv.Namespace)
-					if (csv.Spec.Version.Major < 1 || (csv.Spec.Version.Major == 1 && csv.Spec.Version.Minor < 5)) && major >= 4 && minor >= 19 {
-						oldOADPError += "❌ OADP 1.4 and lower is not supported in OpenShift 4.19 and higher\n\n"
-					}
-					foundOADP = true
-					importantCSVsByNamespace[csv.Namespace] = append(importantCSVsByNamespace[csv.Namespace], csv)
-					for _, subscription := range subscriptionList.Items {
-						if subscription.Status.InstalledCSV == csv.Name {
-							importantSubscriptionsByNamespace[subscription.Namespace] = append(importantSubscriptionsByNamespace[subscription.Namespace], subscription)
-						}
+			for _, resource := range filteredResources {
+				if !since.IsZero() {
+					err = gather.CreatedSince(resource, since)
+					if err != nil {
+						fmt.Println(err)
 					}
 				}
-				if slices.Contains(relatedProducts, csv.Spec.DisplayName) {
-					oadpOperatorsText += fmt.Sprintf("Found related product **%v** version **%v** installed in **%v** namespace\n\n", csv.Spec.DisplayName, csv.Spec.Version, csv.Namespace)
-					foundRelatedProducts = true
-					importantCSVsByNamespace[csv.Namespace] = append(importantCSVsByNamespace[csv.Namespace], csv)
-					for _, subscription := range subscriptionList.Items {
-						if subscription.Status.InstalledCSV == csv.Name {
-							importantSubscriptionsByNamespace[subscription.Namespace] = append(importantSubscriptionsByNamespace[subscription.Namespace], subscription)
-						}
+				if Essential {
+					err = gather.NotCompleted(resource)
+					if err != nil {
+						fmt.Println(err)
 					}
 				}
-				if slices.Contains(communityProducts, csv.Spec.DisplayName) {
-					oadpOperatorsText += fmt.Sprintf("⚠️ Found related product **%v (Community)** version **%v** installed in **%v** namespace\n\n", csv.Spec.DisplayName, csv.Spec.Version, csv.Namespace)
-					foundRelatedProducts = true
-					importantCSVsByNamespace[csv.Namespace] = append(importantCSVsByNamespace[csv.Namespace], csv)
-					for _, subscription := range subscriptionList.Items {
-						if subscription.Status.InstalledCSV == csv.Name {
-							importantSubscriptionsByNamespace[subscription.Namespace] = append(importantSubscriptionsByNamespace[subscription.Namespace], subscription)
-						}
-					}
+			}
+			related := gather.Related{}
+			if Target.IsSet() {
+				related, err = gather.FilterTarget(clusterClient, Target, gather.TargetLists{
+					Backups:              resources.backupList,
+					Restores:             resources.restoreList,
+					Schedules:            resources.scheduleList,
+					BackupRepositories:   resources.backupRepositoryList,
+					DataUploads:          resources.dataUploadList,
+					DataDownloads:        resources.dataDownloadList,
+					PodVolumeBackups:     resources.podVolumeBackupList,
+					PodVolumeRestores:    resources.podVolumeRestoreList,
+					DeleteBackupRequests: resources.deleteBackupRequestList,
+				})
+				if err != nil {
+					fmt.Println(err)
+					templates.AddTargetError(err)
 				}
 			}
 
-			// oc adm inspect --dest-dir must-gather/clusters/${clusterID} ns/${ns}
-			if len(importantCSVsByNamespace) != 0 {
-				ocAdmInspect := ocadminspect.NewInspectOptions(genericiooptions.NewTestIOStreamsDiscard())
-				ocAdmInspect.DestDir = outputPath
-				ocAdmInspectNamespaces := []string{}
-				for namespace := range importantCSVsByNamespace {
-					ocAdmInspectNamespaces = append(ocAdmInspectNamespaces, "ns/"+namespace)
-				}
+			addRedactedNames(redactor, resources)
+			installation := getOperatorInstallation(resources, major, minor)
 
-				// https://github.com/openshift/oc/blob/ae1bd9e4a75b8ab617a569e5c8e1a0d7285a16f6/pkg/cli/admin/inspect/inspect.go#L108
-				err = ocAdmInspect.Complete(ocAdmInspectNamespaces)
+			veleroNamespaces := []string{}
+			for _, dataProtectionApplication := range resources.dataProtectionApplicationList.Items {
+				if !slices.Contains(veleroNamespaces, dataProtectionApplication.Namespace) {
+					veleroNamespaces = append(veleroNamespaces, dataProtectionApplication.Namespace)
+				}
+			}
+			var clientset *kubernetes.Clientset
+			if Target.IsSet() || Metrics || Kopia {
+				clientset, err = kubernetes.NewForConfig(clusterConfig)
 				if err != nil {
 					fmt.Println(err)
 				}
-				err = ocAdmInspect.Validate()
-				if err != nil {
+			}
+
+			// targeted mode only collects velero pods and the node-agent pods of the nodes involved
+			if Target.IsSet() && clientset != nil {
+				gather.VeleroPods(clusterClient, clientset, redactor, outputPath, veleroNamespaces, related.Nodes, RequestTimeout, Timeout)
+			}
+
+			metrics := []gather.Metrics{}
+			if Metrics && clientset != nil {
+				metricsResult := gather.RunSection("metrics", Timeout, Workers, gather.MetricsTasks(clientset, veleroNamespaces, resources.podList.Items, RequestTimeout, &metrics))
+				for _, err := range metricsResult.Errors {
 					fmt.Println(err)
 				}
-				err = ocAdmInspect.Run()
-				if err != nil {
+				templates.AddSectionTimeoutError(metricsResult)
+			}
+
+			kopiaInspections := []gather.KopiaInspection{}
+			if Kopia && clientset != nil {
+				kopiaResult := gather.RunSection("kopia inspection", Timeout, Workers, gather.KopiaTasks(
+					clusterClient, clientset, resources.backupRepositoryList.Items, resources.backupStorageLocationList.Items,
+					KopiaImage, KopiaTimeout, &kopiaInspections,
+				))
+				for _, err := range kopiaResult.Errors {
 					fmt.Println(err)
 				}
+				templates.AddSectionTimeoutError(kopiaResult)
 			}
 
-			// TODO do processes in parallel!?
-			// https://gobyexample.com/waitgroups
-			// https://github.com/konveyor/analyzer-lsp/blob/main/engine/engine.go
-			templates.ReplaceMustGatherVersion(mustGatherVersion)
-			templates.ReplaceClusterInformationSection(outputPath, clusterID, clusterVersion, infrastructureList, nodeList)
-			templates.ReplaceOADPOperatorInstallationSection(outputPath, importantCSVsByNamespace, importantSubscriptionsByNamespace, foundOADP, foundRelatedProducts, oldOADPError, oadpOperatorsText)
-			templates.ReplaceDataProtectionApplicationsSection(outputPath, dataProtectionApplicationList)
-			templates.ReplaceDataProtectionTestsSection(outputPath, dataProtectionTestList)
-			templates.ReplaceCloudStoragesSection(outputPath, cloudStorageList)
-			templates.ReplaceBackupStorageLocationsSection(outputPath, backupStorageLocationList)
-			templates.ReplaceVolumeSnapshotLocationsSection(outputPath, volumeSnapshotLocationList)
-			// this creates DownloadRequests CRs
-			templates.ReplaceBackupsSection(outputPath, backupList, clusterClient, deleteBackupRequestList, podVolumeBackupList, RequestTimeout, SkipTLS)
-			templates.ReplaceRestoresSection(outputPath, restoreList, clusterClient, podVolumeRestoreList, RequestTimeout, SkipTLS)
-
-			downloadRequestList := &velerov1.DownloadRequestList{}
-			err = gather.AllResources(clusterClient, downloadRequestList)
-			if err != nil {
-				fmt.Println(err)
+			// oc adm inspect --dest-dir must-gather/clusters/${clusterID} ns/${ns}
+			if len(installation.csvsByNamespace) != 0 && !Target.IsSet() {
+				ocAdmInspectNamespaces := []string{}
+				for namespace := range installation.csvsByNamespace {
+					ocAdmInspectNamespaces = append(ocAdmInspectNamespaces, "ns/"+namespace)
+				}
+				inspect(redactor, outputPath, ocAdmInspectNamespaces)
 			}
 
-			templates.ReplaceSchedulesSection(outputPath, scheduleList)
-			templates.ReplaceBackupRepositoriesSection(outputPath, backupRepositoryList)
-			templates.ReplaceDataUploadsSection(outputPath, dataUploadList)
-			templates.ReplaceDataDownloadsSection(outputPath, dataDownloadList)
-			templates.ReplacePodVolumeBackupsSection(outputPath, podVolumeBackupList)
-			templates.ReplacePodVolumeRestoresSection(outputPath, podVolumeRestoreList)
-			templates.ReplaceDownloadRequestsSection(outputPath, downloadRequestList)
-			templates.ReplaceDeleteBackupRequestsSection(outputPath, deleteBackupRequestList)
-			templates.ReplaceServerStatusRequestsSection(outputPath, serverStatusRequestList)
-			templates.ReplaceNonAdminBackupStorageLocationRequestsSection(outputPath, nonAdminBackupStorageLocationRequestList)
-			templates.ReplaceNonAdminBackupStorageLocationsSection(outputPath, nonAdminBackupStorageLocationList)
-			templates.ReplaceNonAdminBackupsSection(outputPath, nonAdminBackupList)
-			templates.ReplaceNonAdminRestoresSection(outputPath, nonAdminRestoreList)
-			templates.ReplaceNonAdminDownloadRequestsSection(outputPath, nonAdminDownloadRequestList)
-			templates.ReplaceAvailableStorageClassesSection(outputPath, storageClassList)
-			templates.ReplaceAvailableVolumeSnapshotClassesSection(outputPath, volumeSnapshotClassList)
-			templates.ReplaceAvailableCSIDriversSection(outputPath, csiDriverList)
-			templates.ReplaceCustomResourceDefinitionsSection(outputPath, clusterConfig)
+			// this creates DownloadRequests CRs, which are gathered after the Backups and Restores sections
+			gatherDownloadRequests := func(downloadRequestList *velerov1.DownloadRequestList) {
+				downloadRequestsResult := gather.RunSection("DownloadRequests", Timeout, Workers, []gather.Task{
+					gather.AllResourcesTask(clusterClient, downloadRequestList),
+				})
+				for _, err := range downloadRequestsResult.Errors {
+					fmt.Println(err)
+				}
+				templates.AddSectionTimeoutError(downloadRequestsResult)
+				if !since.IsZero() {
+					err := gather.CreatedSince(downloadRequestList, since)
+					if err != nil {
+						fmt.Println(err)
+					}
+				}
+				if Target.IsSet() {
+					err := gather.Filter(downloadRequestList, func(object client.Object) bool {
+						name := object.(*velerov1.DownloadRequest).Spec.Target.Name
+						return slices.Contains(related.Backups, name) || slices.Contains(related.Restores, name)
+					})
+					if err != nil {
+						fmt.Println(err)
+					}
+				}
+			}
+			writeSummary(outputPath, clusterID, clusterVersion, resources, installation, metrics, kopiaInspections, clusterClient, clusterConfig, gatherDownloadRequests, time.Now())
 			err = templates.WriteVersion(mustGatherVersion)
 			if err != nil {
 				fmt.Printf("Error occurred: %v\n", err)
 				return err
 			}
 			// do not tar!
-			err = templates.Write(outputPath)
+			err = templates.Write(outputPath, templates.SummaryName)
 			if err != nil {
 				fmt.Printf("Error occurred: %v\n", err)
 				return err
@@ -363,3 +333,424 @@ For more information, check OADP must-gather documentation: https://docs.redhat.
 		},
 	}
 )
+
+// clusterResources are the objects gathered from the cluster, or loaded from a must-gather directory
+type clusterResources struct {
+	clusterVersionList        *openshiftconfigv1.ClusterVersionList
+	infrastructureList        *openshiftconfigv1.InfrastructureList
+	nodeList                  *corev1.NodeList
+	clusterServiceVersionList *operatorsv1alpha1.ClusterServiceVersionList
+	subscriptionList          *operatorsv1alpha1.SubscriptionList
+
+	dataProtectionApplicationList *oadpv1alpha1.DataProtectionApplicationList
+	dataProtectionTestList        *oadpv1alpha1.DataProtectionTestList
+	cloudStorageList              *oadpv1alpha1.CloudStorageList
+	backupStorageLocationList     *velerov1.BackupStorageLocationList
+	volumeSnapshotLocationList    *velerov1.VolumeSnapshotLocationList
+	backupList                    *velerov1.BackupList
+	restoreList                   *velerov1.RestoreList
+	scheduleList                  *velerov1.ScheduleList
+	backupRepositoryList          *velerov1.BackupRepositoryList
+	dataUploadList                *velerov2alpha1.DataUploadList
+	dataDownloadList              *velerov2alpha1.DataDownloadList
+	podVolumeBackupList           *velerov1.PodVolumeBackupList
+	podVolumeRestoreList          *velerov1.PodVolumeRestoreList
+	downloadRequestList           *velerov1.DownloadRequestList
+
+	deleteBackupRequestList                  *velerov1.DeleteBackupRequestList
+	serverStatusRequestList                  *velerov1.ServerStatusRequestList
+	nonAdminBackupStorageLocationRequestList *nac1alpha1.NonAdminBackupStorageLocationRequestList
+	nonAdminBackupStorageLocationList        *nac1alpha1.NonAdminBackupStorageLocationList
+	nonAdminBackupList                       *nac1alpha1.NonAdminBackupList
+	nonAdminRestoreList                      *nac1alpha1.NonAdminRestoreList
+	nonAdminDownloadRequestList              *nac1alpha1.NonAdminDownloadRequestList
+
+	podList *corev1.PodList
+
+	storageClassList        *storagev1.StorageClassList
+	volumeSnapshotClassList *volumesnapshotv1.VolumeSnapshotClassList
+	csiDriverList           *storagev1.CSIDriverList
+}
+
+func newClusterResources() *clusterResources {
+	return &clusterResources{
+		clusterVersionList:        &openshiftconfigv1.ClusterVersionList{},
+		infrastructureList:        &openshiftconfigv1.InfrastructureList{},
+		nodeList:                  &corev1.NodeList{},
+		clusterServiceVersionList: &operatorsv1alpha1.ClusterServiceVersionList{},
+		subscriptionList:          &operatorsv1alpha1.SubscriptionList{},
+
+		dataProtectionApplicationList: &oadpv1alpha1.DataProtectionApplicationList{},
+		dataProtectionTestList:        &oadpv1alpha1.DataProtectionTestList{},
+		cloudStorageList:              &oadpv1alpha1.CloudStorageList{},
+		backupStorageLocationList:     &velerov1.BackupStorageLocationList{},
+		volumeSnapshotLocationList:    &velerov1.VolumeSnapshotLocationList{},
+		backupList:                    &velerov1.BackupList{},
+		restoreList:                   &velerov1.RestoreList{},
+		scheduleList:                  &velerov1.ScheduleList{},
+		backupRepositoryList:          &velerov1.BackupRepositoryList{},
+		dataUploadList:                &velerov2alpha1.DataUploadList{},
+		dataDownloadList:              &velerov2alpha1.DataDownloadList{},
+		podVolumeBackupList:           &velerov1.PodVolumeBackupList{},
+		podVolumeRestoreList:          &velerov1.PodVolumeRestoreList{},
+		downloadRequestList:           &velerov1.DownloadRequestList{},
+
+		deleteBackupRequestList:                  &velerov1.DeleteBackupRequestList{},
+		serverStatusRequestList:                  &velerov1.ServerStatusRequestList{},
+		nonAdminBackupStorageLocationRequestList: &nac1alpha1.NonAdminBackupStorageLocationRequestList{},
+		nonAdminBackupStorageLocationList:        &nac1alpha1.NonAdminBackupStorageLocationList{},
+		nonAdminBackupList:                       &nac1alpha1.NonAdminBackupList{},
+		nonAdminRestoreList:                      &nac1alpha1.NonAdminRestoreList{},
+		nonAdminDownloadRequestList:              &nac1alpha1.NonAdminDownloadRequestList{},
+
+		podList: &corev1.PodList{},
+
+		storageClassList:        &storagev1.StorageClassList{},
+		volumeSnapshotClassList: &volumesnapshotv1.VolumeSnapshotClassList{},
+		csiDriverList:           &storagev1.CSIDriverList{},
+	}
+}
+
+// lists returns the lists gathered together. ClusterVersions are gathered first and DownloadRequests after the
+// Backups and Restores sections are created.
+func (r *clusterResources) lists() []client.ObjectList {
+	return []client.ObjectList{
+		r.infrastructureList,
+		r.nodeList,
+		r.clusterServiceVersionList,
+		r.subscriptionList,
+
+		r.dataProtectionApplicationList,
+		r.dataProtectionTestList,
+		r.cloudStorageList,
+		r.backupStorageLocationList,
+		r.volumeSnapshotLocationList,
+		r.backupList,
+		r.restoreList,
+		r.scheduleList,
+		r.backupRepositoryList,
+		r.dataUploadList,
+		r.dataDownloadList,
+		r.podVolumeBackupList,
+		r.podVolumeRestoreList,
+
+		r.deleteBackupRequestList,
+		r.serverStatusRequestList,
+		r.nonAdminBackupStorageLocationRequestList,
+		r.nonAdminBackupStorageLocationList,
+		r.nonAdminBackupList,
+		r.nonAdminRestoreList,
+		r.nonAdminDownloadRequestList,
+
+		r.podList,
+
+		r.storageClassList,
+		r.volumeSnapshotClassList,
+		r.csiDriverList,
+	}
+}
+
+// operatorInstallation are the OADP and related products operators found in the cluster
+type operatorInstallation struct {
+	csvsByNamespace          map[string][]operatorsv1alpha1.ClusterServiceVersion
+	subscriptionsByNamespace map[string][]operatorsv1alpha1.Subscription
+	foundOADP                bool
+	foundRelatedProducts     bool
+	oldOADPError             string
+	text                     string
+}
+
+func addToScheme(scheme *runtime.Scheme) error {
+	schemes := []struct {
+		name        string
+		addToScheme func(*runtime.Scheme) error
+	}{
+		{name: "github.com/openshift/api/config/v1", addToScheme: openshiftconfigv1.AddToScheme},
+		{name: "github.com/operator-framework/api/pkg/operators/v1alpha1", addToScheme: operatorsv1alpha1.AddToScheme},
+		{name: "k8s.io/api/storage/v1", addToScheme: storagev1.AddToScheme},
+		{name: "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1", addToScheme: volumesnapshotv1.AddToScheme},
+		{name: "k8s.io/api/core/v1", addToScheme: corev1.AddToScheme},
+		// OADP CRDs
+		{name: "github.com/openshift/oadp-operator/api/v1alpha1", addToScheme: oadpv1alpha1.AddToScheme},
+		{name: "github.com/migtools/oadp-non-admin/api/v1alpha1", addToScheme: nac1alpha1.AddToScheme},
+		{name: "github.com/vmware-tanzu/velero/pkg/apis/velero/v1", addToScheme: velerov1.AddToScheme},
+		{name: "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1", addToScheme: velerov2alpha1.AddToScheme},
+	}
+	for _, s := range schemes {
+		err := s.addToScheme(scheme)
+		if err != nil {
+			fmt.Printf(addToSchemeError, s.name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// getClusterVersion returns the ClusterVersion of the cluster, with its OpenShift major and minor versions
+func getClusterVersion(clusterVersionList *openshiftconfigv1.ClusterVersionList) (*openshiftconfigv1.ClusterVersion, int, int, error) {
+	if len(clusterVersionList.Items) == 0 {
+		err := fmt.Errorf("no ClusterVersion found in cluster")
+		fmt.Printf("Exiting OADP must-gather, an error happened while gathering ClusterVersion: %v\n", err)
+		return nil, 0, 0, err
+	}
+	clusterVersion := &clusterVersionList.Items[0]
+	versionParts := strings.Split(clusterVersion.Status.Desired.Version, ".")
+	major, err := strconv.Atoi(versionParts[0])
+	if err != nil {
+		fmt.Printf("Exiting OADP must-gather, an error happened while parsing OpenShift major version: %v\n", err)
+		return nil, 0, 0, err
+	}
+	if len(versionParts) < 2 {
+		err = fmt.Errorf("OpenShift version %q has no minor version", clusterVersion.Status.Desired.Version)
+		fmt.Printf("Exiting OADP must-gather, an error happened while parsing OpenShift minor version: %v\n", err)
+		return nil, 0, 0, err
+	}
+	minor, err := strconv.Atoi(versionParts[1])
+	if err != nil {
+		fmt.Printf("Exiting OADP must-gather, an error happened while parsing OpenShift minor version: %v\n", err)
+		return nil, 0, 0, err
+	}
+	return clusterVersion, major, minor, nil
+}
+
+// getOperatorInstallation returns the namespaces with OADP installs and related products
+func getOperatorInstallation(resources *clusterResources, major int, minor int) operatorInstallation {
+	if len(resources.clusterServiceVersionList.Items) == 0 {
+		fmt.Println(fmt.Errorf("no ClusterServiceVersion found in cluster"))
+	}
+	installation := operatorInstallation{
+		csvsByNamespace:          map[string][]operatorsv1alpha1.ClusterServiceVersion{},
+		subscriptionsByNamespace: map[string][]operatorsv1alpha1.Subscription{},
+	}
+
+	// ?Managed Velero operator? only available in ROSA? https://github.com/openshift/managed-velero-operator
+	//
+	// ?Dell Power Protect?
+	// labels:
+	//       app: ppdm-controller
+	//       app.kubernetes.io/name: powerprotect
+	// name: powerprotect-controller-c8dcf8648-nlg85
+	//
+	// upstream velero?
+	relatedProducts := []string{
+		"OpenShift Virtualization",
+		"Advanced Cluster Management for Kubernetes",
+		"Submariner",
+		"IBM Storage Fusion",
+	}
+	communityProducts := []string{"KubeVirt HyperConverged Cluster Operator"}
+
+	addSubscriptions := func(csv operatorsv1alpha1.ClusterServiceVersion) {
+		installation.csvsByNamespace[csv.Namespace] = append(installation.csvsByNamespace[csv.Namespace], csv)
+		for _, subscription := range resources.subscriptionList.Items {
+			if subscription.Status.InstalledCSV == csv.Name {
+				installation.subscriptionsByNamespace[subscription.Namespace] = append(installation.subscriptionsByNamespace[subscription.Namespace], subscription)
+			}
+		}
+	}
+	for _, csv := range resources.clusterServiceVersionList.Items {
+		// OADP dev, community and prod operators have same spec.displayName
+		if csv.Spec.DisplayName == "OADP Operator" {
+			installation.text += fmt.Sprintf("Found **%v** version **%v** installed in **%v** namespace\n\n", csv.Spec.DisplayName, csv.Spec.Version, csv.Namespace)
+			if (csv.Spec.Version.Major < 1 || (csv.Spec.Version.Major == 1 && csv.Spec.Version.Minor < 5)) && major >= 4 && minor >= 19 {
+				installation.oldOADPError += "❌ OADP 1.4 and lower is not supported in OpenShift 4.19 and higher\n\n"
+			}
+			installation.foundOADP = true
+			addSubscriptions(csv)
+		}
+		if slices.Contains(relatedProducts, csv.Spec.DisplayName) {
+			installation.text += fmt.Sprintf("Found related product **%v** version **%v** installed in **%v** namespace\n\n", csv.Spec.DisplayName, csv.Spec.Version, csv.Namespace)
+			installation.foundRelatedProducts = true
+			addSubscriptions(csv)
+		}
+		if slices.Contains(communityProducts, csv.Spec.DisplayName) {
+			installation.text += fmt.Sprintf("⚠️ Found related product **%v (Community)** version **%v** installed in **%v** namespace\n\n", csv.Spec.DisplayName, csv.Spec.Version, csv.Namespace)
+			installation.foundRelatedProducts = true
+			addSubscriptions(csv)
+		}
+	}
+	return installation
+}
+
+// writeSummary fills the summary sections and the JSON report with the resources. Without cluster connection,
+// clusterClient and clusterConfig are nil, and the files gathered before are linked instead of requested again.
+// gatherTime is the time the resources were gathered, the diagnosis compares timestamps with it.
+func writeSummary(
+	outputPath string,
+	clusterID string,
+	clusterVersion *openshiftconfigv1.ClusterVersion,
+	resources *clusterResources,
+	installation operatorInstallation,
+	metrics []gather.Metrics,
+	kopiaInspections []gather.KopiaInspection,
+	clusterClient client.Client,
+	clusterConfig *rest.Config,
+	gatherDownloadRequests func(*velerov1.DownloadRequestList),
+	gatherTime time.Time,
+) {
+	templates.ReplaceMustGatherVersion(mustGatherVersion)
+	findings := diagnosis.Run(diagnosis.Resources{
+		Now:                        gatherTime,
+		OpenShiftVersion:           clusterVersion.Status.Desired.Version,
+		ClusterServiceVersions:     resources.clusterServiceVersionList.Items,
+		Nodes:                      resources.nodeList.Items,
+		Pods:                       resources.podList.Items,
+		DataProtectionApplications: resources.dataProtectionApplicationList.Items,
+		BackupStorageLocations:     resources.backupStorageLocationList.Items,
+		DataUploads:                resources.dataUploadList.Items,
+		StorageClasses:             resources.storageClassList.Items,
+		VolumeSnapshotClasses:      resources.volumeSnapshotClassList.Items,
+		CSIDrivers:                 resources.csiDriverList.Items,
+	})
+	templates.ReplaceDiagnosisSection(findings)
+	templates.ReplaceClusterInformationSection(outputPath, clusterID, clusterVersion, resources.infrastructureList, resources.nodeList)
+	templates.ReplaceOADPOperatorInstallationSection(outputPath, installation.csvsByNamespace, installation.subscriptionsByNamespace, installation.foundOADP, installation.foundRelatedProducts, installation.oldOADPError, installation.text)
+	templates.ReplaceDataProtectionApplicationsSection(outputPath, resources.dataProtectionApplicationList)
+	templates.ReplaceDataProtectionTestsSection(outputPath, resources.dataProtectionTestList)
+	templates.ReplaceCloudStoragesSection(outputPath, resources.cloudStorageList)
+	templates.ReplaceBackupStorageLocationsSection(outputPath, resources.backupStorageLocationList)
+	templates.ReplaceVolumeSnapshotLocationsSection(outputPath, resources.volumeSnapshotLocationList)
+	templates.ReplaceBackupsSection(outputPath, resources.backupList, clusterClient, resources.deleteBackupRequestList, resources.podVolumeBackupList, RequestTimeout, SkipTLS, Timeout, Workers)
+	templates.ReplaceRestoresSection(outputPath, resources.restoreList, clusterClient, resources.podVolumeRestoreList, RequestTimeout, SkipTLS, Timeout, Workers)
+	if gatherDownloadRequests != nil {
+		gatherDownloadRequests(resources.downloadRequestList)
+	}
+	templates.ReplaceSchedulesSection(outputPath, resources.scheduleList)
+	templates.ReplaceBackupRepositoriesSection(outputPath, resources.backupRepositoryList, kopiaInspections)
+	templates.ReplaceDataUploadsSection(outputPath, resources.dataUploadList)
+	templates.ReplaceDataDownloadsSection(outputPath, resources.dataDownloadList)
+	templates.ReplacePodVolumeBackupsSection(outputPath, resources.podVolumeBackupList)
+	templates.ReplacePodVolumeRestoresSection(outputPath, resources.podVolumeRestoreList)
+	templates.ReplaceDownloadRequestsSection(outputPath, resources.downloadRequestList)
+	templates.ReplaceDeleteBackupRequestsSection(outputPath, resources.deleteBackupRequestList)
+	templates.ReplaceServerStatusRequestsSection(outputPath, resources.serverStatusRequestList)
+	templates.ReplaceNonAdminBackupStorageLocationRequestsSection(outputPath, resources.nonAdminBackupStorageLocationRequestList)
+	templates.ReplaceNonAdminBackupStorageLocationsSection(outputPath, resources.nonAdminBackupStorageLocationList)
+	templates.ReplaceNonAdminBackupsSection(outputPath, resources.nonAdminBackupList)
+	templates.ReplaceNonAdminRestoresSection(outputPath, resources.nonAdminRestoreList)
+	templates.ReplaceNonAdminDownloadRequestsSection(outputPath, resources.nonAdminDownloadRequestList)
+	gather.AddStatusCounters(metrics, resources.dataUploadList.Items, resources.dataDownloadList.Items, resources.backupRepositoryList.Items)
+	templates.ReplaceMetricsSection(outputPath, metrics)
+	templates.ReplaceAvailableStorageClassesSection(outputPath, resources.storageClassList)
+	templates.ReplaceAvailableVolumeSnapshotClassesSection(outputPath, resources.volumeSnapshotClassList)
+	templates.ReplaceAvailableCSIDriversSection(outputPath, resources.csiDriverList)
+	templates.ReplaceCustomResourceDefinitionsSection(outputPath, clusterConfig)
+
+	summaryReport := report.New(mustGatherVersion)
+	summaryReport.Cluster.ID = clusterID
+	summaryReport.Cluster.OpenShiftVersion = clusterVersion.Status.Desired.Version
+	if len(resources.infrastructureList.Items) != 0 {
+		summaryReport.Cluster.Platform = string(resources.infrastructureList.Items[0].Spec.PlatformSpec.Type)
+	}
+	summaryReport.SetArchitectures(resources.nodeList.Items)
+	summaryReport.SetOperators(installation.csvsByNamespace)
+	summaryReport.SetDataProtectionApplications(resources.dataProtectionApplicationList.Items)
+	summaryReport.SetBackupStorageLocations(resources.backupStorageLocationList.Items)
+	summaryReport.SetVolumeSnapshotLocations(resources.volumeSnapshotLocationList.Items)
+	summaryReport.SetBackups(resources.backupList.Items)
+	summaryReport.SetRestores(resources.restoreList.Items)
+	summaryReport.SetMetrics(metrics)
+	summaryReport.Findings = findings
+	templates.SetReport(summaryReport)
+}
+
+// inspect runs `oc adm inspect` on the namespaces. Its output is written to a temporary folder and copied to outputPath
+// after redaction.
+func inspect(redactor *redact.Redactor, outputPath string, ocAdmInspectNamespaces []string) {
+	inspectPath, err := os.MkdirTemp("", "oadp-must-gather-inspect-")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(inspectPath)
+
+	ocAdmInspect := ocadminspect.NewInspectOptions(genericiooptions.NewTestIOStreamsDiscard())
+	ocAdmInspect.DestDir = inspectPath
+	// https://github.com/openshift/oc/blob/ae1bd9e4a75b8ab617a569e5c8e1a0d7285a16f6/pkg/cli/admin/inspect/inspect.go#L108
+	err = ocAdmInspect.Complete(ocAdmInspectNamespaces)
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = ocAdmInspect.Validate()
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = ocAdmInspect.Run()
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = redactor.CopyDirectory(inspectPath, outputPath)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// getRedactor returns the Redactor of the default rules, or of the --redact-rules file, with --redact-names and
+// --redact-key
+func getRedactor() (*redact.Redactor, error) {
+	rules := redact.DefaultRules()
+	if len(RedactRules) != 0 {
+		var err error
+		rules, err = redact.LoadRules(RedactRules)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if RedactNames {
+		rules.Names = true
+	}
+	if len(RedactKey) != 0 {
+		rules.NameKey = RedactKey
+	}
+	return redact.New(rules)
+}
+
+// addRedactedNames registers the names of the namespaced objects and of the namespaces they refer to, to be replaced by
+// pseudonyms. Cluster scoped objects, like StorageClasses, keep their names.
+func addRedactedNames(redactor *redact.Redactor, resources *clusterResources) {
+	for _, resource := range resources.lists() {
+		items, err := meta.ExtractList(resource)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		for _, item := range items {
+			object, err := meta.Accessor(item)
+			if err != nil || len(object.GetNamespace()) == 0 {
+				continue
+			}
+			redactor.AddNames(object.GetNamespace(), object.GetName())
+		}
+	}
+	for _, backup := range resources.backupList.Items {
+		redactor.AddNames(backup.Spec.IncludedNamespaces...)
+		redactor.AddNames(backup.Spec.ExcludedNamespaces...)
+	}
+	for _, restore := range resources.restoreList.Items {
+		redactor.AddNames(restore.Spec.IncludedNamespaces...)
+		for source, target := range restore.Spec.NamespaceMapping {
+			redactor.AddNames(source, target)
+		}
+	}
+}
+
+// getSince returns the time from which Backups, Restores and related resources are gathered, zero to gather all
+func getSince() (time.Time, error) {
+	if Since != 0 && len(SinceTime) != 0 {
+		return time.Time{}, fmt.Errorf("only one of --since and --since-time can be set")
+	}
+	if Since < 0 {
+		return time.Time{}, fmt.Errorf("--since value must be greater than zero")
+	}
+	if Since > 0 {
+		return time.Now().Add(-Since), nil
+	}
+	if len(SinceTime) != 0 {
+		sinceTime, err := time.Parse(time.RFC3339, SinceTime)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("--since-time value must be a RFC3339 date, like 2025-05-05T10:00:00Z: %w", err)
+		}
+		return sinceTime, nil
+	}
+	return time.Time{}, nil
+}
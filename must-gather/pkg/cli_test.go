@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetSince(t *testing.T) {
+	tests := []struct {
+		name      string
+		since     time.Duration
+		sinceTime string
+		want      time.Time
+		wantErr   string
+	}{
+		{
+			name: "gather all",
+		},
+		{
+			name:      "since time",
+			sinceTime: "2025-05-05T10:00:00Z",
+			want:      time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "since and since time",
+			since:     time.Hour,
+			sinceTime: "2025-05-05T10:00:00Z",
+			wantErr:   "only one of --since and --since-time can be set",
+		},
+		{
+			name:    "negative since",
+			since:   -time.Hour,
+			wantErr: "--since value must be greater than zero",
+		},
+		{
+			name:      "since time is not RFC3339",
+			sinceTime: "2025-05-05",
+			wantErr:   "--since-time value must be a RFC3339 date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(since time.Duration, sinceTime string) { Since, SinceTime = since, sinceTime }(Since, SinceTime)
+			Since, SinceTime = tt.since, tt.sinceTime
+
+			got, err := getSince()
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	defer func(since time.Duration) { Since = since }(Since)
+	Since = time.Hour
+	before := time.Now()
+	got, err := getSince()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Before(before.Add(-time.Hour)) || got.After(time.Now().Add(-time.Hour)) {
+		t.Errorf("got %v, want one hour ago", got)
+	}
+}
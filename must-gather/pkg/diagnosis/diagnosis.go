@@ -0,0 +1,74 @@
+package diagnosis
+
+import (
+	"time"
+
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+type Severity string
+
+const (
+	SeverityCritical Severity = "Critical"
+	SeverityWarning  Severity = "Warning"
+)
+
+// Finding is a problem found by a Rule in the gathered objects, with a suggested fix
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Object   string   `json:"object"`
+	Message  string   `json:"message"`
+	Fix      string   `json:"fix"`
+}
+
+// Resources are the gathered objects the Rules run over
+type Resources struct {
+	// Now is the time the Rules compare timestamps and certificate expiration dates with
+	Now                        time.Time
+	OpenShiftVersion           string
+	ClusterServiceVersions     []operatorsv1alpha1.ClusterServiceVersion
+	Nodes                      []corev1.Node
+	Pods                       []corev1.Pod
+	DataProtectionApplications []oadpv1alpha1.DataProtectionApplication
+	BackupStorageLocations     []velerov1.BackupStorageLocation
+	DataUploads                []velerov2alpha1.DataUpload
+	StorageClasses             []storagev1.StorageClass
+	VolumeSnapshotClasses      []volumesnapshotv1.VolumeSnapshotClass
+	CSIDrivers                 []storagev1.CSIDriver
+}
+
+// Rule checks the gathered objects for a known problem
+type Rule struct {
+	Name  string
+	Check func(resources Resources) []Finding
+}
+
+// Rules are run over the gathered objects, in order
+var Rules = []Rule{
+	{Name: "BackupStorageLocationNotAvailable", Check: backupStorageLocationNotAvailable},
+	{Name: "BackupStorageLocationCACertificateExpired", Check: backupStorageLocationCACertificateExpired},
+	{Name: "DataProtectionApplicationNotReconciled", Check: dataProtectionApplicationNotReconciled},
+	{Name: "DataUploadStuckByLoadAffinity", Check: dataUploadStuckByLoadAffinity},
+	{Name: "NoDefaultVolumeSnapshotClass", Check: noDefaultVolumeSnapshotClass},
+	{Name: "NodeAgentMissing", Check: nodeAgentMissing},
+	{Name: "OADPOpenShiftVersionMismatch", Check: oadpOpenShiftVersionMismatch},
+}
+
+// Run returns the findings of all Rules over the gathered objects
+func Run(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, rule := range Rules {
+		for _, finding := range rule.Check(resources) {
+			finding.Rule = rule.Name
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,309 @@
+package diagnosis
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// certificateExpirationWarning is how long before its expiration a CA certificate is reported
+	certificateExpirationWarning = 30 * 24 * time.Hour
+	// dataUploadAcceptedTimeout is how long a DataUpload can be Accepted before it is reported as stuck
+	dataUploadAcceptedTimeout = 10 * time.Minute
+	// volumeSnapshotClassDefaultAnnotation marks the default VolumeSnapshotClass of a CSI driver
+	volumeSnapshotClassDefaultAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+// supportedOpenShiftMinorVersions are the OpenShift 4 minor versions supported by each OADP minor version, zero
+// meaning no known upper limit. https://access.redhat.com/articles/5456281
+var supportedOpenShiftMinorVersions = map[string][2]int{
+	"1.3": {12, 15},
+	"1.4": {14, 18},
+	"1.5": {19, 0},
+}
+
+func objectName(kind string, namespace string, name string) string {
+	return fmt.Sprintf("%s %s/%s", kind, namespace, name)
+}
+
+func backupStorageLocationNotAvailable(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, backupStorageLocation := range resources.BackupStorageLocations {
+		if backupStorageLocation.Status.Phase == velerov1.BackupStorageLocationPhaseAvailable {
+			continue
+		}
+		finding := Finding{
+			Object: objectName("BackupStorageLocation", backupStorageLocation.Namespace, backupStorageLocation.Name),
+			Fix:    "check the BackupStorageLocation bucket, prefix, region and credentials, and the velero logs",
+		}
+		if backupStorageLocation.Status.Phase == velerov1.BackupStorageLocationPhaseUnavailable {
+			finding.Severity = SeverityCritical
+			finding.Message = "BackupStorageLocation is Unavailable"
+			if len(backupStorageLocation.Status.Message) != 0 {
+				finding.Message += ": " + backupStorageLocation.Status.Message
+			}
+		} else {
+			finding.Severity = SeverityWarning
+			finding.Message = "BackupStorageLocation was not validated by velero"
+			finding.Fix = "check that the velero pod is running and its logs"
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func backupStorageLocationCACertificateExpired(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, backupStorageLocation := range resources.BackupStorageLocations {
+		if backupStorageLocation.Spec.ObjectStorage == nil || len(backupStorageLocation.Spec.ObjectStorage.CACert) == 0 {
+			continue
+		}
+		object := objectName("BackupStorageLocation", backupStorageLocation.Namespace, backupStorageLocation.Name)
+		rest := backupStorageLocation.Spec.ObjectStorage.CACert
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				findings = append(findings, Finding{
+					Severity: SeverityWarning,
+					Object:   object,
+					Message:  fmt.Sprintf("BackupStorageLocation CA certificate can not be parsed: %v", err),
+					Fix:      "set spec.objectStorage.caCert to a base64 encoded PEM CA bundle",
+				})
+				continue
+			}
+			fix := "renew the CA certificate and update the caCert of the backup location in the DataProtectionApplication"
+			if certificate.NotAfter.Before(resources.Now) {
+				findings = append(findings, Finding{
+					Severity: SeverityCritical,
+					Object:   object,
+					Message:  fmt.Sprintf("BackupStorageLocation CA certificate %q expired on %s", certificate.Subject.CommonName, certificate.NotAfter.Format(time.RFC3339)),
+					Fix:      fix,
+				})
+			} else if certificate.NotAfter.Before(resources.Now.Add(certificateExpirationWarning)) {
+				findings = append(findings, Finding{
+					Severity: SeverityWarning,
+					Object:   object,
+					Message:  fmt.Sprintf("BackupStorageLocation CA certificate %q expires on %s", certificate.Subject.CommonName, certificate.NotAfter.Format(time.RFC3339)),
+					Fix:      fix,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func dataProtectionApplicationNotReconciled(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, dataProtectionApplication := range resources.DataProtectionApplications {
+		condition := meta.FindStatusCondition(dataProtectionApplication.Status.Conditions, oadpv1alpha1.ConditionReconciled)
+		if condition == nil || condition.Status != metav1.ConditionFalse {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityCritical,
+			Object:   objectName("DataProtectionApplication", dataProtectionApplication.Namespace, dataProtectionApplication.Name),
+			Message:  fmt.Sprintf("DataProtectionApplication is not reconciled: %s", condition.Message),
+			Fix:      "fix the DataProtectionApplication spec according to the condition message, and check the OADP operator logs",
+		})
+	}
+	return findings
+}
+
+func dataUploadStuckByLoadAffinity(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, dataUpload := range resources.DataUploads {
+		if dataUpload.Status.Phase != velerov2alpha1.DataUploadPhaseAccepted {
+			continue
+		}
+		index := slices.IndexFunc(resources.DataProtectionApplications, func(dpa oadpv1alpha1.DataProtectionApplication) bool {
+			return dpa.Namespace == dataUpload.Namespace && dpa.Spec.Configuration != nil &&
+				dpa.Spec.Configuration.NodeAgent != nil && len(dpa.Spec.Configuration.NodeAgent.LoadAffinityConfig) != 0
+		})
+		if index < 0 {
+			continue
+		}
+		object := objectName("DataUpload", dataUpload.Namespace, dataUpload.Name)
+		fix := "update spec.configuration.nodeAgent.loadAffinity of the DataProtectionApplication to match nodes where the data mover pods can run"
+		matchingNodes := []string{}
+		for _, loadAffinity := range resources.DataProtectionApplications[index].Spec.Configuration.NodeAgent.LoadAffinityConfig {
+			if loadAffinity == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(&loadAffinity.NodeSelector)
+			if err != nil {
+				findings = append(findings, Finding{
+					Severity: SeverityCritical,
+					Object:   object,
+					Message:  fmt.Sprintf("DataUpload is Accepted and the load affinity node selector is not valid: %v", err),
+					Fix:      fix,
+				})
+				continue
+			}
+			for _, node := range resources.Nodes {
+				if selector.Matches(labels.Set(node.Labels)) && !slices.Contains(matchingNodes, node.Name) {
+					matchingNodes = append(matchingNodes, node.Name)
+				}
+			}
+		}
+		if len(matchingNodes) == 0 {
+			findings = append(findings, Finding{
+				Severity: SeverityCritical,
+				Object:   object,
+				Message:  "DataUpload is Accepted and no node matches the load affinity of the node-agent",
+				Fix:      fix,
+			})
+			continue
+		}
+		acceptedTimestamp := dataUpload.Status.AcceptedTimestamp
+		if acceptedTimestamp == nil {
+			acceptedTimestamp = &dataUpload.CreationTimestamp
+		}
+		if acceptedTimestamp.Add(dataUploadAcceptedTimeout).Before(resources.Now) {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Object:   object,
+				Message:  fmt.Sprintf("DataUpload is Accepted since %s, its data mover pod may not be schedulable on the load affinity nodes %s", acceptedTimestamp.Format(time.RFC3339), strings.Join(matchingNodes, ", ")),
+				Fix:      "check the taints, resources and node-agent pods of the load affinity nodes, or " + fix,
+			})
+		}
+	}
+	return findings
+}
+
+func noDefaultVolumeSnapshotClass(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, csiDriver := range resources.CSIDrivers {
+		inUse := slices.ContainsFunc(resources.StorageClasses, func(storageClass storagev1.StorageClass) bool {
+			return storageClass.Provisioner == csiDriver.Name
+		})
+		if !inUse {
+			continue
+		}
+		volumeSnapshotClasses := 0
+		defaultClass := false
+		for _, volumeSnapshotClass := range resources.VolumeSnapshotClasses {
+			if volumeSnapshotClass.Driver != csiDriver.Name {
+				continue
+			}
+			volumeSnapshotClasses++
+			_, hasVeleroLabel := volumeSnapshotClass.Labels[velerov1.VolumeSnapshotClassSelectorLabel]
+			if hasVeleroLabel || volumeSnapshotClass.Annotations[volumeSnapshotClassDefaultAnnotation] == "true" {
+				defaultClass = true
+			}
+		}
+		// like velero, a single VolumeSnapshotClass of a driver is used even without the label
+		if defaultClass || volumeSnapshotClasses == 1 {
+			continue
+		}
+		finding := Finding{
+			Severity: SeverityWarning,
+			Object:   "CSIDriver " + csiDriver.Name,
+			Fix:      fmt.Sprintf("add the %s: \"true\" label to the VolumeSnapshotClass velero should use for the driver", velerov1.VolumeSnapshotClassSelectorLabel),
+		}
+		if volumeSnapshotClasses == 0 {
+			finding.Message = "CSIDriver is used by a StorageClass and has no VolumeSnapshotClass, CSI snapshots of its volumes fail"
+			finding.Fix = "create a VolumeSnapshotClass for the driver with the " + velerov1.VolumeSnapshotClassSelectorLabel + ": \"true\" label"
+		} else {
+			finding.Message = fmt.Sprintf("CSIDriver has %d VolumeSnapshotClasses and none is the default, CSI snapshots of its volumes fail", volumeSnapshotClasses)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func nodeAgentMissing(resources Resources) []Finding {
+	findings := []Finding{}
+	for _, dataProtectionApplication := range resources.DataProtectionApplications {
+		configuration := dataProtectionApplication.Spec.Configuration
+		if configuration == nil || configuration.NodeAgent == nil || configuration.NodeAgent.Enable == nil || !*configuration.NodeAgent.Enable {
+			continue
+		}
+		nodeAgentNodes := []string{}
+		for _, pod := range resources.Pods {
+			if pod.Namespace == dataProtectionApplication.Namespace && pod.Labels["name"] == "node-agent" && pod.Status.Phase == corev1.PodRunning {
+				nodeAgentNodes = append(nodeAgentNodes, pod.Spec.NodeName)
+			}
+		}
+		missingNodes := []string{}
+		for _, pod := range resources.Pods {
+			if len(pod.Spec.NodeName) == 0 || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			hasPersistentVolume := slices.ContainsFunc(pod.Spec.Volumes, func(volume corev1.Volume) bool {
+				return volume.PersistentVolumeClaim != nil
+			})
+			if hasPersistentVolume && !slices.Contains(nodeAgentNodes, pod.Spec.NodeName) && !slices.Contains(missingNodes, pod.Spec.NodeName) {
+				missingNodes = append(missingNodes, pod.Spec.NodeName)
+			}
+		}
+		slices.Sort(missingNodes)
+		for _, node := range missingNodes {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Object:   "Node " + node,
+				Message:  fmt.Sprintf("Node runs pods with PersistentVolumes and no node-agent pod of DataProtectionApplication %s/%s is running on it, file system backups and data movement of its volumes fail", dataProtectionApplication.Namespace, dataProtectionApplication.Name),
+				Fix:      "check the node-agent DaemonSet pods, and the spec.configuration.nodeAgent.podConfig nodeSelector and tolerations of the DataProtectionApplication",
+			})
+		}
+	}
+	return findings
+}
+
+func oadpOpenShiftVersionMismatch(resources Resources) []Finding {
+	findings := []Finding{}
+	versionParts := strings.Split(resources.OpenShiftVersion, ".")
+	if len(versionParts) < 2 || versionParts[0] != "4" {
+		return findings
+	}
+	openShiftMinor, err := strconv.Atoi(versionParts[1])
+	if err != nil {
+		return findings
+	}
+	for _, csv := range resources.ClusterServiceVersions {
+		// OADP dev, community and prod operators have same spec.displayName
+		if csv.Spec.DisplayName != "OADP Operator" {
+			continue
+		}
+		oadpMinorVersion := fmt.Sprintf("%d.%d", csv.Spec.Version.Major, csv.Spec.Version.Minor)
+		supported, ok := supportedOpenShiftMinorVersions[oadpMinorVersion]
+		if !ok {
+			// OADP 1.2 and lower are not supported in OpenShift 4.19 and higher
+			if csv.Spec.Version.Major > 1 || csv.Spec.Version.Minor > 2 {
+				continue
+			}
+			supported = [2]int{0, 18}
+		}
+		if openShiftMinor >= supported[0] && (supported[1] == 0 || openShiftMinor <= supported[1]) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityCritical,
+			Object:   objectName("ClusterServiceVersion", csv.Namespace, csv.Name),
+			Message:  fmt.Sprintf("OADP %s is not supported in OpenShift %s", csv.Spec.Version.String(), resources.OpenShiftVersion),
+			Fix:      "install the OADP version supported by the OpenShift version, check https://access.redhat.com/articles/5456281",
+		})
+	}
+	return findings
+}
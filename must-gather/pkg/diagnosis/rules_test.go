@@ -0,0 +1,465 @@
+package diagnosis
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/operator-framework/api/pkg/lib/version"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var now = time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)
+
+func checkFindings(t *testing.T, findings []Finding, want []Severity) {
+	t.Helper()
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings %v, want %d", len(findings), findings, len(want))
+	}
+	for i, finding := range findings {
+		if finding.Severity != want[i] {
+			t.Errorf("finding %d severity is %s, want %s: %v", i, finding.Severity, want[i], finding)
+		}
+		if len(finding.Object) == 0 || len(finding.Message) == 0 || len(finding.Fix) == 0 {
+			t.Errorf("finding %d is incomplete: %v", i, finding)
+		}
+	}
+}
+
+func newCACert(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+}
+
+func newBackupStorageLocation(phase velerov1.BackupStorageLocationPhase, caCert []byte) velerov1.BackupStorageLocation {
+	return velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "openshift-adp"},
+		Spec: velerov1.BackupStorageLocationSpec{
+			StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket", CACert: caCert}},
+		},
+		Status: velerov1.BackupStorageLocationStatus{Phase: phase},
+	}
+}
+
+func newDataProtectionApplication(nodeAgent *oadpv1alpha1.NodeAgentConfig, conditions ...metav1.Condition) oadpv1alpha1.DataProtectionApplication {
+	return oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "dpa", Namespace: "openshift-adp"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{NodeAgent: nodeAgent},
+		},
+		Status: oadpv1alpha1.DataProtectionApplicationStatus{Conditions: conditions},
+	}
+}
+
+func newNode(name string, labels map[string]string) corev1.Node {
+	return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func newPod(namespace string, name string, labels map[string]string, node string, persistentVolume bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if persistentVolume {
+		pod.Spec.Volumes = []corev1.Volume{{
+			Name:         "data",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}},
+		}}
+	}
+	return pod
+}
+
+func newCSV(oadpVersion string) operatorsv1alpha1.ClusterServiceVersion {
+	return operatorsv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "oadp-operator.v" + oadpVersion, Namespace: "openshift-adp"},
+		Spec: operatorsv1alpha1.ClusterServiceVersionSpec{
+			DisplayName: "OADP Operator",
+			Version:     version.OperatorVersion{Version: semver.MustParse(oadpVersion)},
+		},
+	}
+}
+
+func TestBackupStorageLocationNotAvailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources Resources
+		want      []Severity
+	}{
+		{
+			name:      "Available BSL",
+			resources: Resources{BackupStorageLocations: []velerov1.BackupStorageLocation{newBackupStorageLocation(velerov1.BackupStorageLocationPhaseAvailable, nil)}},
+			want:      []Severity{},
+		},
+		{
+			name:      "Unavailable BSL",
+			resources: Resources{BackupStorageLocations: []velerov1.BackupStorageLocation{newBackupStorageLocation(velerov1.BackupStorageLocationPhaseUnavailable, nil)}},
+			want:      []Severity{SeverityCritical},
+		},
+		{
+			name:      "BSL without phase",
+			resources: Resources{BackupStorageLocations: []velerov1.BackupStorageLocation{newBackupStorageLocation("", nil)}},
+			want:      []Severity{SeverityWarning},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkFindings(t, backupStorageLocationNotAvailable(test.resources), test.want)
+		})
+	}
+}
+
+func TestBackupStorageLocationCACertificateExpired(t *testing.T) {
+	tests := []struct {
+		name   string
+		caCert []byte
+		want   []Severity
+	}{
+		{
+			name:   "no CA certificate",
+			caCert: nil,
+			want:   []Severity{},
+		},
+		{
+			name:   "valid CA certificate",
+			caCert: newCACert(t, now.Add(365*24*time.Hour)),
+			want:   []Severity{},
+		},
+		{
+			name:   "CA certificate about to expire",
+			caCert: newCACert(t, now.Add(24*time.Hour)),
+			want:   []Severity{SeverityWarning},
+		},
+		{
+			name:   "expired CA certificate",
+			caCert: newCACert(t, now.Add(-24*time.Hour)),
+			want:   []Severity{SeverityCritical},
+		},
+		{
+			name:   "CA bundle with an expired CA certificate",
+			caCert: append(newCACert(t, now.Add(365*24*time.Hour)), newCACert(t, now.Add(-24*time.Hour))...),
+			want:   []Severity{SeverityCritical},
+		},
+		{
+			name:   "invalid CA certificate",
+			caCert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")}),
+			want:   []Severity{SeverityWarning},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				Now:                    now,
+				BackupStorageLocations: []velerov1.BackupStorageLocation{newBackupStorageLocation(velerov1.BackupStorageLocationPhaseAvailable, test.caCert)},
+			}
+			checkFindings(t, backupStorageLocationCACertificateExpired(resources), test.want)
+		})
+	}
+}
+
+func TestDataProtectionApplicationNotReconciled(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []metav1.Condition
+		want       []Severity
+	}{
+		{
+			name:       "DPA without conditions",
+			conditions: nil,
+			want:       []Severity{},
+		},
+		{
+			name:       "reconciled DPA",
+			conditions: []metav1.Condition{{Type: oadpv1alpha1.ConditionReconciled, Status: metav1.ConditionTrue, Reason: oadpv1alpha1.ReconciledReasonComplete}},
+			want:       []Severity{},
+		},
+		{
+			name:       "not reconciled DPA",
+			conditions: []metav1.Condition{{Type: oadpv1alpha1.ConditionReconciled, Status: metav1.ConditionFalse, Reason: oadpv1alpha1.ReconciledReasonError, Message: "invalid spec"}},
+			want:       []Severity{SeverityCritical},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				DataProtectionApplications: []oadpv1alpha1.DataProtectionApplication{newDataProtectionApplication(nil, test.conditions...)},
+			}
+			checkFindings(t, dataProtectionApplicationNotReconciled(resources), test.want)
+		})
+	}
+}
+
+func TestDataUploadStuckByLoadAffinity(t *testing.T) {
+	loadAffinity := &oadpv1alpha1.NodeAgentConfig{
+		NodeAgentConfigMapSettings: oadpv1alpha1.NodeAgentConfigMapSettings{
+			LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+				{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"data-mover": "true"}}},
+			},
+		},
+	}
+	newDataUpload := func(phase velerov2alpha1.DataUploadPhase, accepted time.Time) velerov2alpha1.DataUpload {
+		return velerov2alpha1.DataUpload{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-abcde", Namespace: "openshift-adp"},
+			Status:     velerov2alpha1.DataUploadStatus{Phase: phase, AcceptedTimestamp: &metav1.Time{Time: accepted}},
+		}
+	}
+	tests := []struct {
+		name      string
+		nodeAgent *oadpv1alpha1.NodeAgentConfig
+		nodes     []corev1.Node
+		upload    velerov2alpha1.DataUpload
+		want      []Severity
+	}{
+		{
+			name:      "DataUpload in progress",
+			nodeAgent: loadAffinity,
+			nodes:     []corev1.Node{newNode("worker-1", nil)},
+			upload:    newDataUpload(velerov2alpha1.DataUploadPhaseInProgress, now.Add(-time.Hour)),
+			want:      []Severity{},
+		},
+		{
+			name:      "Accepted DataUpload without load affinity",
+			nodeAgent: &oadpv1alpha1.NodeAgentConfig{},
+			nodes:     []corev1.Node{newNode("worker-1", nil)},
+			upload:    newDataUpload(velerov2alpha1.DataUploadPhaseAccepted, now.Add(-time.Hour)),
+			want:      []Severity{},
+		},
+		{
+			name:      "Accepted DataUpload and no node matches load affinity",
+			nodeAgent: loadAffinity,
+			nodes:     []corev1.Node{newNode("worker-1", nil)},
+			upload:    newDataUpload(velerov2alpha1.DataUploadPhaseAccepted, now.Add(-time.Minute)),
+			want:      []Severity{SeverityCritical},
+		},
+		{
+			name:      "recently Accepted DataUpload on load affinity node",
+			nodeAgent: loadAffinity,
+			nodes:     []corev1.Node{newNode("worker-1", map[string]string{"data-mover": "true"})},
+			upload:    newDataUpload(velerov2alpha1.DataUploadPhaseAccepted, now.Add(-time.Minute)),
+			want:      []Severity{},
+		},
+		{
+			name:      "stuck Accepted DataUpload on load affinity node",
+			nodeAgent: loadAffinity,
+			nodes:     []corev1.Node{newNode("worker-1", map[string]string{"data-mover": "true"})},
+			upload:    newDataUpload(velerov2alpha1.DataUploadPhaseAccepted, now.Add(-time.Hour)),
+			want:      []Severity{SeverityWarning},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				Now:                        now,
+				Nodes:                      test.nodes,
+				DataProtectionApplications: []oadpv1alpha1.DataProtectionApplication{newDataProtectionApplication(test.nodeAgent)},
+				DataUploads:                []velerov2alpha1.DataUpload{test.upload},
+			}
+			checkFindings(t, dataUploadStuckByLoadAffinity(resources), test.want)
+		})
+	}
+}
+
+func TestNoDefaultVolumeSnapshotClass(t *testing.T) {
+	newVolumeSnapshotClass := func(name string, driver string, labels map[string]string) volumesnapshotv1.VolumeSnapshotClass {
+		return volumesnapshotv1.VolumeSnapshotClass{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}, Driver: driver}
+	}
+	csiDrivers := []storagev1.CSIDriver{{ObjectMeta: metav1.ObjectMeta{Name: "ebs.csi.aws.com"}}}
+	storageClasses := []storagev1.StorageClass{{ObjectMeta: metav1.ObjectMeta{Name: "gp3-csi"}, Provisioner: "ebs.csi.aws.com"}}
+	tests := []struct {
+		name                  string
+		storageClasses        []storagev1.StorageClass
+		volumeSnapshotClasses []volumesnapshotv1.VolumeSnapshotClass
+		want                  []Severity
+	}{
+		{
+			name:                  "CSI driver not in use",
+			storageClasses:        nil,
+			volumeSnapshotClasses: nil,
+			want:                  []Severity{},
+		},
+		{
+			name:                  "CSI driver in use without VolumeSnapshotClass",
+			storageClasses:        storageClasses,
+			volumeSnapshotClasses: nil,
+			want:                  []Severity{SeverityWarning},
+		},
+		{
+			name:                  "CSI driver in use with a single VolumeSnapshotClass",
+			storageClasses:        storageClasses,
+			volumeSnapshotClasses: []volumesnapshotv1.VolumeSnapshotClass{newVolumeSnapshotClass("csi-aws-vsc", "ebs.csi.aws.com", nil)},
+			want:                  []Severity{},
+		},
+		{
+			name:           "CSI driver in use with VolumeSnapshotClasses without velero label",
+			storageClasses: storageClasses,
+			volumeSnapshotClasses: []volumesnapshotv1.VolumeSnapshotClass{
+				newVolumeSnapshotClass("csi-aws-vsc", "ebs.csi.aws.com", nil),
+				newVolumeSnapshotClass("csi-aws-vsc-retain", "ebs.csi.aws.com", nil),
+			},
+			want: []Severity{SeverityWarning},
+		},
+		{
+			name:           "CSI driver in use with VolumeSnapshotClasses with velero label",
+			storageClasses: storageClasses,
+			volumeSnapshotClasses: []volumesnapshotv1.VolumeSnapshotClass{
+				newVolumeSnapshotClass("csi-aws-vsc", "ebs.csi.aws.com", nil),
+				newVolumeSnapshotClass("csi-aws-vsc-retain", "ebs.csi.aws.com", map[string]string{velerov1.VolumeSnapshotClassSelectorLabel: "true"}),
+			},
+			want: []Severity{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				StorageClasses:        test.storageClasses,
+				VolumeSnapshotClasses: test.volumeSnapshotClasses,
+				CSIDrivers:            csiDrivers,
+			}
+			checkFindings(t, noDefaultVolumeSnapshotClass(resources), test.want)
+		})
+	}
+}
+
+func TestNodeAgentMissing(t *testing.T) {
+	nodeAgentLabels := map[string]string{"component": "velero", "name": "node-agent"}
+	tests := []struct {
+		name      string
+		nodeAgent *oadpv1alpha1.NodeAgentConfig
+		pods      []corev1.Pod
+		want      []Severity
+	}{
+		{
+			name:      "node-agent disabled",
+			nodeAgent: nil,
+			pods:      []corev1.Pod{newPod("app", "app-1", nil, "worker-1", true)},
+			want:      []Severity{},
+		},
+		{
+			name:      "node-agent on all nodes with PersistentVolumes",
+			nodeAgent: &oadpv1alpha1.NodeAgentConfig{NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)}},
+			pods: []corev1.Pod{
+				newPod("app", "app-1", nil, "worker-1", true),
+				newPod("app", "app-2", nil, "worker-2", false),
+				newPod("openshift-adp", "node-agent-abcde", nodeAgentLabels, "worker-1", false),
+			},
+			want: []Severity{},
+		},
+		{
+			name:      "node-agent missing on nodes with PersistentVolumes",
+			nodeAgent: &oadpv1alpha1.NodeAgentConfig{NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)}},
+			pods: []corev1.Pod{
+				newPod("app", "app-1", nil, "worker-1", true),
+				newPod("app", "app-2", nil, "worker-2", true),
+				newPod("app", "app-3", nil, "worker-3", true),
+				newPod("openshift-adp", "node-agent-abcde", nodeAgentLabels, "worker-1", false),
+			},
+			want: []Severity{SeverityWarning, SeverityWarning},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				Pods:                       test.pods,
+				DataProtectionApplications: []oadpv1alpha1.DataProtectionApplication{newDataProtectionApplication(test.nodeAgent)},
+			}
+			checkFindings(t, nodeAgentMissing(resources), test.want)
+		})
+	}
+}
+
+func TestOADPOpenShiftVersionMismatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		openShiftVersion string
+		oadpVersion      string
+		want             []Severity
+	}{
+		{
+			name:             "OADP 1.4 in OpenShift 4.16",
+			openShiftVersion: "4.16.10",
+			oadpVersion:      "1.4.4",
+			want:             []Severity{},
+		},
+		{
+			name:             "OADP 1.4 in OpenShift 4.19",
+			openShiftVersion: "4.19.0",
+			oadpVersion:      "1.4.4",
+			want:             []Severity{SeverityCritical},
+		},
+		{
+			name:             "OADP 1.5 in OpenShift 4.18",
+			openShiftVersion: "4.18.3",
+			oadpVersion:      "1.5.0",
+			want:             []Severity{SeverityCritical},
+		},
+		{
+			name:             "OADP 1.5 in OpenShift 4.20",
+			openShiftVersion: "4.20.1",
+			oadpVersion:      "1.5.1",
+			want:             []Severity{},
+		},
+		{
+			name:             "OADP 1.2 in OpenShift 4.19",
+			openShiftVersion: "4.19.0",
+			oadpVersion:      "1.2.5",
+			want:             []Severity{SeverityCritical},
+		},
+		{
+			name:             "unknown OADP version",
+			openShiftVersion: "4.19.0",
+			oadpVersion:      "99.0.0",
+			want:             []Severity{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := Resources{
+				OpenShiftVersion:       test.openShiftVersion,
+				ClusterServiceVersions: []operatorsv1alpha1.ClusterServiceVersion{newCSV(test.oadpVersion)},
+			}
+			checkFindings(t, oadpOpenShiftVersionMismatch(resources), test.want)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	findings := Run(Resources{
+		Now:                    now,
+		BackupStorageLocations: []velerov1.BackupStorageLocation{newBackupStorageLocation(velerov1.BackupStorageLocationPhaseUnavailable, newCACert(t, now.Add(-time.Hour)))},
+	})
+	checkFindings(t, findings, []Severity{SeverityCritical, SeverityCritical})
+	if findings[0].Rule != "BackupStorageLocationNotAvailable" || findings[1].Rule != "BackupStorageLocationCACertificateExpired" {
+		t.Errorf("findings rules are %s and %s", findings[0].Rule, findings[1].Rule)
+	}
+}
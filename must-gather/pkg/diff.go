@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openshift/oadp-operator/must-gather/pkg/diff"
+	"github.com/openshift/oadp-operator/must-gather/pkg/gather"
+	"github.com/openshift/oadp-operator/must-gather/pkg/templates"
+)
+
+// DiffOutput is the directory the diff files are written to
+var DiffOutput string
+
+var Diff = &cobra.Command{
+	Use:   "diff <before-must-gather-directory> <after-must-gather-directory>",
+	Short: "Compare the OADP configuration of two existing OADP Must-gather directories, without cluster connection",
+	Long: `Compare the OADP configuration of two existing OADP Must-gather directories, without cluster connection.
+
+The differences of DataProtectionApplications spec, velero and plugin images, BackupStorageLocations and VolumeSnapshotLocations spec, OADP and related operators versions, StorageClasses and VolumeSnapshotClasses, and the change of Backups success rate are written to oadp-must-gather-diff.md and oadp-must-gather-diff.json.`,
+	Example: `  # comparing the directories created by oc adm must-gather before and after an upgrade
+  gather diff must-gather.local.123456789 must-gather.local.987654321
+
+  # comparing a working and a broken cluster, writing the diff to /tmp
+  gather diff working/must-gather/clusters/1a2b3c4d broken/must-gather/clusters/5e6f7a8b --output /tmp`,
+	Args:          cobra.ExactArgs(2),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		before, err := loadDiffResources(args[0])
+		if err != nil {
+			fmt.Printf("Exiting OADP must-gather diff: %v\n", err)
+			return err
+		}
+		after, err := loadDiffResources(args[1])
+		if err != nil {
+			fmt.Printf("Exiting OADP must-gather diff: %v\n", err)
+			return err
+		}
+
+		changes := diff.Compare(before, after)
+		content, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(DiffOutput, templates.FolderPermission)
+		if err != nil {
+			fmt.Printf("Error occurred: %v\n", err)
+			return err
+		}
+		markdownPath := filepath.Join(DiffOutput, "oadp-must-gather-diff.md")
+		err = os.WriteFile(markdownPath, []byte(changes.Markdown()), templates.FilePermission)
+		if err != nil {
+			fmt.Printf("Error occurred: %v\n", err)
+			return err
+		}
+		jsonPath := filepath.Join(DiffOutput, "oadp-must-gather-diff.json")
+		err = os.WriteFile(jsonPath, content, templates.FilePermission)
+		if err != nil {
+			fmt.Printf("Error occurred: %v\n", err)
+			return err
+		}
+		fmt.Printf("OADP must-gather diff written to %s and %s, %d changes found\n", markdownPath, jsonPath, len(changes.Changes))
+		return nil
+	},
+}
+
+// loadDiffResources loads the objects compared by diff from a must-gather directory, which must contain one cluster
+// directory
+func loadDiffResources(directory string) (diff.Resources, error) {
+	clusterPaths, err := findClusterPaths(directory)
+	if err != nil {
+		return diff.Resources{}, err
+	}
+	if len(clusterPaths) != 1 {
+		return diff.Resources{}, fmt.Errorf("found %d OADP must-gather cluster directories in %s, pass one of them: %v", len(clusterPaths), directory, clusterPaths)
+	}
+
+	scheme := runtime.NewScheme()
+	err = addToScheme(scheme)
+	if err != nil {
+		return diff.Resources{}, err
+	}
+	resources := newClusterResources()
+	err = gather.Load(scheme, clusterPaths[0], append(resources.lists(), resources.clusterVersionList)...)
+	if err != nil {
+		return diff.Resources{}, fmt.Errorf("an error happened while loading %s: %w", clusterPaths[0], err)
+	}
+	clusterVersion, _, _, err := getClusterVersion(resources.clusterVersionList)
+	if err != nil {
+		return diff.Resources{}, err
+	}
+	return diff.Resources{
+		Path:                       clusterPaths[0],
+		ClusterID:                  filepath.Base(clusterPaths[0]),
+		OpenShiftVersion:           clusterVersion.Status.Desired.Version,
+		ClusterServiceVersions:     resources.clusterServiceVersionList.Items,
+		Pods:                       resources.podList.Items,
+		DataProtectionApplications: resources.dataProtectionApplicationList.Items,
+		BackupStorageLocations:     resources.backupStorageLocationList.Items,
+		VolumeSnapshotLocations:    resources.volumeSnapshotLocationList.Items,
+		Backups:                    resources.backupList.Items,
+		StorageClasses:             resources.storageClassList.Items,
+		VolumeSnapshotClasses:      resources.volumeSnapshotClassList.Items,
+	}, nil
+}
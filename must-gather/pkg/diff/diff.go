@@ -0,0 +1,428 @@
+// Package diff compares the OADP configuration of two must-gathers, like before and after an upgrade, or of a working
+// and a broken cluster
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// SchemaVersion is increased when a field of Diff is removed or changes meaning. New fields can be added without
+// increasing it, so consumers must ignore unknown fields.
+const SchemaVersion = "v1"
+
+const (
+	defaultStorageClassAnnotation        = "storageclass.kubernetes.io/is-default-class"
+	defaultVolumeSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+	// markdownValueLength is the number of characters of a value shown in the Markdown diff, like of a CA certificate
+	markdownValueLength = 120
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "Added"
+	ChangeRemoved  ChangeType = "Removed"
+	ChangeModified ChangeType = "Modified"
+)
+
+type Kind string
+
+const (
+	KindClusterServiceVersion     Kind = "ClusterServiceVersion"
+	KindDataProtectionApplication Kind = "DataProtectionApplication"
+	// KindImage are the images of the velero and node-agent Pods containers, like the plugins init containers
+	KindImage                  Kind = "Image"
+	KindBackupStorageLocation  Kind = "BackupStorageLocation"
+	KindVolumeSnapshotLocation Kind = "VolumeSnapshotLocation"
+	KindStorageClass           Kind = "StorageClass"
+	KindVolumeSnapshotClass    Kind = "VolumeSnapshotClass"
+)
+
+// sections are the kinds of Changes, in the order of the Markdown diff
+var sections = []struct {
+	kind  Kind
+	title string
+}{
+	{kind: KindClusterServiceVersion, title: "Operators (ClusterServiceVersions)"},
+	{kind: KindDataProtectionApplication, title: "DataProtectionApplications (DPAs)"},
+	{kind: KindImage, title: "Velero and plugin images"},
+	{kind: KindBackupStorageLocation, title: "BackupStorageLocations (BSLs)"},
+	{kind: KindVolumeSnapshotLocation, title: "VolumeSnapshotLocations (VSLs)"},
+	{kind: KindStorageClass, title: "StorageClasses"},
+	{kind: KindVolumeSnapshotClass, title: "VolumeSnapshotClasses"},
+}
+
+// Resources are the objects of a must-gather that are compared
+type Resources struct {
+	Path                       string
+	ClusterID                  string
+	OpenShiftVersion           string
+	ClusterServiceVersions     []operatorsv1alpha1.ClusterServiceVersion
+	Pods                       []corev1.Pod
+	DataProtectionApplications []oadpv1alpha1.DataProtectionApplication
+	BackupStorageLocations     []velerov1.BackupStorageLocation
+	VolumeSnapshotLocations    []velerov1.VolumeSnapshotLocation
+	Backups                    []velerov1.Backup
+	StorageClasses             []storagev1.StorageClass
+	VolumeSnapshotClasses      []volumesnapshotv1.VolumeSnapshotClass
+}
+
+// Diff holds the changes from the before must-gather to the after must-gather
+type Diff struct {
+	SchemaVersion     string            `json:"schemaVersion"`
+	Before            Source            `json:"before"`
+	After             Source            `json:"after"`
+	Changes           []Change          `json:"changes"`
+	BackupSuccessRate BackupSuccessRate `json:"backupSuccessRate"`
+}
+
+// Source is a compared must-gather
+type Source struct {
+	Path             string `json:"path"`
+	ClusterID        string `json:"clusterID"`
+	OpenShiftVersion string `json:"openShiftVersion"`
+}
+
+// Change is an added or removed object, or a modified field of an object. Field is the JSON path of the field, like
+// spec.configuration.velero.defaultPlugins, and Before and After are its values.
+type Change struct {
+	Kind   Kind       `json:"kind"`
+	Object string     `json:"object"`
+	Type   ChangeType `json:"type"`
+	Field  string     `json:"field,omitempty"`
+	Before string     `json:"before,omitempty"`
+	After  string     `json:"after,omitempty"`
+}
+
+type BackupSuccessRate struct {
+	Before SuccessRate `json:"before"`
+	After  SuccessRate `json:"after"`
+	// Change is the difference in percentage points, not set if no Backup finished in one of the must-gathers
+	Change *float64 `json:"change"`
+}
+
+// SuccessRate is the percentage of the finished Backups that completed, not set if no Backup finished
+type SuccessRate struct {
+	Total     int      `json:"total"`
+	Finished  int      `json:"finished"`
+	Completed int      `json:"completed"`
+	Rate      *float64 `json:"rate"`
+}
+
+// Compare returns the changes from before to after
+func Compare(before Resources, after Resources) *Diff {
+	diff := &Diff{
+		SchemaVersion: SchemaVersion,
+		Before:        Source{Path: before.Path, ClusterID: before.ClusterID, OpenShiftVersion: before.OpenShiftVersion},
+		After:         Source{Path: after.Path, ClusterID: after.ClusterID, OpenShiftVersion: after.OpenShiftVersion},
+		Changes:       []Change{},
+	}
+	diff.compare(KindClusterServiceVersion, clusterServiceVersionFields(before.ClusterServiceVersions), clusterServiceVersionFields(after.ClusterServiceVersions))
+	diff.compare(KindDataProtectionApplication, dataProtectionApplicationFields(before.DataProtectionApplications), dataProtectionApplicationFields(after.DataProtectionApplications))
+	diff.compare(KindImage, imageFields(before.Pods), imageFields(after.Pods))
+	diff.compare(KindBackupStorageLocation, backupStorageLocationFields(before.BackupStorageLocations), backupStorageLocationFields(after.BackupStorageLocations))
+	diff.compare(KindVolumeSnapshotLocation, volumeSnapshotLocationFields(before.VolumeSnapshotLocations), volumeSnapshotLocationFields(after.VolumeSnapshotLocations))
+	diff.compare(KindStorageClass, storageClassFields(before.StorageClasses), storageClassFields(after.StorageClasses))
+	diff.compare(KindVolumeSnapshotClass, volumeSnapshotClassFields(before.VolumeSnapshotClasses), volumeSnapshotClassFields(after.VolumeSnapshotClasses))
+
+	diff.BackupSuccessRate.Before = backupSuccessRate(before.Backups)
+	diff.BackupSuccessRate.After = backupSuccessRate(after.Backups)
+	if diff.BackupSuccessRate.Before.Rate != nil && diff.BackupSuccessRate.After.Rate != nil {
+		change := *diff.BackupSuccessRate.After.Rate - *diff.BackupSuccessRate.Before.Rate
+		diff.BackupSuccessRate.Change = &change
+	}
+	return diff
+}
+
+// compare adds the changes of the objects of a kind, whose fields are keyed by object
+func (d *Diff) compare(kind Kind, before map[string]map[string]string, after map[string]map[string]string) {
+	for _, object := range sortedKeys(before, after) {
+		beforeFields, inBefore := before[object]
+		afterFields, inAfter := after[object]
+		switch {
+		case !inBefore:
+			d.Changes = append(d.Changes, Change{Kind: kind, Object: object, Type: ChangeAdded})
+		case !inAfter:
+			d.Changes = append(d.Changes, Change{Kind: kind, Object: object, Type: ChangeRemoved})
+		default:
+			for _, field := range sortedKeys(beforeFields, afterFields) {
+				if beforeFields[field] == afterFields[field] {
+					continue
+				}
+				d.Changes = append(d.Changes, Change{
+					Kind:   kind,
+					Object: object,
+					Type:   ChangeModified,
+					Field:  field,
+					Before: beforeFields[field],
+					After:  afterFields[field],
+				})
+			}
+		}
+	}
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	keys := []string{}
+	for _, m := range maps {
+		for key := range m {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// clusterServiceVersionFields are keyed by display name, since the ClusterServiceVersion name contains its version
+func clusterServiceVersionFields(items []operatorsv1alpha1.ClusterServiceVersion) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	for _, csv := range items {
+		name := csv.Spec.DisplayName
+		if len(name) == 0 {
+			name = csv.Name
+		}
+		objects[csv.Namespace+"/"+name] = map[string]string{"spec.version": csv.Spec.Version.String()}
+	}
+	return objects
+}
+
+func dataProtectionApplicationFields(items []oadpv1alpha1.DataProtectionApplication) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	for _, dataProtectionApplication := range items {
+		objects[dataProtectionApplication.Namespace+"/"+dataProtectionApplication.Name] = fields("spec", dataProtectionApplication.Spec)
+	}
+	return objects
+}
+
+// imageFields are the container images of a velero and a node-agent Pod of each namespace, keyed by container
+func imageFields(items []corev1.Pod) map[string]map[string]string {
+	pods := slices.Clone(items)
+	slices.SortFunc(pods, func(a, b corev1.Pod) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+	objects := map[string]map[string]string{}
+	for _, pod := range pods {
+		object := ""
+		switch {
+		case pod.Labels["deploy"] == "velero":
+			object = pod.Namespace + "/velero"
+		case pod.Labels["name"] == "node-agent":
+			object = pod.Namespace + "/node-agent"
+		default:
+			continue
+		}
+		if _, ok := objects[object]; ok {
+			continue
+		}
+		objects[object] = map[string]string{}
+		for _, container := range pod.Spec.InitContainers {
+			objects[object]["initContainers."+container.Name] = container.Image
+		}
+		for _, container := range pod.Spec.Containers {
+			objects[object]["containers."+container.Name] = container.Image
+		}
+	}
+	return objects
+}
+
+func backupStorageLocationFields(items []velerov1.BackupStorageLocation) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	for _, backupStorageLocation := range items {
+		objects[backupStorageLocation.Namespace+"/"+backupStorageLocation.Name] = fields("spec", backupStorageLocation.Spec)
+	}
+	return objects
+}
+
+func volumeSnapshotLocationFields(items []velerov1.VolumeSnapshotLocation) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	for _, volumeSnapshotLocation := range items {
+		objects[volumeSnapshotLocation.Namespace+"/"+volumeSnapshotLocation.Name] = fields("spec", volumeSnapshotLocation.Spec)
+	}
+	return objects
+}
+
+func storageClassFields(items []storagev1.StorageClass) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	for _, storageClass := range items {
+		objectFields := fields("", storageClass, "apiVersion", "kind", "metadata")
+		addDefaultAnnotation(objectFields, storageClass.Annotations, defaultStorageClassAnnotation)
+		objects[storageClass.Name] = objectFields
+	}
+	return objects
+}
+
+func volumeSnapshotClassFields(items []volumesnapshotv1.VolumeSnapshotClass) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	for _, volumeSnapshotClass := range items {
+		objectFields := fields("", volumeSnapshotClass, "apiVersion", "kind", "metadata")
+		addDefaultAnnotation(objectFields, volumeSnapshotClass.Annotations, defaultVolumeSnapshotClassAnnotation)
+		objects[volumeSnapshotClass.Name] = objectFields
+	}
+	return objects
+}
+
+func addDefaultAnnotation(objectFields map[string]string, annotations map[string]string, annotation string) {
+	value, ok := annotations[annotation]
+	if ok {
+		objectFields["metadata.annotations."+annotation] = value
+	}
+}
+
+// fields flattens the JSON of an object into its leaf fields, keyed by JSON path, without the dropped top level fields.
+// Lists of values are a single field.
+func fields(prefix string, object any, drop ...string) map[string]string {
+	objectFields := map[string]string{}
+	content, err := json.Marshal(object)
+	if err != nil {
+		objectFields[prefix] = err.Error()
+		return objectFields
+	}
+	var value any
+	err = json.Unmarshal(content, &value)
+	if err != nil {
+		objectFields[prefix] = err.Error()
+		return objectFields
+	}
+	if fieldsMap, ok := value.(map[string]any); ok {
+		for _, field := range drop {
+			delete(fieldsMap, field)
+		}
+	}
+	flatten(prefix, value, objectFields)
+	return objectFields
+}
+
+func flatten(path string, value any, objectFields map[string]string) {
+	join := func(field string) string {
+		if len(path) == 0 {
+			return field
+		}
+		return path + "." + field
+	}
+	switch typed := value.(type) {
+	case nil:
+	case string:
+		objectFields[path] = typed
+	case map[string]any:
+		for field, fieldValue := range typed {
+			flatten(join(field), fieldValue, objectFields)
+		}
+	case []any:
+		if len(typed) == 0 {
+			return
+		}
+		values := true
+		for _, item := range typed {
+			switch item.(type) {
+			case map[string]any, []any:
+				values = false
+			}
+		}
+		if !values {
+			for i, item := range typed {
+				flatten(fmt.Sprintf("%s[%d]", path, i), item, objectFields)
+			}
+			return
+		}
+		content, _ := json.Marshal(typed)
+		objectFields[path] = string(content)
+	default:
+		content, _ := json.Marshal(typed)
+		objectFields[path] = string(content)
+	}
+}
+
+func backupSuccessRate(backups []velerov1.Backup) SuccessRate {
+	successRate := SuccessRate{Total: len(backups)}
+	for _, backup := range backups {
+		switch backup.Status.Phase {
+		case velerov1.BackupPhaseCompleted:
+			successRate.Completed++
+			successRate.Finished++
+		case velerov1.BackupPhasePartiallyFailed, velerov1.BackupPhaseFailed, velerov1.BackupPhaseFailedValidation:
+			successRate.Finished++
+		}
+	}
+	if successRate.Finished != 0 {
+		rate := 100 * float64(successRate.Completed) / float64(successRate.Finished)
+		successRate.Rate = &rate
+	}
+	return successRate
+}
+
+// Markdown returns the diff as a Markdown document, with a table of changes per kind
+func (d *Diff) Markdown() string {
+	markdown := "# OADP must-gather diff\n\n"
+	markdown += "| | before | after |\n| --- | --- | --- |\n"
+	markdown += fmt.Sprintf("| must-gather | %s | %s |\n", markdownValue(d.Before.Path), markdownValue(d.After.Path))
+	markdown += fmt.Sprintf("| Cluster ID | %s | %s |\n", markdownValue(d.Before.ClusterID), markdownValue(d.After.ClusterID))
+	markdown += fmt.Sprintf("| OpenShift version | %s | %s |\n", markdownValue(d.Before.OpenShiftVersion), markdownValue(d.After.OpenShiftVersion))
+
+	markdown += "\n## Backup success rate\n\n"
+	markdown += "| | before | after |\n| --- | --- | --- |\n"
+	markdown += fmt.Sprintf("| Backups | %d | %d |\n", d.BackupSuccessRate.Before.Total, d.BackupSuccessRate.After.Total)
+	markdown += fmt.Sprintf("| finished | %d | %d |\n", d.BackupSuccessRate.Before.Finished, d.BackupSuccessRate.After.Finished)
+	markdown += fmt.Sprintf("| Completed | %d | %d |\n", d.BackupSuccessRate.Before.Completed, d.BackupSuccessRate.After.Completed)
+	markdown += fmt.Sprintf("| success rate | %s | %s |\n", percentage(d.BackupSuccessRate.Before.Rate), percentage(d.BackupSuccessRate.After.Rate))
+	if d.BackupSuccessRate.Change != nil {
+		emoji := "➡️"
+		if *d.BackupSuccessRate.Change < 0 {
+			emoji = "⚠️"
+		} else if *d.BackupSuccessRate.Change > 0 {
+			emoji = "✅"
+		}
+		markdown += fmt.Sprintf("\n%s Backup success rate changed by %+.1f percentage points\n", emoji, *d.BackupSuccessRate.Change)
+	} else {
+		markdown += "\nNo Backup finished in one of the must-gathers, success rates can not be compared\n"
+	}
+
+	for _, section := range sections {
+		markdown += fmt.Sprintf("\n## %s\n\n", section.title)
+		changes := []Change{}
+		for _, change := range d.Changes {
+			if change.Kind == section.kind {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			markdown += "No changes\n"
+			continue
+		}
+		markdown += "| object | change | field | before | after |\n| --- | --- | --- | --- | --- |\n"
+		for _, change := range changes {
+			markdown += fmt.Sprintf(
+				"| %s | %s | %s | %s | %s |\n",
+				markdownValue(change.Object), change.Type, markdownValue(change.Field),
+				markdownValue(change.Before), markdownValue(change.After),
+			)
+		}
+	}
+	return markdown
+}
+
+func markdownValue(value string) string {
+	if runes := []rune(value); len(runes) > markdownValueLength {
+		value = fmt.Sprintf("%s… (%d characters)", string(runes[:markdownValueLength]), len(runes))
+	}
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
+
+func percentage(rate *float64) string {
+	if rate == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *rate)
+}
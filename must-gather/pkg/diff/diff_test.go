@@ -0,0 +1,166 @@
+package diff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/operator-framework/api/pkg/lib/version"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newResources(oadpVersion string, plugins []oadpv1alpha1.DefaultPlugin, awsPluginImage string, bucket string, defaultStorageClass string, phases ...velerov1.BackupPhase) Resources {
+	resources := Resources{
+		Path:             "must-gather/clusters/1a2b3c4d",
+		ClusterID:        "1a2b3c4d",
+		OpenShiftVersion: "4.19.0",
+		ClusterServiceVersions: []operatorsv1alpha1.ClusterServiceVersion{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "oadp-operator.v" + oadpVersion},
+			Spec: operatorsv1alpha1.ClusterServiceVersionSpec{
+				DisplayName: "OADP Operator",
+				Version:     version.OperatorVersion{Version: semver.MustParse(oadpVersion)},
+			},
+		}},
+		Pods: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "velero-1", Labels: map[string]string{"component": "velero", "deploy": "velero"}},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "velero-plugin-for-aws", Image: awsPluginImage}},
+					Containers:     []corev1.Container{{Name: "velero", Image: "velero:v1"}},
+				},
+			},
+			{
+				// sorts before the velero Pod and has its component label
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "node-agent-1", Labels: map[string]string{"component": "velero", "name": "node-agent"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "node-agent", Image: "velero:v1"}}},
+			},
+		},
+		DataProtectionApplications: []oadpv1alpha1.DataProtectionApplication{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "dpa"},
+			Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: plugins},
+				},
+			},
+		}},
+		BackupStorageLocations: []velerov1.BackupStorageLocation{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "dpa-1"},
+			Spec: velerov1.BackupStorageLocationSpec{
+				Provider: "aws",
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: bucket},
+				},
+			},
+		}},
+		VolumeSnapshotClasses: []volumesnapshotv1.VolumeSnapshotClass{{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-snapclass"},
+			Driver:     "ebs.csi.aws.com",
+		}},
+	}
+	for _, name := range []string{"gp2-csi", "gp3-csi"} {
+		storageClass := storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Provisioner: "ebs.csi.aws.com"}
+		if name == defaultStorageClass {
+			storageClass.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+		}
+		resources.StorageClasses = append(resources.StorageClasses, storageClass)
+	}
+	for _, phase := range phases {
+		resources.Backups = append(resources.Backups, velerov1.Backup{Status: velerov1.BackupStatus{Phase: phase}})
+	}
+	return resources
+}
+
+func TestCompare(t *testing.T) {
+	before := newResources(
+		"1.4.4", []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS, oadpv1alpha1.DefaultPluginOpenShift},
+		"velero-plugin-for-aws:v1.4", "bucket", "gp2-csi",
+		velerov1.BackupPhaseCompleted, velerov1.BackupPhaseCompleted, velerov1.BackupPhaseCompleted, velerov1.BackupPhaseFailed,
+	)
+	after := newResources(
+		"1.5.0", []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS, oadpv1alpha1.DefaultPluginOpenShift, oadpv1alpha1.DefaultPluginKubeVirt},
+		"velero-plugin-for-aws:v1.5", "other-bucket", "gp3-csi",
+		velerov1.BackupPhaseCompleted, velerov1.BackupPhasePartiallyFailed, velerov1.BackupPhaseInProgress,
+	)
+	after.VolumeSnapshotClasses = nil
+	after.BackupStorageLocations = append(after.BackupStorageLocations, velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "dpa-2"},
+	})
+
+	diff := Compare(before, after)
+	want := []Change{
+		{Kind: KindClusterServiceVersion, Object: "openshift-adp/OADP Operator", Type: ChangeModified, Field: "spec.version", Before: "1.4.4", After: "1.5.0"},
+		{Kind: KindDataProtectionApplication, Object: "openshift-adp/dpa", Type: ChangeModified, Field: "spec.configuration.velero.defaultPlugins", Before: `["aws","openshift"]`, After: `["aws","openshift","kubevirt"]`},
+		{Kind: KindImage, Object: "openshift-adp/velero", Type: ChangeModified, Field: "initContainers.velero-plugin-for-aws", Before: "velero-plugin-for-aws:v1.4", After: "velero-plugin-for-aws:v1.5"},
+		{Kind: KindBackupStorageLocation, Object: "openshift-adp/dpa-1", Type: ChangeModified, Field: "spec.objectStorage.bucket", Before: "bucket", After: "other-bucket"},
+		{Kind: KindBackupStorageLocation, Object: "openshift-adp/dpa-2", Type: ChangeAdded},
+		{Kind: KindStorageClass, Object: "gp2-csi", Type: ChangeModified, Field: "metadata.annotations." + defaultStorageClassAnnotation, Before: "true"},
+		{Kind: KindStorageClass, Object: "gp3-csi", Type: ChangeModified, Field: "metadata.annotations." + defaultStorageClassAnnotation, After: "true"},
+		{Kind: KindVolumeSnapshotClass, Object: "csi-snapclass", Type: ChangeRemoved},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("got %d changes %v, want %d", len(diff.Changes), diff.Changes, len(want))
+	}
+	for i, change := range diff.Changes {
+		if change != want[i] {
+			t.Errorf("change %d is %v, want %v", i, change, want[i])
+		}
+	}
+
+	if diff.BackupSuccessRate.Before.Finished != 4 || *diff.BackupSuccessRate.Before.Rate != 75 {
+		t.Errorf("before success rate is %v, want 3 of 4 finished Backups", diff.BackupSuccessRate.Before)
+	}
+	if diff.BackupSuccessRate.After.Total != 3 || *diff.BackupSuccessRate.After.Rate != 50 {
+		t.Errorf("after success rate is %v, want 1 of 2 finished Backups", diff.BackupSuccessRate.After)
+	}
+	if diff.BackupSuccessRate.Change == nil || *diff.BackupSuccessRate.Change != -25 {
+		t.Errorf("success rate change is %v, want -25", diff.BackupSuccessRate.Change)
+	}
+
+	markdown := diff.Markdown()
+	for _, text := range []string{
+		"| success rate | 75.0% | 50.0% |",
+		"changed by -25.0 percentage points",
+		"| openshift-adp/dpa-2 | Added |  |  |  |",
+		"## VolumeSnapshotLocations (VSLs)\n\nNo changes\n",
+	} {
+		if !strings.Contains(markdown, text) {
+			t.Errorf("Markdown diff does not contain %q:\n%s", text, markdown)
+		}
+	}
+	_, err := json.Marshal(diff)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompareWithoutFinishedBackups(t *testing.T) {
+	before := newResources("1.5.0", nil, "velero-plugin-for-aws:v1.5", "bucket", "gp3-csi")
+	after := newResources("1.5.0", nil, "velero-plugin-for-aws:v1.5", "bucket", "gp3-csi", velerov1.BackupPhaseCompleted)
+
+	diff := Compare(before, after)
+	if len(diff.Changes) != 0 {
+		t.Errorf("got changes %v, want none", diff.Changes)
+	}
+	if diff.BackupSuccessRate.Before.Rate != nil || diff.BackupSuccessRate.Change != nil {
+		t.Errorf("got success rate %v, want not set without finished Backups", diff.BackupSuccessRate)
+	}
+	if !strings.Contains(diff.Markdown(), "success rates can not be compared") {
+		t.Errorf("Markdown diff does not explain missing success rate change:\n%s", diff.Markdown())
+	}
+}
+
+func TestMarkdownValue(t *testing.T) {
+	if got := markdownValue("a|b\nc"); got != "a\\|b<br>c" {
+		t.Errorf("got %q", got)
+	}
+	if got := markdownValue(strings.Repeat("x", 200)); !strings.HasSuffix(got, "… (200 characters)") {
+		t.Errorf("got %q, want truncated value", got)
+	}
+}
@@ -0,0 +1,56 @@
+package gather
+
+import (
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Filter removes from the list the objects for which keep returns false
+func Filter(clusterResource client.ObjectList, keep func(client.Object) bool) error {
+	items, err := meta.ExtractList(clusterResource)
+	if err != nil {
+		return err
+	}
+	kept := []runtime.Object{}
+	for _, item := range items {
+		object, ok := item.(client.Object)
+		if !ok || keep(object) {
+			kept = append(kept, item)
+		}
+	}
+	return meta.SetList(clusterResource, kept)
+}
+
+// CreatedSince removes from the list the objects created before since
+func CreatedSince(clusterResource client.ObjectList, since time.Time) error {
+	return Filter(clusterResource, func(object client.Object) bool {
+		return !object.GetCreationTimestamp().Time.Before(since)
+	})
+}
+
+// NotCompleted removes from the list the Backups, Restores, DataUploads, DataDownloads, PodVolumeBackups and
+// PodVolumeRestores that completed successfully
+func NotCompleted(clusterResource client.ObjectList) error {
+	return Filter(clusterResource, func(object client.Object) bool {
+		switch typed := object.(type) {
+		case *velerov1.Backup:
+			return typed.Status.Phase != velerov1.BackupPhaseCompleted
+		case *velerov1.Restore:
+			return typed.Status.Phase != velerov1.RestorePhaseCompleted
+		case *velerov2alpha1.DataUpload:
+			return typed.Status.Phase != velerov2alpha1.DataUploadPhaseCompleted
+		case *velerov2alpha1.DataDownload:
+			return typed.Status.Phase != velerov2alpha1.DataDownloadPhaseCompleted
+		case *velerov1.PodVolumeBackup:
+			return typed.Status.Phase != velerov1.PodVolumeBackupPhaseCompleted
+		case *velerov1.PodVolumeRestore:
+			return typed.Status.Phase != velerov1.PodVolumeRestorePhaseCompleted
+		}
+		return true
+	})
+}
@@ -0,0 +1,82 @@
+package gather
+
+import (
+	"testing"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreatedSince(t *testing.T) {
+	since := time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)
+	backups := &velerov1.BackupList{Items: []velerov1.Backup{
+		{ObjectMeta: metav1.ObjectMeta{Name: "before", CreationTimestamp: metav1.NewTime(since.Add(-time.Second))}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "at", CreationTimestamp: metav1.NewTime(since)}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "after", CreationTimestamp: metav1.NewTime(since.Add(time.Hour))}},
+	}}
+
+	err := CreatedSince(backups, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups.Items) != 2 || backups.Items[0].Name != "at" || backups.Items[1].Name != "after" {
+		t.Errorf("got Backups %v, want at and after", backups.Items)
+	}
+
+	err = CreatedSince(backups, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups.Items) != 2 {
+		t.Errorf("got %d Backups, want all kept with zero time", len(backups.Items))
+	}
+}
+
+func TestNotCompleted(t *testing.T) {
+	backups := &velerov1.BackupList{Items: []velerov1.Backup{
+		{ObjectMeta: metav1.ObjectMeta{Name: "completed"}, Status: velerov1.BackupStatus{Phase: velerov1.BackupPhaseCompleted}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "partially-failed"}, Status: velerov1.BackupStatus{Phase: velerov1.BackupPhasePartiallyFailed}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "in-progress"}, Status: velerov1.BackupStatus{Phase: velerov1.BackupPhaseInProgress}},
+	}}
+	restores := &velerov1.RestoreList{Items: []velerov1.Restore{
+		{ObjectMeta: metav1.ObjectMeta{Name: "completed"}, Status: velerov1.RestoreStatus{Phase: velerov1.RestorePhaseCompleted}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "failed"}, Status: velerov1.RestoreStatus{Phase: velerov1.RestorePhaseFailed}},
+	}}
+	dataUploads := &velerov2alpha1.DataUploadList{Items: []velerov2alpha1.DataUpload{
+		{ObjectMeta: metav1.ObjectMeta{Name: "completed"}, Status: velerov2alpha1.DataUploadStatus{Phase: velerov2alpha1.DataUploadPhaseCompleted}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "canceled"}, Status: velerov2alpha1.DataUploadStatus{Phase: velerov2alpha1.DataUploadPhaseCanceled}},
+	}}
+	podVolumeRestores := &velerov1.PodVolumeRestoreList{Items: []velerov1.PodVolumeRestore{
+		{ObjectMeta: metav1.ObjectMeta{Name: "completed"}, Status: velerov1.PodVolumeRestoreStatus{Phase: velerov1.PodVolumeRestorePhaseCompleted}},
+	}}
+	// other resources have no completed phase and are kept
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "succeeded"}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	}}
+
+	for _, err := range []error{
+		NotCompleted(backups), NotCompleted(restores), NotCompleted(dataUploads), NotCompleted(podVolumeRestores), NotCompleted(pods),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(backups.Items) != 2 || backups.Items[0].Name != "partially-failed" || backups.Items[1].Name != "in-progress" {
+		t.Errorf("got Backups %v, want partially-failed and in-progress", backups.Items)
+	}
+	if len(restores.Items) != 1 || restores.Items[0].Name != "failed" {
+		t.Errorf("got Restores %v, want failed", restores.Items)
+	}
+	if len(dataUploads.Items) != 1 || dataUploads.Items[0].Name != "canceled" {
+		t.Errorf("got DataUploads %v, want canceled", dataUploads.Items)
+	}
+	if len(podVolumeRestores.Items) != 0 {
+		t.Errorf("got PodVolumeRestores %v, want none", podVolumeRestores.Items)
+	}
+	if len(pods.Items) != 1 {
+		t.Errorf("got Pods %v, want succeeded Pod kept", pods.Items)
+	}
+}
@@ -2,10 +2,35 @@ package gather
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 func AllResources(clusterClient client.Client, clusterResource client.ObjectList) error {
 	return clusterClient.List(context.Background(), clusterResource)
 }
+
+// AllResourcesTask lists all objects of a resource in a gather section. Objects are listed into a copy of
+// clusterResource, so it stays empty if the section times out before the list request finishes.
+func AllResourcesTask(clusterClient client.Client, clusterResource client.ObjectList) Task {
+	name := fmt.Sprintf("%T", clusterResource)
+	if objectGVK, err := apiutil.GVKForObject(clusterResource, clusterClient.Scheme()); err == nil {
+		name = objectGVK.Kind
+	}
+	return Task{
+		Name: name,
+		Run: func(ctx context.Context) (func(), error) {
+			list := clusterResource.DeepCopyObject().(client.ObjectList)
+			err := clusterClient.List(ctx, list)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				reflect.ValueOf(clusterResource).Elem().Set(reflect.ValueOf(list).Elem())
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,317 @@
+package gather
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	repoconfig "github.com/vmware-tanzu/velero/pkg/repository/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	kopiaContainer       = "kopia"
+	kopiaCredentialsPath = "/credentials"
+	kopiaPodPollInterval = 2 * time.Second
+	// kopiaNotFoundExitCode is the exit code of kopiaScript when the image has no kopia CLI
+	kopiaNotFoundExitCode = 127
+
+	// velero repository password secret, https://github.com/vmware-tanzu/velero/blob/main/pkg/repository/keys/keys.go
+	repositoryCredentialsSecret = "velero-repo-credentials"
+	repositoryCredentialsKey    = "repository-password"
+)
+
+// defaultCredentialsSecrets are the secrets used by BackupStorageLocations without spec.credential, like in
+// https://github.com/openshift/oadp-operator/blob/master/pkg/credentials/credentials.go
+var defaultCredentialsSecrets = map[repoconfig.BackendType]string{
+	repoconfig.AWSBackend:   "cloud-credentials",
+	repoconfig.AzureBackend: "cloud-credentials-azure",
+	repoconfig.GCPBackend:   "cloud-credentials-gcp",
+}
+
+// kopiaScript connects read-only to the repository, with the connect arguments passed to the script, and runs the
+// inspection commands, each one with a timeout. Credentials are read from the mounted BackupStorageLocation secret and
+// passed through kopia environment variables, so they are not printed.
+const kopiaScript = `
+# velero images embed kopia as a library, without the kopia CLI
+if ! command -v kopia >/dev/null 2>&1; then
+  echo "### kopia CLI not found in the image, set an image with the kopia CLI with --kopia-image"
+  exit 127
+fi
+# value prints the value of a key of the credentials file, in an ini section, or without sections if the section is empty
+value() {
+  awk -v section="[$1]" -v key="$2" '
+    /^\[/ { found = ($0 == section); next }
+    section == "[]" || found {
+      name = substr($0, 1, index($0, "=") - 1); gsub(/[ \t]/, "", name)
+      if (name == key) { value = substr($0, index($0, "=") + 1); gsub(/^[ \t]+|[ \t\r]+$/, "", value); print value; exit }
+    }' ` + kopiaCredentialsPath + `/cloud
+}
+if [ -f ` + kopiaCredentialsPath + `/cloud ]; then
+  export AWS_ACCESS_KEY_ID="$(value "$AWS_PROFILE" aws_access_key_id)"
+  export AWS_SECRET_ACCESS_KEY="$(value "$AWS_PROFILE" aws_secret_access_key)"
+  export AWS_SESSION_TOKEN="$(value "$AWS_PROFILE" aws_session_token)"
+  export AZURE_STORAGE_KEY="$(value "" AZURE_STORAGE_ACCOUNT_ACCESS_KEY)"
+fi
+run() {
+  echo "### kopia $*"
+  timeout "$KOPIA_COMMAND_TIMEOUT" kopia "$@" 2>&1
+  echo "### exit code $?"
+  echo
+}
+echo "### kopia repository connect $1 --readonly"
+timeout "$KOPIA_COMMAND_TIMEOUT" kopia repository connect "$@" --readonly 2>&1
+echo "### exit code $?"
+echo
+run repository status
+run maintenance info
+run content stats
+`
+
+// KopiaInspection is the output of the read-only kopia commands run for a BackupRepository
+type KopiaInspection struct {
+	Namespace string
+	Name      string
+	Output    string
+	Err       error
+}
+
+// File is the path the kopia commands output is written to
+func (k KopiaInspection) File() string {
+	return fmt.Sprintf("namespaces/%s/velero.io/backuprepositories/kopia-%s.txt", k.Namespace, k.Name)
+}
+
+// KopiaTasks run, for each kopia BackupRepository, a short-lived pod with image, which must have the kopia CLI, and
+// the BackupStorageLocation credentials, running read-only kopia `repository status`, `maintenance info` and
+// `content stats` commands with commandTimeout each. Each KopiaInspection is appended to inspections.
+func KopiaTasks(
+	clusterClient client.Client,
+	clientset kubernetes.Interface,
+	backupRepositories []velerov1.BackupRepository,
+	backupStorageLocations []velerov1.BackupStorageLocation,
+	image string,
+	commandTimeout time.Duration,
+	inspections *[]KopiaInspection,
+) []Task {
+	tasks := []Task{}
+	for _, backupRepository := range backupRepositories {
+		if backupRepository.Spec.RepositoryType != velerov1.BackupRepositoryTypeKopia {
+			continue
+		}
+		tasks = append(tasks, Task{
+			Name: backupRepository.Namespace + "/" + backupRepository.Name,
+			Run: func(ctx context.Context) (func(), error) {
+				inspection := KopiaInspection{Namespace: backupRepository.Namespace, Name: backupRepository.Name}
+				inspection.Output, inspection.Err = inspectKopiaRepository(ctx, clusterClient, clientset, &backupRepository, backupStorageLocations, image, commandTimeout)
+				return func() {
+					*inspections = append(*inspections, inspection)
+				}, inspection.Err
+			},
+		})
+	}
+	return tasks
+}
+
+func inspectKopiaRepository(
+	ctx context.Context,
+	clusterClient client.Client,
+	clientset kubernetes.Interface,
+	backupRepository *velerov1.BackupRepository,
+	backupStorageLocations []velerov1.BackupStorageLocation,
+	image string,
+	commandTimeout time.Duration,
+) (string, error) {
+	var backupStorageLocation *velerov1.BackupStorageLocation
+	for index := range backupStorageLocations {
+		if backupStorageLocations[index].Namespace == backupRepository.Namespace && backupStorageLocations[index].Name == backupRepository.Spec.BackupStorageLocation {
+			backupStorageLocation = &backupStorageLocations[index]
+		}
+	}
+	if backupStorageLocation == nil {
+		return "", fmt.Errorf("BackupStorageLocation %s/%s of BackupRepository %s not found", backupRepository.Namespace, backupRepository.Spec.BackupStorageLocation, backupRepository.Name)
+	}
+	pod, err := kopiaPod(backupRepository, backupStorageLocation, image, commandTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	err = clusterClient.Create(ctx, pod)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		deleteContext, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := clusterClient.Delete(deleteContext, pod, client.GracePeriodSeconds(0))
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	err = wait.PollUntilContextCancel(ctx, kopiaPodPollInterval, true, func(ctx context.Context) (bool, error) {
+		err := clusterClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+		if err != nil {
+			return false, nil
+		}
+		return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("kopia pod %s/%s did not finish, phase %s: %w", pod.Namespace, pod.Name, pod.Status.Phase, err)
+	}
+	logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: kopiaContainer}).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.ExitCode == kopiaNotFoundExitCode {
+				return string(logs), fmt.Errorf("image %s of kopia pod %s/%s has no kopia CLI, set an image with the kopia CLI with --kopia-image", image, pod.Namespace, pod.Name)
+			}
+		}
+		return string(logs), fmt.Errorf("kopia pod %s/%s failed: %s", pod.Namespace, pod.Name, pod.Status.Message)
+	}
+	return string(logs), nil
+}
+
+// kopiaConnectArguments returns the `kopia repository connect` arguments of the repository of a volume namespace, the
+// same way velero computes its storage options
+func kopiaConnectArguments(backupStorageLocation *velerov1.BackupStorageLocation, volumeNamespace string) ([]string, error) {
+	config := backupStorageLocation.Spec.Config
+	if config == nil {
+		config = map[string]string{}
+	}
+	bucket := strings.Trim(config["bucket"], "/")
+	prefix := strings.Trim(config["prefix"], "/")
+	if backupStorageLocation.Spec.ObjectStorage != nil {
+		bucket = strings.Trim(backupStorageLocation.Spec.ObjectStorage.Bucket, "/")
+		prefix = strings.Trim(backupStorageLocation.Spec.ObjectStorage.Prefix, "/")
+	}
+	prefix = path.Join(prefix, string(velerov1.BackupRepositoryTypeKopia), volumeNamespace) + "/"
+
+	switch repoconfig.GetBackendType(backupStorageLocation.Spec.Provider, config) {
+	case repoconfig.AWSBackend:
+		arguments := []string{"s3", "--bucket", bucket, "--prefix", prefix}
+		// without region, velero looks up the bucket region, kopia finds it from the global endpoint
+		endpoint := "s3.amazonaws.com"
+		region := strings.Trim(config["region"], "/")
+		if len(region) != 0 {
+			endpoint = fmt.Sprintf("s3-%s.amazonaws.com", region)
+			arguments = append(arguments, "--region", region)
+		}
+		if len(config["s3Url"]) != 0 {
+			s3URL, err := url.Parse(config["s3Url"])
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse s3Url %s: %w", config["s3Url"], err)
+			}
+			if s3URL.Path != "" && s3URL.Path != "/" {
+				return nil, fmt.Errorf("path is not expected in s3Url %s", config["s3Url"])
+			}
+			endpoint = strings.Trim(s3URL.Host, "/")
+			if s3URL.Scheme == "http" {
+				arguments = append(arguments, "--disable-tls")
+			}
+		}
+		arguments = append(arguments, "--endpoint", endpoint)
+		if insecure, _ := strconv.ParseBool(config["insecureSkipTLSVerify"]); insecure {
+			arguments = append(arguments, "--disable-tls-verification")
+		}
+		if backupStorageLocation.Spec.ObjectStorage != nil && len(backupStorageLocation.Spec.ObjectStorage.CACert) != 0 {
+			arguments = append(arguments, "--root-ca-pem-base64", base64.StdEncoding.EncodeToString(backupStorageLocation.Spec.ObjectStorage.CACert))
+		}
+		return arguments, nil
+	case repoconfig.AzureBackend:
+		return []string{"azure", "--container", bucket, "--prefix", prefix, "--storage-account", config["storageAccount"]}, nil
+	case repoconfig.GCPBackend:
+		return []string{"gcs", "--bucket", bucket, "--prefix", prefix, "--credentials-file", kopiaCredentialsPath + "/cloud"}, nil
+	}
+	return nil, fmt.Errorf("kopia inspection of BackupStorageLocation provider %s is not supported", backupStorageLocation.Spec.Provider)
+}
+
+func kopiaPod(backupRepository *velerov1.BackupRepository, backupStorageLocation *velerov1.BackupStorageLocation, image string, commandTimeout time.Duration) (*corev1.Pod, error) {
+	arguments, err := kopiaConnectArguments(backupStorageLocation, backupRepository.Spec.VolumeNamespace)
+	if err != nil {
+		return nil, err
+	}
+	profile := backupStorageLocation.Spec.Config["profile"]
+	if len(profile) == 0 {
+		profile = "default"
+	}
+	credentials := backupStorageLocation.Spec.Credential
+	if credentials == nil {
+		backendType := repoconfig.GetBackendType(backupStorageLocation.Spec.Provider, backupStorageLocation.Spec.Config)
+		credentials = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: defaultCredentialsSecrets[backendType]},
+			Key:                  "cloud",
+		}
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "oadp-must-gather-kopia-",
+			Namespace:    backupRepository.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "oadp-must-gather-kopia",
+				"app.kubernetes.io/managed-by": "oadp-must-gather",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			// connect and the 3 inspection commands, with time to pull the image
+			ActiveDeadlineSeconds: ptr.To(int64(5*commandTimeout.Seconds()) + 120),
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   ptr.To(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    kopiaContainer,
+					Image:   image,
+					Command: append([]string{"/bin/sh", "-c", kopiaScript, "kopia-inspection"}, arguments...),
+					Env: []corev1.EnvVar{
+						{Name: "HOME", Value: "/tmp"},
+						{Name: "KOPIA_CONFIG_PATH", Value: "/tmp/kopia/repository.config"},
+						{Name: "KOPIA_CACHE_DIRECTORY", Value: "/tmp/kopia/cache"},
+						{Name: "KOPIA_LOG_DIR", Value: "/tmp/kopia/logs"},
+						{Name: "KOPIA_CHECK_FOR_UPDATES", Value: "false"},
+						{Name: "KOPIA_COMMAND_TIMEOUT", Value: strconv.Itoa(int(commandTimeout.Seconds()))},
+						{Name: "AWS_PROFILE", Value: profile},
+						{
+							Name: "KOPIA_PASSWORD",
+							ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: repositoryCredentialsSecret},
+								Key:                  repositoryCredentialsKey,
+							}},
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "tmp", MountPath: "/tmp"},
+						{Name: "credentials", MountPath: kopiaCredentialsPath, ReadOnly: true},
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: ptr.To(false),
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "credentials", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+					SecretName: credentials.Name,
+					Items:      []corev1.KeyToPath{{Key: credentials.Key, Path: "cloud"}},
+					Optional:   ptr.To(true),
+				}}},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,105 @@
+package gather
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+)
+
+func TestKopiaConnectArguments(t *testing.T) {
+	objectStorage := func(bucket string, prefix string, caCert []byte) velerov1.StorageType {
+		return velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: bucket, Prefix: prefix, CACert: caCert}}
+	}
+	tests := []struct {
+		name    string
+		spec    velerov1.BackupStorageLocationSpec
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "AWS with region",
+			spec: velerov1.BackupStorageLocationSpec{
+				Provider: "aws", StorageType: objectStorage("bucket", "", nil), Config: map[string]string{"region": "us-east-2"},
+			},
+			want: []string{"s3", "--bucket", "bucket", "--prefix", "kopia/app/", "--region", "us-east-2", "--endpoint", "s3-us-east-2.amazonaws.com"},
+		},
+		{
+			name: "AWS without region",
+			spec: velerov1.BackupStorageLocationSpec{Provider: "velero.io/aws", StorageType: objectStorage("/bucket/", "/velero/", nil)},
+			want: []string{"s3", "--bucket", "bucket", "--prefix", "velero/kopia/app/", "--endpoint", "s3.amazonaws.com"},
+		},
+		{
+			name: "S3 compatible storage over HTTP",
+			spec: velerov1.BackupStorageLocationSpec{
+				Provider: "aws", StorageType: objectStorage("bucket", "cluster-1", nil),
+				Config: map[string]string{"region": "minio", "s3Url": "http://minio.example.com:9000/", "insecureSkipTLSVerify": "true"},
+			},
+			want: []string{
+				"s3", "--bucket", "bucket", "--prefix", "cluster-1/kopia/app/", "--region", "minio", "--disable-tls",
+				"--endpoint", "minio.example.com:9000", "--disable-tls-verification",
+			},
+		},
+		{
+			name: "S3 compatible storage with CA certificate",
+			spec: velerov1.BackupStorageLocationSpec{
+				Provider: "aws", StorageType: objectStorage("bucket", "", []byte("certificate")),
+				Config: map[string]string{"s3Url": "https://s3.example.com", "insecureSkipTLSVerify": "false"},
+			},
+			want: []string{
+				"s3", "--bucket", "bucket", "--prefix", "kopia/app/", "--endpoint", "s3.example.com",
+				"--root-ca-pem-base64", base64.StdEncoding.EncodeToString([]byte("certificate")),
+			},
+		},
+		{
+			name: "S3 compatible storage of a custom provider",
+			spec: velerov1.BackupStorageLocationSpec{
+				Provider: "example.com/s3", Config: map[string]string{"bucket": "bucket", "prefix": "velero", "s3Url": "https://s3.example.com"},
+			},
+			want: []string{"s3", "--bucket", "bucket", "--prefix", "velero/kopia/app/", "--endpoint", "s3.example.com"},
+		},
+		{
+			name: "s3Url with path",
+			spec: velerov1.BackupStorageLocationSpec{
+				Provider: "aws", StorageType: objectStorage("bucket", "", nil), Config: map[string]string{"s3Url": "https://s3.example.com/bucket"},
+			},
+			wantErr: "path is not expected in s3Url https://s3.example.com/bucket",
+		},
+		{
+			name: "Azure",
+			spec: velerov1.BackupStorageLocationSpec{
+				Provider: "azure", StorageType: objectStorage("container", "velero", nil),
+				Config: map[string]string{"storageAccount": "account", "resourceGroup": "group"},
+			},
+			want: []string{"azure", "--container", "container", "--prefix", "velero/kopia/app/", "--storage-account", "account"},
+		},
+		{
+			name: "GCP",
+			spec: velerov1.BackupStorageLocationSpec{Provider: "gcp", StorageType: objectStorage("bucket", "", nil)},
+			want: []string{"gcs", "--bucket", "bucket", "--prefix", "kopia/app/", "--credentials-file", kopiaCredentialsPath + "/cloud"},
+		},
+		{
+			name:    "unsupported provider",
+			spec:    velerov1.BackupStorageLocationSpec{Provider: "example.com/storage", StorageType: objectStorage("bucket", "", nil)},
+			wantErr: "kopia inspection of BackupStorageLocation provider example.com/storage is not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kopiaConnectArguments(&velerov1.BackupStorageLocation{Spec: tt.spec}, "app")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package gather
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Load fills the lists with the objects of the yaml files under a must-gather directory, like AllResources does with
+// the objects of the cluster. Files of kinds not in the scheme are ignored, and objects written in more than one file,
+// like by OADP must-gather and `oc adm inspect`, are loaded once.
+func Load(scheme *runtime.Scheme, outputPath string, clusterResources ...client.ObjectList) error {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	objectsByKind := map[schema.GroupVersionKind][]runtime.Object{}
+	loaded := map[string]bool{}
+	decode := func(content []byte) {
+		object, objectGVK, err := decoder.Decode(content, nil, nil)
+		if err != nil {
+			return
+		}
+		if meta.IsListType(object) {
+			items, err := meta.ExtractList(object)
+			if err != nil {
+				return
+			}
+			for _, item := range items {
+				if unknown, ok := item.(*runtime.Unknown); ok {
+					item, objectGVK, err = decoder.Decode(unknown.Raw, nil, nil)
+					if err != nil {
+						continue
+					}
+				} else {
+					gvk := item.GetObjectKind().GroupVersionKind()
+					objectGVK = &gvk
+				}
+				addObject(objectsByKind, loaded, *objectGVK, item)
+			}
+			return
+		}
+		addObject(objectsByKind, loaded, *objectGVK, object)
+	}
+
+	err := filepath.WalkDir(outputPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || (filepath.Ext(filePath) != ".yaml" && filepath.Ext(filePath) != ".yml") {
+			return nil
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		decode(content)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, clusterResource := range clusterResources {
+		listGVK, err := apiutil.GVKForObject(clusterResource, scheme)
+		if err != nil {
+			return err
+		}
+		itemGVK := listGVK.GroupVersion().WithKind(strings.TrimSuffix(listGVK.Kind, "List"))
+		err = meta.SetList(clusterResource, objectsByKind[itemGVK])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addObject(objectsByKind map[schema.GroupVersionKind][]runtime.Object, loaded map[string]bool, objectGVK schema.GroupVersionKind, object runtime.Object) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return
+	}
+	key := objectGVK.String() + "/" + accessor.GetNamespace() + "/" + accessor.GetName()
+	if loaded[key] {
+		return
+	}
+	loaded[key] = true
+	objectsByKind[objectGVK] = append(objectsByKind[objectGVK], object)
+}
@@ -0,0 +1,109 @@
+package gather
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+func writeYAML(t *testing.T, filePath string, obj runtime.Object) {
+	t.Helper()
+	content := &bytes.Buffer{}
+	printer := printers.YAMLPrinter{}
+	if err := printer.PrintObj(obj, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, content.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	backupGVK := velerov1.SchemeGroupVersion.WithKind("Backup")
+	newBackup := func(name string, phase velerov1.BackupPhase) *velerov1.Backup {
+		backup := &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: name},
+			Spec:       velerov1.BackupSpec{IncludedNamespaces: []string{"app"}},
+			Status:     velerov1.BackupStatus{Phase: phase, Errors: 2},
+		}
+		backup.GetObjectKind().SetGroupVersionKind(backupGVK)
+		return backup
+	}
+	outputPath := t.TempDir()
+
+	// OADP must-gather writes v1 Lists
+	mustGatherList := &corev1.List{}
+	mustGatherList.GetObjectKind().SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("List"))
+	mustGatherList.Items = []runtime.RawExtension{{Object: newBackup("backup-1", velerov1.BackupPhasePartiallyFailed)}}
+	writeYAML(t, filepath.Join(outputPath, "namespaces/openshift-adp/velero.io/backups/backups.yaml"), mustGatherList)
+
+	// oc adm inspect writes typed Lists, including the objects already written by OADP must-gather
+	inspectList := &velerov1.BackupList{Items: []velerov1.Backup{
+		*newBackup("backup-1", velerov1.BackupPhasePartiallyFailed),
+		*newBackup("backup-2", velerov1.BackupPhaseCompleted),
+	}}
+	inspectList.GetObjectKind().SetGroupVersionKind(velerov1.SchemeGroupVersion.WithKind("BackupList"))
+	writeYAML(t, filepath.Join(outputPath, "namespaces/openshift-adp/velero.io/backups.yaml"), inspectList)
+
+	// and single objects
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "velero-1", Labels: map[string]string{"deploy": "velero"}},
+		Spec:       corev1.PodSpec{NodeName: "worker-1"},
+	}
+	pod.GetObjectKind().SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+	writeYAML(t, filepath.Join(outputPath, "namespaces/openshift-adp/pods/velero-1/velero-1.yaml"), pod)
+
+	// kinds not in the scheme and other files are ignored
+	err := os.WriteFile(filepath.Join(outputPath, "namespaces/openshift-adp/routes.yaml"), []byte("apiVersion: route.openshift.io/v1\nkind: Route\nmetadata:\n  name: route\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(outputPath, "namespaces/openshift-adp/velero.io/backups/backup-1.log"), []byte("kind: Backup\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := velerov1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	backupList := &velerov1.BackupList{}
+	podList := &corev1.PodList{}
+	restoreList := &velerov1.RestoreList{}
+	if err := Load(scheme, outputPath, backupList, podList, restoreList); err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, backup := range backupList.Items {
+		names = append(names, backup.Name)
+	}
+	if !slices.Equal(names, []string{"backup-1", "backup-2"}) {
+		t.Fatalf("got Backups %v, want backup-1 and backup-2 loaded once", names)
+	}
+	backup := backupList.Items[0]
+	if backup.Namespace != "openshift-adp" || backup.Status.Phase != velerov1.BackupPhasePartiallyFailed || backup.Status.Errors != 2 ||
+		!slices.Equal(backup.Spec.IncludedNamespaces, []string{"app"}) {
+		t.Errorf("Backup %s was not loaded as written: %+v", backup.Name, backup)
+	}
+	if len(podList.Items) != 1 || podList.Items[0].Name != "velero-1" || podList.Items[0].Labels["deploy"] != "velero" || podList.Items[0].Spec.NodeName != "worker-1" {
+		t.Errorf("got Pods %+v, want velero-1 loaded as written", podList.Items)
+	}
+	if len(restoreList.Items) != 0 {
+		t.Errorf("got Restores %+v, want none", restoreList.Items)
+	}
+}
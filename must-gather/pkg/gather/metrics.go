@@ -0,0 +1,183 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// VeleroMetricsService is created by the OADP operator in the DataProtectionApplication namespace
+	VeleroMetricsService = "openshift-adp-velero-metrics-svc"
+	// metricsPort is the velero and node-agent --metrics-address port
+	metricsPort = "8085"
+)
+
+// digestMetrics are the key counters of the velero and node-agent metrics: backup and restore success and failure,
+// and data mover results. Velero exposes no data mover bytes nor repository maintenance metrics, they are derived from
+// the resources status by AddStatusCounters.
+var digestMetrics = []*regexp.Regexp{
+	regexp.MustCompile(`^velero_(backup|restore)_(attempt|success|failure|failed|partial_failure)_total$`),
+	regexp.MustCompile(`^podVolume_data_(upload|download)_(success|failure|cancel)_total$`),
+}
+
+// Metrics is the Prometheus exposition text scraped from the velero metrics Service or a node-agent Pod
+type Metrics struct {
+	Namespace string
+	Name      string
+	Text      string
+	// Counters is the digest of the key counters, summed over their labels
+	Counters map[string]float64
+	Err      error
+}
+
+// File is the path the exposition text is written to
+func (m Metrics) File() string {
+	return fmt.Sprintf("namespaces/%s/metrics/%s.txt", m.Namespace, m.Name)
+}
+
+// MetricsTasks scrape, through the API server proxy, the velero metrics Service of the namespaces and the running
+// node-agent pods in them. Each scraped Metrics is appended to metrics.
+func MetricsTasks(clientset kubernetes.Interface, namespaces []string, pods []corev1.Pod, requestTimeout time.Duration, metrics *[]Metrics) []Task {
+	tasks := []Task{}
+	scrape := func(namespace string, name string, request func(ctx context.Context) ([]byte, error)) Task {
+		return Task{
+			Name: namespace + "/" + name,
+			Run: func(ctx context.Context) (func(), error) {
+				ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+				defer cancel()
+				scraped := Metrics{Namespace: namespace, Name: name}
+				text, err := request(ctx)
+				if err == nil {
+					scraped.Text = string(text)
+					scraped.Counters, err = DigestMetrics(scraped.Text)
+				}
+				scraped.Err = err
+				return func() {
+					*metrics = append(*metrics, scraped)
+				}, err
+			},
+		}
+	}
+	for _, namespace := range namespaces {
+		tasks = append(tasks, scrape(namespace, VeleroMetricsService, func(ctx context.Context) ([]byte, error) {
+			return clientset.CoreV1().Services(namespace).ProxyGet("http", VeleroMetricsService, metricsPort, "metrics", nil).DoRaw(ctx)
+		}))
+		for _, pod := range pods {
+			if pod.Namespace != namespace || pod.Labels["name"] != "node-agent" || pod.Status.Phase != corev1.PodRunning {
+				continue
+			}
+			tasks = append(tasks, scrape(namespace, pod.Name, func(ctx context.Context) ([]byte, error) {
+				return clientset.CoreV1().Pods(namespace).ProxyGet("http", pod.Name, metricsPort, "metrics", nil).DoRaw(ctx)
+			}))
+		}
+	}
+	return tasks
+}
+
+// DigestMetrics returns the key counters of a Prometheus exposition text, summed over their labels
+func DigestMetrics(text string) (map[string]float64, error) {
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+	counters := map[string]float64{}
+	for name, family := range families {
+		if !matchesDigest(name) {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				counters[name] += metric.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				counters[name] += metric.GetGauge().GetValue()
+			case dto.MetricType_UNTYPED:
+				counters[name] += metric.GetUntyped().GetValue()
+			}
+		}
+	}
+	return counters, nil
+}
+
+func matchesDigest(name string) bool {
+	for _, expression := range digestMetrics {
+		if expression.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddStatusCounters adds to the digest of the velero metrics Service of each namespace the key counters velero does not
+// expose as metrics, derived from the status of the gathered resources: the bytes moved by DataUploads and
+// DataDownloads, and the results of the recent BackupRepository maintenances.
+func AddStatusCounters(metrics []Metrics, dataUploads []velerov2alpha1.DataUpload, dataDownloads []velerov2alpha1.DataDownload, backupRepositories []velerov1.BackupRepository) {
+	for i := range metrics {
+		scraped := &metrics[i]
+		if scraped.Name != VeleroMetricsService || scraped.Err != nil {
+			continue
+		}
+		if scraped.Counters == nil {
+			scraped.Counters = map[string]float64{}
+		}
+		for _, dataUpload := range dataUploads {
+			if dataUpload.Namespace == scraped.Namespace {
+				scraped.Counters["data_upload_bytes_done"] += float64(dataUpload.Status.Progress.BytesDone)
+			}
+		}
+		for _, dataDownload := range dataDownloads {
+			if dataDownload.Namespace == scraped.Namespace {
+				scraped.Counters["data_download_bytes_done"] += float64(dataDownload.Status.Progress.BytesDone)
+			}
+		}
+		for _, backupRepository := range backupRepositories {
+			if backupRepository.Namespace != scraped.Namespace {
+				continue
+			}
+			for _, maintenance := range backupRepository.Status.RecentMaintenance {
+				switch maintenance.Result {
+				case velerov1.BackupRepositoryMaintenanceSucceeded:
+					scraped.Counters["repo_maintenance_success_total"]++
+				case velerov1.BackupRepositoryMaintenanceFailed:
+					scraped.Counters["repo_maintenance_failure_total"]++
+				}
+			}
+		}
+	}
+}
+
+// LoadMetrics returns the Metrics written under a must-gather directory
+func LoadMetrics(outputPath string) ([]Metrics, error) {
+	files, err := filepath.Glob(filepath.Join(outputPath, "namespaces", "*", "metrics", "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	metrics := []Metrics{}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		loaded := Metrics{
+			Namespace: filepath.Base(filepath.Dir(filepath.Dir(file))),
+			Name:      strings.TrimSuffix(filepath.Base(file), ".txt"),
+			Text:      string(text),
+		}
+		loaded.Counters, loaded.Err = DigestMetrics(loaded.Text)
+		metrics = append(metrics, loaded)
+	}
+	return metrics, nil
+}
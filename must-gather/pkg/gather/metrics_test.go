@@ -0,0 +1,104 @@
+package gather
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/vmware-tanzu/velero/pkg/apis/velero/shared"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// veleroExposition is a sample of the velero server and node-agent metrics
+const veleroExposition = `# HELP velero_backup_attempt_total Total number of attempted backups
+# TYPE velero_backup_attempt_total counter
+velero_backup_attempt_total{schedule=""} 2
+velero_backup_attempt_total{schedule="daily"} 5
+# HELP velero_backup_success_total Total number of successful backups
+# TYPE velero_backup_success_total counter
+velero_backup_success_total{schedule=""} 1
+velero_backup_success_total{schedule="daily"} 4
+# HELP velero_backup_partial_failure_total Total number of partially failed backups
+# TYPE velero_backup_partial_failure_total counter
+velero_backup_partial_failure_total{schedule="daily"} 1
+# HELP velero_backup_tarball_size_bytes Size, in bytes, of a backup
+# TYPE velero_backup_tarball_size_bytes gauge
+velero_backup_tarball_size_bytes{schedule="daily"} 4096
+# HELP velero_restore_failed_total Total number of failed restores
+# TYPE velero_restore_failed_total counter
+velero_restore_failed_total{schedule=""} 1
+# HELP podVolume_data_upload_success_total Total number of successful uploaded snapshots
+# TYPE podVolume_data_upload_success_total counter
+podVolume_data_upload_success_total{node="worker-1"} 3
+podVolume_data_upload_success_total{node="worker-2"} 2
+# HELP podVolume_data_download_failure_total Total number of failed downloaded snapshots
+# TYPE podVolume_data_download_failure_total counter
+podVolume_data_download_failure_total{node="worker-1"} 1
+# HELP podVolume_pod_volume_backup_enqueue_count Total number of pod_volume_backup objects enqueued
+# TYPE podVolume_pod_volume_backup_enqueue_count counter
+podVolume_pod_volume_backup_enqueue_count{node="worker-1"} 7
+`
+
+func TestDigestMetrics(t *testing.T) {
+	counters, err := DigestMetrics(veleroExposition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"velero_backup_attempt_total":           7,
+		"velero_backup_success_total":           5,
+		"velero_backup_partial_failure_total":   1,
+		"velero_restore_failed_total":           1,
+		"podVolume_data_upload_success_total":   5,
+		"podVolume_data_download_failure_total": 1,
+	}
+	if !maps.Equal(counters, want) {
+		t.Errorf("got counters %v, want %v", counters, want)
+	}
+
+	_, err = DigestMetrics("velero_backup_attempt_total{schedule=\"daily\" 5\n")
+	if err == nil {
+		t.Errorf("invalid exposition text was digested")
+	}
+}
+
+func TestAddStatusCounters(t *testing.T) {
+	metrics := []Metrics{
+		{Namespace: "openshift-adp", Name: VeleroMetricsService, Counters: map[string]float64{"velero_backup_success_total": 5}},
+		{Namespace: "openshift-adp", Name: "node-agent-1"},
+		{Namespace: "other", Name: VeleroMetricsService},
+	}
+	dataUploads := []velerov2alpha1.DataUpload{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp"}, Status: velerov2alpha1.DataUploadStatus{Progress: shared.DataMoveOperationProgress{BytesDone: 1000}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp"}, Status: velerov2alpha1.DataUploadStatus{Progress: shared.DataMoveOperationProgress{BytesDone: 24}}},
+	}
+	dataDownloads := []velerov2alpha1.DataDownload{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "other"}, Status: velerov2alpha1.DataDownloadStatus{Progress: shared.DataMoveOperationProgress{BytesDone: 512}}},
+	}
+	backupRepositories := []velerov1.BackupRepository{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp"},
+		Status: velerov1.BackupRepositoryStatus{RecentMaintenance: []velerov1.BackupRepositoryMaintenanceStatus{
+			{Result: velerov1.BackupRepositoryMaintenanceSucceeded},
+			{Result: velerov1.BackupRepositoryMaintenanceFailed},
+			{Result: velerov1.BackupRepositoryMaintenanceSucceeded},
+		}},
+	}}
+
+	AddStatusCounters(metrics, dataUploads, dataDownloads, backupRepositories)
+	want := []map[string]float64{
+		{
+			"velero_backup_success_total":    5,
+			"data_upload_bytes_done":         1024,
+			"repo_maintenance_success_total": 2,
+			"repo_maintenance_failure_total": 1,
+		},
+		nil,
+		{"data_download_bytes_done": 512},
+	}
+	for i, scraped := range metrics {
+		if !maps.Equal(scraped.Counters, want[i]) {
+			t.Errorf("got %s/%s counters %v, want %v", scraped.Namespace, scraped.Name, scraped.Counters, want[i])
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Task is a request of a gather section. Run does the request and returns apply, which writes its result. apply is
+// not called after the section times out, so late results do not change the partial results already written.
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) (apply func(), err error)
+}
+
+// SectionResult is the outcome of a gather section
+type SectionResult struct {
+	Name    string
+	Timeout time.Duration
+	Total   int
+	// TimedOut are the names of the tasks not finished before the section timeout
+	TimedOut []string
+	Errors   []error
+}
+
+// RunSection runs the tasks of a gather section with at most workers at the same time, printing its progress. It
+// returns when all tasks are finished or when timeout is reached, whatever happens first.
+func RunSection(name string, timeout time.Duration, workers int, tasks []Task) SectionResult {
+	result := SectionResult{Name: name, Timeout: timeout, Total: len(tasks)}
+	if len(tasks) == 0 {
+		return result
+	}
+	workers = max(1, min(workers, len(tasks)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	mutex := sync.Mutex{}
+	closed := false
+	finished := make([]bool, len(tasks))
+	done := 0
+
+	queue := make(chan int)
+	go func() {
+		defer close(queue)
+		for i := range tasks {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	waitGroup := sync.WaitGroup{}
+	for range workers {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for i := range queue {
+				apply, err := tasks[i].Run(ctx)
+				mutex.Lock()
+				if !closed {
+					if apply != nil {
+						apply()
+					}
+					if err != nil {
+						result.Errors = append(result.Errors, err)
+					}
+					finished[i] = true
+					done++
+					fmt.Printf("Gathering %s: %d/%d\n", name, done, len(tasks))
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	allDone := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(allDone)
+	}()
+
+	select {
+	case <-allDone:
+	case <-ctx.Done():
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	closed = true
+	for i, isFinished := range finished {
+		if !isFinished {
+			result.TimedOut = append(result.TimedOut, tasks[i].Name)
+		}
+	}
+	if len(result.TimedOut) != 0 {
+		fmt.Printf("Gathering %s timed out after %v, %d of %d not gathered\n", name, timeout, len(result.TimedOut), len(tasks))
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunSection(t *testing.T) {
+	applied := []string{}
+	tasks := []Task{}
+	for i := range 5 {
+		name := fmt.Sprintf("task-%d", i)
+		tasks = append(tasks, Task{Name: name, Run: func(context.Context) (func(), error) {
+			if i%2 == 1 {
+				return nil, errors.New(name + " failed")
+			}
+			// apply is called with the section lock held, so it does not need its own
+			return func() { applied = append(applied, name) }, nil
+		}})
+	}
+
+	result := RunSection("test", time.Minute, 2, tasks)
+	if result.Name != "test" || result.Total != 5 || len(result.TimedOut) != 0 {
+		t.Errorf("got result %+v, want 5 tasks and none timed out", result)
+	}
+	slices.Sort(applied)
+	if !slices.Equal(applied, []string{"task-0", "task-2", "task-4"}) {
+		t.Errorf("got applied %v, want task-0, task-2 and task-4", applied)
+	}
+	errorMessages := []string{}
+	for _, err := range result.Errors {
+		errorMessages = append(errorMessages, err.Error())
+	}
+	slices.Sort(errorMessages)
+	if !slices.Equal(errorMessages, []string{"task-1 failed", "task-3 failed"}) {
+		t.Errorf("got errors %v, want task-1 and task-3 failed", errorMessages)
+	}
+}
+
+func TestRunSectionWorkers(t *testing.T) {
+	running := atomic.Int32{}
+	maxRunning := atomic.Int32{}
+	tasks := []Task{}
+	for i := range 12 {
+		tasks = append(tasks, Task{Name: fmt.Sprintf("task-%d", i), Run: func(context.Context) (func(), error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := maxRunning.Load()
+				if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil, nil
+		}})
+	}
+
+	result := RunSection("test", time.Minute, 3, tasks)
+	if len(result.TimedOut) != 0 || len(result.Errors) != 0 {
+		t.Errorf("got result %+v, want all tasks finished", result)
+	}
+	if got := maxRunning.Load(); got < 1 || got > 3 {
+		t.Errorf("got %d tasks running at the same time, want at most 3 workers", got)
+	}
+}
+
+func TestRunSectionTimeout(t *testing.T) {
+	release := make(chan struct{})
+	lateReturned := sync.WaitGroup{}
+	lateApplied := atomic.Bool{}
+	tasks := []Task{
+		{Name: "fast", Run: func(context.Context) (func(), error) {
+			return func() {}, nil
+		}},
+	}
+	for _, name := range []string{"slow-1", "slow-2"} {
+		lateReturned.Add(1)
+		tasks = append(tasks, Task{Name: name, Run: func(ctx context.Context) (func(), error) {
+			defer lateReturned.Done()
+			<-ctx.Done()
+			// the result arrives after the section returned
+			<-release
+			return func() { lateApplied.Store(true) }, errors.New(name + " failed")
+		}})
+	}
+	// never started, the section times out before a worker is free
+	tasks = append(tasks, Task{Name: "queued", Run: func(context.Context) (func(), error) {
+		return func() { lateApplied.Store(true) }, nil
+	}})
+
+	result := RunSection("test", 50*time.Millisecond, 2, tasks)
+	if !slices.Equal(result.TimedOut, []string{"slow-1", "slow-2", "queued"}) {
+		t.Errorf("got timed out %v, want slow-1, slow-2 and queued", result.TimedOut)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("got errors %v, want none", result.Errors)
+	}
+
+	close(release)
+	lateReturned.Wait()
+	// the workers apply the late results after Run returns
+	time.Sleep(50 * time.Millisecond)
+	if lateApplied.Load() {
+		t.Errorf("late result was applied after the section timed out")
+	}
+}
+
+func TestRunSectionWithoutTasks(t *testing.T) {
+	result := RunSection("test", time.Minute, 2, nil)
+	if result.Total != 0 || len(result.TimedOut) != 0 || len(result.Errors) != 0 {
+		t.Errorf("got result %+v, want empty result", result)
+	}
+}
@@ -0,0 +1,94 @@
+package gather
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/oadp-operator/must-gather/pkg/gvk"
+	"github.com/openshift/oadp-operator/must-gather/pkg/redact"
+)
+
+// VeleroPods writes the yaml and the logs of the velero pods, and of the node-agent pods running on the nodes, of the
+// namespaces. The files follow `oc adm inspect` folder structure, so `omg` can read them, and are redacted before
+// they are written. requestTimeout bounds opening each logs stream and timeout reading it.
+func VeleroPods(clusterClient client.Client, clientset kubernetes.Interface, redactor *redact.Redactor, outputPath string, namespaces []string, nodes []string, requestTimeout time.Duration, timeout time.Duration) {
+	for _, namespace := range namespaces {
+		podList := &corev1.PodList{}
+		err := clusterClient.List(context.Background(), podList, client.InNamespace(namespace), client.MatchingLabels{"component": "velero"})
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		for _, pod := range podList.Items {
+			isVelero := pod.Labels["deploy"] == "velero"
+			isRelatedNodeAgent := pod.Labels["name"] == "node-agent" && slices.Contains(nodes, pod.Spec.NodeName)
+			if !isVelero && !isRelatedNodeAgent {
+				continue
+			}
+			folder := outputPath + redactor.Path(fmt.Sprintf("namespaces/%s/pods/%s/", namespace, pod.Name))
+			err = writePod(redactor, folder, &pod)
+			if err != nil {
+				fmt.Println(err)
+			}
+			for _, container := range pod.Spec.Containers {
+				err = writePodLogs(clientset, redactor, folder, &pod, container.Name, requestTimeout, timeout)
+				if err != nil {
+					fmt.Println(err)
+				}
+			}
+		}
+	}
+}
+
+func writePod(redactor *redact.Redactor, folder string, pod *corev1.Pod) error {
+	err := os.MkdirAll(folder, 0777)
+	if err != nil {
+		return err
+	}
+	pod.GetObjectKind().SetGroupVersionKind(gvk.PodGVK)
+	printer := printers.YAMLPrinter{}
+	content := &bytes.Buffer{}
+	err = printer.PrintObj(pod, content)
+	if err != nil {
+		return err
+	}
+	redacted, err := redactor.YAML(content.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(folder+redactor.Path(pod.Name)+".yaml", redacted, 0644)
+}
+
+func writePodLogs(clientset kubernetes.Interface, redactor *redact.Redactor, folder string, pod *corev1.Pod, container string, requestTimeout time.Duration, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// the request timeout only bounds opening the stream, large logs take longer to read
+	openTimer := time.AfterFunc(requestTimeout, cancel)
+	logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container}).Stream(ctx)
+	openTimer.Stop()
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+	content, err := io.ReadAll(logs)
+	if err != nil {
+		return err
+	}
+	logsPath := path.Join(folder, container, container, "logs", "current.log")
+	err = os.MkdirAll(path.Dir(logsPath), 0777)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(logsPath, []byte(redactor.Text(string(content))), 0644)
+}
@@ -0,0 +1,203 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	"github.com/vmware-tanzu/velero/pkg/label"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Target is the Backup, Restore or Schedule gathered by a targeted must-gather, with the resources related to it
+type Target struct {
+	Backup   string
+	Restore  string
+	Schedule string
+}
+
+// TargetLists are the lists filtered to the resources related to a Target
+type TargetLists struct {
+	Backups              *velerov1.BackupList
+	Restores             *velerov1.RestoreList
+	Schedules            *velerov1.ScheduleList
+	BackupRepositories   *velerov1.BackupRepositoryList
+	DataUploads          *velerov2alpha1.DataUploadList
+	DataDownloads        *velerov2alpha1.DataDownloadList
+	PodVolumeBackups     *velerov1.PodVolumeBackupList
+	PodVolumeRestores    *velerov1.PodVolumeRestoreList
+	DeleteBackupRequests *velerov1.DeleteBackupRequestList
+}
+
+// Related are the names of the Backups, Restores and nodes related to a Target
+type Related struct {
+	Backups  []string
+	Restores []string
+	Nodes    []string
+}
+
+// IsSet returns true when one of --backup, --restore and --schedule is set
+func (t Target) IsSet() bool {
+	return len(t.Backup) != 0 || len(t.Restore) != 0 || len(t.Schedule) != 0
+}
+
+// Validate checks that at most one of --backup, --restore and --schedule is set
+func (t Target) Validate() error {
+	set := 0
+	for _, name := range []string{t.Backup, t.Restore, t.Schedule} {
+		if len(name) != 0 {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("only one of --backup, --restore and --schedule can be set")
+	}
+	return nil
+}
+
+// FilterTarget removes from the lists the resources not related to the target. The Backups of a Schedule and the
+// Backup of a Restore are related to it, and so are their DataUploads, PodVolumeBackups and BackupRepositories. An
+// error is returned when the target is not in the lists, after removing all the other resources.
+func FilterTarget(clusterClient client.Client, target Target, lists TargetLists) (Related, error) {
+	related := Related{}
+	var notFoundErr error
+	switch {
+	case len(target.Backup) != 0:
+		related.Backups = []string{target.Backup}
+		if !slices.ContainsFunc(lists.Backups.Items, func(backup velerov1.Backup) bool { return backup.Name == target.Backup }) {
+			notFoundErr = fmt.Errorf("Backup %s of --backup was not found", target.Backup)
+		}
+	case len(target.Restore) != 0:
+		if !slices.ContainsFunc(lists.Restores.Items, func(restore velerov1.Restore) bool { return restore.Name == target.Restore }) {
+			notFoundErr = fmt.Errorf("Restore %s of --restore was not found", target.Restore)
+		}
+		related.Restores = []string{target.Restore}
+		for _, restore := range lists.Restores.Items {
+			if restore.Name == target.Restore && len(restore.Spec.BackupName) != 0 {
+				related.Backups = append(related.Backups, restore.Spec.BackupName)
+			}
+		}
+	case len(target.Schedule) != 0:
+		if !slices.ContainsFunc(lists.Schedules.Items, func(schedule velerov1.Schedule) bool { return schedule.Name == target.Schedule }) {
+			notFoundErr = fmt.Errorf("Schedule %s of --schedule was not found", target.Schedule)
+		}
+		for _, backup := range lists.Backups.Items {
+			if backup.Labels[velerov1.ScheduleNameLabel] == label.GetValidName(target.Schedule) {
+				related.Backups = append(related.Backups, backup.Name)
+			}
+		}
+	}
+
+	isRelatedBackup := func(name string) bool { return slices.Contains(related.Backups, name) }
+	isRelatedBackupLabel := func(object client.Object) bool {
+		return slices.ContainsFunc(related.Backups, func(name string) bool {
+			return object.GetLabels()[velerov1.BackupNameLabel] == label.GetValidName(name)
+		})
+	}
+	isRelatedRestoreLabel := func(object client.Object) bool {
+		return slices.ContainsFunc(related.Restores, func(name string) bool {
+			return object.GetLabels()[velerov1.RestoreNameLabel] == label.GetValidName(name)
+		})
+	}
+
+	// storage locations and volume namespaces of the Backups, to find their BackupRepositories
+	repositories := map[string][]string{}
+	schedules := []string{label.GetValidName(target.Schedule)}
+	err := Filter(lists.Backups, func(object client.Object) bool {
+		backup := object.(*velerov1.Backup)
+		if !isRelatedBackup(backup.Name) {
+			return false
+		}
+		repositories[backup.Spec.StorageLocation] = []string{}
+		schedules = append(schedules, backup.Labels[velerov1.ScheduleNameLabel])
+		return true
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.Restores, func(object client.Object) bool {
+		return slices.Contains(related.Restores, object.GetName())
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.Schedules, func(object client.Object) bool {
+		return slices.Contains(schedules, label.GetValidName(object.GetName()))
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.DeleteBackupRequests, isRelatedBackupLabel)
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.PodVolumeBackups, func(object client.Object) bool {
+		if !isRelatedBackupLabel(object) {
+			return false
+		}
+		podVolumeBackup := object.(*velerov1.PodVolumeBackup)
+		related.Nodes = append(related.Nodes, podVolumeBackup.Spec.Node)
+		repositories[podVolumeBackup.Spec.BackupStorageLocation] = append(repositories[podVolumeBackup.Spec.BackupStorageLocation], podVolumeBackup.Spec.Pod.Namespace)
+		return true
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.DataUploads, func(object client.Object) bool {
+		if !isRelatedBackupLabel(object) {
+			return false
+		}
+		dataUpload := object.(*velerov2alpha1.DataUpload)
+		related.Nodes = append(related.Nodes, dataUpload.Status.Node)
+		repositories[dataUpload.Spec.BackupStorageLocation] = append(repositories[dataUpload.Spec.BackupStorageLocation], dataUpload.Spec.SourceNamespace)
+		return true
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.DataDownloads, func(object client.Object) bool {
+		if !isRelatedRestoreLabel(object) {
+			return false
+		}
+		related.Nodes = append(related.Nodes, object.(*velerov2alpha1.DataDownload).Status.Node)
+		return true
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.PodVolumeRestores, func(object client.Object) bool {
+		if !isRelatedRestoreLabel(object) {
+			return false
+		}
+		// PodVolumeRestores run on the node of the restored pod
+		podReference := object.(*velerov1.PodVolumeRestore).Spec.Pod
+		pod := &corev1.Pod{}
+		err := clusterClient.Get(context.Background(), types.NamespacedName{Namespace: podReference.Namespace, Name: podReference.Name}, pod)
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			related.Nodes = append(related.Nodes, pod.Spec.NodeName)
+		}
+		return true
+	})
+	if err != nil {
+		return related, err
+	}
+	err = Filter(lists.BackupRepositories, func(object client.Object) bool {
+		backupRepository := object.(*velerov1.BackupRepository)
+		volumeNamespaces, ok := repositories[backupRepository.Spec.BackupStorageLocation]
+		return ok && slices.Contains(volumeNamespaces, backupRepository.Spec.VolumeNamespace)
+	})
+	if err != nil {
+		return related, err
+	}
+
+	related.Nodes = slices.DeleteFunc(related.Nodes, func(node string) bool { return len(node) == 0 })
+	slices.Sort(related.Nodes)
+	related.Nodes = slices.Compact(related.Nodes)
+	return related, notFoundErr
+}
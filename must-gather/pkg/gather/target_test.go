@@ -0,0 +1,172 @@
+package gather
+
+import (
+	"slices"
+	"testing"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerov2alpha1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTargetLists() TargetLists {
+	backup := func(name string, schedule string, storageLocation string) velerov1.Backup {
+		backup := velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: name},
+			Spec:       velerov1.BackupSpec{StorageLocation: storageLocation},
+		}
+		if len(schedule) != 0 {
+			backup.Labels = map[string]string{velerov1.ScheduleNameLabel: schedule}
+		}
+		return backup
+	}
+	backupLabels := func(name string) map[string]string { return map[string]string{velerov1.BackupNameLabel: name} }
+	restoreLabels := func(name string) map[string]string { return map[string]string{velerov1.RestoreNameLabel: name} }
+	return TargetLists{
+		Backups: &velerov1.BackupList{Items: []velerov1.Backup{
+			backup("daily-1", "daily", "default"),
+			backup("daily-2", "daily", "default"),
+			backup("manual", "", "other"),
+		}},
+		Restores: &velerov1.RestoreList{Items: []velerov1.Restore{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "restore-manual"}, Spec: velerov1.RestoreSpec{BackupName: "manual"}},
+		}},
+		Schedules: &velerov1.ScheduleList{Items: []velerov1.Schedule{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "daily"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-adp", Name: "weekly"}},
+		}},
+		BackupRepositories: &velerov1.BackupRepositoryList{Items: []velerov1.BackupRepository{
+			{ObjectMeta: metav1.ObjectMeta{Name: "app-default"}, Spec: velerov1.BackupRepositorySpec{BackupStorageLocation: "default", VolumeNamespace: "app"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "app-other"}, Spec: velerov1.BackupRepositorySpec{BackupStorageLocation: "other", VolumeNamespace: "app"}},
+		}},
+		DataUploads: &velerov2alpha1.DataUploadList{Items: []velerov2alpha1.DataUpload{{
+			ObjectMeta: metav1.ObjectMeta{Name: "daily-1-upload", Labels: backupLabels("daily-1")},
+			Spec:       velerov2alpha1.DataUploadSpec{BackupStorageLocation: "default", SourceNamespace: "app"},
+			Status:     velerov2alpha1.DataUploadStatus{Node: "worker-1"},
+		}}},
+		DataDownloads: &velerov2alpha1.DataDownloadList{Items: []velerov2alpha1.DataDownload{{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore-manual-download", Labels: restoreLabels("restore-manual")},
+			Status:     velerov2alpha1.DataDownloadStatus{Node: "worker-2"},
+		}}},
+		PodVolumeBackups: &velerov1.PodVolumeBackupList{Items: []velerov1.PodVolumeBackup{{
+			ObjectMeta: metav1.ObjectMeta{Name: "manual-pvb", Labels: backupLabels("manual")},
+			Spec: velerov1.PodVolumeBackupSpec{
+				Node: "worker-3", BackupStorageLocation: "other", Pod: corev1.ObjectReference{Namespace: "app", Name: "app-1"},
+			},
+		}}},
+		PodVolumeRestores: &velerov1.PodVolumeRestoreList{Items: []velerov1.PodVolumeRestore{{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore-manual-pvr", Labels: restoreLabels("restore-manual")},
+			Spec:       velerov1.PodVolumeRestoreSpec{Pod: corev1.ObjectReference{Namespace: "app", Name: "app-1"}},
+		}}},
+		DeleteBackupRequests: &velerov1.DeleteBackupRequestList{Items: []velerov1.DeleteBackupRequest{{
+			ObjectMeta: metav1.ObjectMeta{Name: "daily-2-delete", Labels: backupLabels("daily-2")},
+		}}},
+	}
+}
+
+func objectNames(t *testing.T, list client.ObjectList) []string {
+	t.Helper()
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.(client.Object).GetName())
+	}
+	return names
+}
+
+func TestFilterTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    Target
+		want      map[string][]string
+		wantNodes []string
+		wantErr   string
+	}{
+		{
+			name:   "Backup",
+			target: Target{Backup: "daily-1"},
+			want: map[string][]string{
+				"Backups": {"daily-1"}, "Schedules": {"daily"}, "DataUploads": {"daily-1-upload"}, "BackupRepositories": {"app-default"},
+			},
+			wantNodes: []string{"worker-1"},
+		},
+		{
+			name:   "Restore with its Backup",
+			target: Target{Restore: "restore-manual"},
+			want: map[string][]string{
+				"Backups": {"manual"}, "Restores": {"restore-manual"}, "PodVolumeBackups": {"manual-pvb"}, "BackupRepositories": {"app-other"},
+				"DataDownloads": {"restore-manual-download"}, "PodVolumeRestores": {"restore-manual-pvr"},
+			},
+			wantNodes: []string{"worker-2", "worker-3", "worker-4"},
+		},
+		{
+			name:   "Schedule with its Backups",
+			target: Target{Schedule: "daily"},
+			want: map[string][]string{
+				"Backups": {"daily-1", "daily-2"}, "Schedules": {"daily"}, "DataUploads": {"daily-1-upload"}, "BackupRepositories": {"app-default"},
+				"DeleteBackupRequests": {"daily-2-delete"},
+			},
+			wantNodes: []string{"worker-1"},
+		},
+		{
+			name:    "Backup not found",
+			target:  Target{Backup: "missing"},
+			want:    map[string][]string{},
+			wantErr: "Backup missing of --backup was not found",
+		},
+		{
+			name:    "Restore not found",
+			target:  Target{Restore: "missing"},
+			want:    map[string][]string{},
+			wantErr: "Restore missing of --restore was not found",
+		},
+		{
+			name:    "Schedule not found",
+			target:  Target{Schedule: "missing"},
+			want:    map[string][]string{},
+			wantErr: "Schedule missing of --schedule was not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := corev1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			clusterClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "app-1"},
+				Spec:       corev1.PodSpec{NodeName: "worker-4"},
+			}).Build()
+			lists := newTargetLists()
+
+			related, err := FilterTarget(clusterClient, tt.target, lists)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("FilterTarget() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			for kind, list := range map[string]client.ObjectList{
+				"Backups": lists.Backups, "Restores": lists.Restores, "Schedules": lists.Schedules,
+				"BackupRepositories": lists.BackupRepositories, "DataUploads": lists.DataUploads, "DataDownloads": lists.DataDownloads,
+				"PodVolumeBackups": lists.PodVolumeBackups, "PodVolumeRestores": lists.PodVolumeRestores,
+				"DeleteBackupRequests": lists.DeleteBackupRequests,
+			} {
+				if got := objectNames(t, list); !slices.Equal(got, tt.want[kind]) {
+					t.Errorf("got %s %v, want %v", kind, got, tt.want[kind])
+				}
+			}
+			if !slices.Equal(related.Nodes, tt.wantNodes) {
+				t.Errorf("got nodes %v, want %v", related.Nodes, tt.wantNodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+const Redacted = "REDACTED"
+
+// Pattern is a regular expression replaced in all written text, like logs, describe outputs and yaml values
+type Pattern struct {
+	Regexp      string `json:"regexp"`
+	Replacement string `json:"replacement"`
+}
+
+// Rules configure what is redacted. A rule file overrides the fields it sets, the others keep their default value.
+type Rules struct {
+	// SecretData redacts the values of data and stringData of Secrets
+	SecretData bool `json:"secretData"`
+	// Keys are regular expressions of credential-like keys, whose string values are redacted. Environment variables
+	// with a name matching a key have their value redacted.
+	Keys []string `json:"keys"`
+	// Patterns are replaced in all written text
+	Patterns []Pattern `json:"patterns"`
+	// Names replaces the names of the gathered namespaces and objects by stable pseudonyms
+	Names bool `json:"names"`
+	// KeepNames are regular expressions of names not replaced by pseudonyms
+	KeepNames []string `json:"keepNames"`
+	// NameKey is the key the pseudonyms are derived from. Without it, a random key is used and the pseudonyms of two
+	// must-gathers differ, so they can not be compared. Anyone with the key can find the pseudonyms of known names.
+	NameKey string `json:"nameKey"`
+}
+
+// DefaultRules redact Secret data, credential-like keys, like in BackupStorageLocation config, and presigned URL
+// signatures
+func DefaultRules() Rules {
+	return Rules{
+		SecretData: true,
+		Keys: []string{
+			`(?i)pass(word)?`,
+			// like clientSecret and AWS_SECRET_ACCESS_KEY, not references like secretName
+			`(?i)secret(_?(access_?)?key)?$`,
+			`(?i)token`,
+			`(?i)(access|api|private|account|storage)_?key`,
+			`(?i)account_?name`,
+			`(?i)storage_?account`,
+			`(?i)tenant_?id`,
+			`(?i)subscription_?id`,
+			`(?i)client_?id`,
+			`(?i)endpoint`,
+			`(?i)s3_?url`,
+			`(?i)public_?url`,
+			`(?i)kms_?key_?id`,
+			`^kubectl\.kubernetes\.io/last-applied-configuration$`,
+		},
+		Patterns: []Pattern{
+			{
+				// AWS, GCP and Azure presigned URL signatures and credentials
+				Regexp:      `(?i)([?&](X-Amz-Signature|X-Amz-Credential|X-Amz-Security-Token|X-Goog-Signature|X-Goog-Credential|AWSAccessKeyId|Signature|sig)=)[^&\s"'<>\\]+`,
+				Replacement: "${1}" + Redacted,
+			},
+			{
+				// credentials in JSON text, like the storage config printed by `kopia repository status`
+				Regexp:      `(?i)("(accessKeyID|secretAccessKey|sessionToken|storageKey|sasToken|clientSecret|password)"\s*:\s*")[^"]*`,
+				Replacement: "${1}" + Redacted,
+			},
+		},
+		KeepNames: []string{
+			`^default$`,
+			`^velero$`,
+			`^node-agent$`,
+			`^openshift(-.*)?$`,
+			`^kube-.*$`,
+		},
+	}
+}
+
+// LoadRules returns the default rules overridden by the fields set in a yaml rule file
+func LoadRules(filePath string) (Rules, error) {
+	rules := DefaultRules()
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return rules, err
+	}
+	err = yaml.UnmarshalStrict(content, &rules)
+	return rules, err
+}
+
+// Redactor applies Rules to everything OADP must-gather writes. A nil Redactor does not redact.
+type Redactor struct {
+	rules     Rules
+	keys      []*regexp.Regexp
+	patterns  []*regexp.Regexp
+	keepNames []*regexp.Regexp
+
+	// pseudonyms are derived from the names with Rules.NameKey or a random key, so they are stable in a must-gather
+	// and can not be reversed by hashing known names without the key
+	pseudonymKey []byte
+	mutex        sync.RWMutex
+	pseudonyms   map[string]string
+}
+
+var nameToken = regexp.MustCompile(`[a-z0-9]([-.a-z0-9]*[a-z0-9])?`)
+
+func New(rules Rules) (*Redactor, error) {
+	redactor := &Redactor{
+		rules:        rules,
+		pseudonymKey: []byte(rules.NameKey),
+		pseudonyms:   map[string]string{},
+	}
+	if len(rules.NameKey) == 0 {
+		redactor.pseudonymKey = make([]byte, 32)
+		_, err := rand.Read(redactor.pseudonymKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, expressions := range []struct {
+		source []string
+		target *[]*regexp.Regexp
+	}{
+		{source: rules.Keys, target: &redactor.keys},
+		{source: rules.KeepNames, target: &redactor.keepNames},
+	} {
+		for _, expression := range expressions.source {
+			compiled, err := regexp.Compile(expression)
+			if err != nil {
+				return nil, err
+			}
+			*expressions.target = append(*expressions.target, compiled)
+		}
+	}
+	for _, pattern := range rules.Patterns {
+		compiled, err := regexp.Compile(pattern.Regexp)
+		if err != nil {
+			return nil, err
+		}
+		redactor.patterns = append(redactor.patterns, compiled)
+	}
+	return redactor, nil
+}
+
+// AddNames registers namespace and object names to be replaced by pseudonyms, when Rules.Names is set
+func (r *Redactor) AddNames(names ...string) {
+	if r == nil || !r.rules.Names {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, name := range names {
+		if len(name) == 0 || len(r.pseudonyms[name]) != 0 || matchesAny(r.keepNames, name) {
+			continue
+		}
+		hash := hmac.New(sha256.New, r.pseudonymKey)
+		hash.Write([]byte(name))
+		r.pseudonyms[name] = "redacted-" + hex.EncodeToString(hash.Sum(nil))[:10]
+	}
+}
+
+// Text redacts the patterns and the registered names of a text
+func (r *Redactor) Text(text string) string {
+	if r == nil {
+		return text
+	}
+	for i, pattern := range r.patterns {
+		text = pattern.ReplaceAllString(text, r.rules.Patterns[i].Replacement)
+	}
+	return r.names(text)
+}
+
+// Path redacts the registered names of a file path
+func (r *Redactor) Path(filePath string) string {
+	if r == nil {
+		return filePath
+	}
+	return r.names(filePath)
+}
+
+func (r *Redactor) names(text string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.pseudonyms) == 0 {
+		return text
+	}
+	return nameToken.ReplaceAllStringFunc(text, func(token string) string {
+		if pseudonym, ok := r.pseudonyms[token]; ok {
+			return pseudonym
+		}
+		// like backup-name.log and describe-backup-name.txt
+		parts := strings.Split(token, ".")
+		for i, part := range parts {
+			parts[i] = r.suffixName(part)
+		}
+		return strings.Join(parts, ".")
+	})
+}
+
+// suffixName replaces the longest registered name ending a token after a dash
+func (r *Redactor) suffixName(token string) string {
+	for i := 0; i < len(token); i++ {
+		if i != 0 && token[i-1] != '-' {
+			continue
+		}
+		if pseudonym, ok := r.pseudonyms[token[i:]]; ok {
+			return token[:i] + pseudonym
+		}
+	}
+	return token
+}
+
+// YAML redacts Secret data, the values of credential-like keys, the patterns and the registered names of a yaml
+// document
+func (r *Redactor) YAML(content []byte) ([]byte, error) {
+	if r == nil {
+		return content, nil
+	}
+	var document interface{}
+	err := yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(r.value(document, ""))
+}
+
+func (r *Redactor) value(value interface{}, key string) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		isSecret := r.rules.SecretData && typed["kind"] == "Secret" && typed["apiVersion"] == "v1"
+		// environment variables, like AWS_SECRET_ACCESS_KEY
+		name, hasName := typed["name"].(string)
+		_, hasValue := typed["value"].(string)
+		isCredentialEnv := hasName && hasValue && matchesAny(r.keys, name)
+		for mapKey, mapValue := range typed {
+			switch {
+			case isSecret && (mapKey == "data" || mapKey == "stringData"):
+				typed[mapKey] = redactValues(mapValue)
+			case isCredentialEnv && mapKey == "value":
+				typed[mapKey] = Redacted
+			default:
+				typed[mapKey] = r.value(mapValue, mapKey)
+			}
+		}
+		return typed
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = r.value(item, key)
+		}
+		return typed
+	case string:
+		if len(typed) != 0 && matchesAny(r.keys, key) {
+			return Redacted
+		}
+		return r.Text(typed)
+	}
+	return value
+}
+
+func redactValues(value interface{}) interface{} {
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return Redacted
+	}
+	for key := range data {
+		data[key] = Redacted
+	}
+	return data
+}
+
+func matchesAny(expressions []*regexp.Regexp, text string) bool {
+	for _, expression := range expressions {
+		if expression.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyDirectory writes the redacted files of source, like the output of `oc adm inspect`, to destination
+func (r *Redactor) CopyDirectory(source string, destination string) error {
+	return filepath.WalkDir(source, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(source, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		if extension := filepath.Ext(filePath); extension == ".yaml" || extension == ".yml" {
+			redacted, err := r.YAML(content)
+			if err != nil {
+				// not a single yaml document, redact it as text
+				redacted = []byte(r.Text(string(content)))
+			}
+			content = redacted
+		} else {
+			content = []byte(r.Text(string(content)))
+		}
+		destinationPath := filepath.Join(destination, r.Path(relativePath))
+		err = os.MkdirAll(filepath.Dir(destinationPath), 0777)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(destinationPath, content, 0644)
+	})
+}
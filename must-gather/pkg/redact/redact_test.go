@@ -0,0 +1,385 @@
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newRedactor(t *testing.T, rules Rules) *Redactor {
+	t.Helper()
+	redactor, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redactor
+}
+
+func TestYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		content  string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:  "Secret data",
+			rules: DefaultRules(),
+			content: `apiVersion: v1
+kind: Secret
+metadata:
+  name: cloud-credentials
+  namespace: openshift-adp
+data:
+  cloud: W2RlZmF1bHRdCmF3c19hY2Nlc3Nfa2V5X2lkPUFLSUEK
+stringData:
+  password: hunter2
+`,
+			contains: []string{"cloud: " + Redacted, "password: " + Redacted, "name: cloud-credentials"},
+			excludes: []string{"W2RlZmF1bHRd", "hunter2"},
+		},
+		{
+			name:  "Secret data in a List",
+			rules: DefaultRules(),
+			content: `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: cloud-credentials
+  data:
+    cloud: W2RlZmF1bHRdCmF3c19hY2Nlc3Nfa2V5X2lkPUFLSUEK
+`,
+			contains: []string{"cloud: " + Redacted},
+			excludes: []string{"W2RlZmF1bHRd"},
+		},
+		{
+			name:  "Secret data not redacted",
+			rules: Rules{SecretData: false},
+			content: `apiVersion: v1
+kind: Secret
+data:
+  cloud: W2RlZmF1bHRd
+`,
+			contains: []string{"cloud: W2RlZmF1bHRd"},
+		},
+		{
+			name:  "credential-like config keys",
+			rules: DefaultRules(),
+			content: `apiVersion: oadp.openshift.io/v1alpha1
+kind: DataProtectionApplication
+metadata:
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"spec":{"backupLocations":[{"velero":{"config":{"storageAccount":"account"}}}]}}'
+  name: dpa
+spec:
+  backupLocations:
+  - velero:
+      config:
+        region: us-east-1
+        resourceGroup: group
+        s3Url: https://s3.example.com
+        storageAccount: account
+        storageAccountKeyEnvVar: AZURE_STORAGE_ACCOUNT_ACCESS_KEY
+        subscriptionId: 00000000-0000-0000-0000-000000000000
+      credential:
+        key: cloud
+        name: cloud-credentials
+      provider: aws
+`,
+			contains: []string{
+				"region: us-east-1",
+				"resourceGroup: group",
+				"s3Url: " + Redacted,
+				"storageAccount: " + Redacted,
+				"subscriptionId: " + Redacted,
+				"key: cloud",
+				"name: cloud-credentials",
+				"kubectl.kubernetes.io/last-applied-configuration: " + Redacted,
+			},
+			excludes: []string{"s3.example.com", "00000000-0000", "account\n"},
+		},
+		{
+			name:  "credential-like environment variables",
+			rules: DefaultRules(),
+			content: `apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - env:
+    - name: AWS_SECRET_ACCESS_KEY
+      value: wJalrXUtnFEMI
+    - name: VELERO_NAMESPACE
+      value: openshift-adp
+    name: velero
+`,
+			contains: []string{"value: " + Redacted, "value: openshift-adp"},
+			excludes: []string{"wJalrXUtnFEMI"},
+		},
+		{
+			name:  "secret values and secret references",
+			rules: DefaultRules(),
+			content: `apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - env:
+    - name: AZURE_CLIENT_SECRET
+      value: azure-secret-value
+    name: velero
+  volumes:
+  - name: cloud-credentials
+    secret:
+      defaultMode: 420
+      secretName: cloud-credentials
+stringData:
+  clientSecret: client-secret-value
+`,
+			contains: []string{"secretName: cloud-credentials", "clientSecret: " + Redacted, "value: " + Redacted},
+			excludes: []string{"azure-secret-value", "client-secret-value"},
+		},
+		{
+			name:  "presigned URL in a value",
+			rules: DefaultRules(),
+			content: `apiVersion: velero.io/v1
+kind: DownloadRequest
+status:
+  downloadURL: https://bucket.s3.amazonaws.com/backups/backup.tar.gz?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIA%2F20250505&X-Amz-Signature=abcdef0123
+`,
+			contains: []string{"X-Amz-Algorithm=AWS4-HMAC-SHA256", "X-Amz-Credential=" + Redacted, "X-Amz-Signature=" + Redacted},
+			excludes: []string{"AKIA", "abcdef0123"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			redacted, err := newRedactor(t, test.rules).YAML([]byte(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, text := range test.contains {
+				if !strings.Contains(string(redacted), text) {
+					t.Errorf("redacted yaml does not contain %q:\n%s", text, redacted)
+				}
+			}
+			for _, text := range test.excludes {
+				if strings.Contains(string(redacted), text) {
+					t.Errorf("redacted yaml contains %q:\n%s", text, redacted)
+				}
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	redactor := newRedactor(t, DefaultRules())
+	logs := `level=info msg="download URL https://storage.googleapis.com/bucket/backup.log?X-Goog-Algorithm=GOOG4-RSA-SHA256&X-Goog-Signature=0a1b2c3d"
+level=info msg="download URL https://account.blob.core.windows.net/container/backup.log?sv=2021-08-06&se=2025-05-05&sig=abc%2Bdef%3D"
+Storage config:      {
+                       "bucket": "bucket",
+                       "accessKeyID": "AKIAEXAMPLE",
+                       "secretAccessKey": "****************"
+                     }`
+	redacted := redactor.Text(logs)
+	for _, text := range []string{"0a1b2c3d", "abc%2Bdef%3D", "AKIAEXAMPLE"} {
+		if strings.Contains(redacted, text) {
+			t.Errorf("redacted text contains %q:\n%s", text, redacted)
+		}
+	}
+	for _, text := range []string{"X-Goog-Signature=" + Redacted, "sig=" + Redacted, "sv=2021-08-06", `"accessKeyID": "` + Redacted, `"bucket": "bucket"`} {
+		if !strings.Contains(redacted, text) {
+			t.Errorf("redacted text does not contain %q:\n%s", text, redacted)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	rules := DefaultRules()
+	rules.Names = true
+	redactor := newRedactor(t, rules)
+	redactor.AddNames("my-app", "backup-1", "openshift-adp", "default")
+
+	pseudonym := redactor.Text("my-app")
+	if pseudonym == "my-app" || !strings.HasPrefix(pseudonym, "redacted-") {
+		t.Fatalf("name my-app was not replaced by a pseudonym: %s", pseudonym)
+	}
+	if redactor.Text("my-app") != pseudonym {
+		t.Errorf("pseudonym of my-app is not stable")
+	}
+	if redactor.Text("backup-1") == pseudonym {
+		t.Errorf("names have the same pseudonym")
+	}
+
+	text := redactor.Text(`Backup openshift-adp/backup-1 of namespace my-app to default, my-application not gathered`)
+	for _, name := range []string{"backup-1", " my-app ", "redacted-"} {
+		if name == "redacted-" {
+			if !strings.Contains(text, name) {
+				t.Errorf("text has no pseudonym: %s", text)
+			}
+			continue
+		}
+		if strings.Contains(text, name) {
+			t.Errorf("text contains %q: %s", name, text)
+		}
+	}
+	for _, name := range []string{"openshift-adp/", " default,", "my-application"} {
+		if !strings.Contains(text, name) {
+			t.Errorf("text does not contain %q: %s", name, text)
+		}
+	}
+
+	path := redactor.Path("namespaces/my-app/velero.io/backups/describe-backup-1.txt")
+	if strings.Contains(path, "my-app") || strings.Contains(path, "backup-1") || !strings.HasSuffix(path, ".txt") {
+		t.Errorf("path names were not replaced: %s", path)
+	}
+
+	redacted, err := redactor.YAML([]byte(`apiVersion: velero.io/v1
+kind: Backup
+metadata:
+  labels:
+    velero.io/storage-location: default
+  name: backup-1
+  namespace: openshift-adp
+spec:
+  includedNamespaces:
+  - my-app
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(redacted), "my-app") || strings.Contains(string(redacted), "backup-1") {
+		t.Errorf("yaml names were not replaced:\n%s", redacted)
+	}
+	if !strings.Contains(string(redacted), "namespace: openshift-adp") || !strings.Contains(string(redacted), "apiVersion: velero.io/v1") {
+		t.Errorf("yaml kept names were replaced:\n%s", redacted)
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	rules := DefaultRules()
+	rules.Names = true
+	pseudonym := func(rules Rules) string {
+		redactor := newRedactor(t, rules)
+		redactor.AddNames("my-app")
+		return redactor.Text("my-app")
+	}
+
+	if pseudonym(rules) == pseudonym(rules) {
+		t.Errorf("pseudonyms of random keys are the same")
+	}
+	rules.NameKey = "key"
+	if pseudonym(rules) != pseudonym(rules) {
+		t.Errorf("pseudonyms of the same key differ")
+	}
+	other := rules
+	other.NameKey = "other-key"
+	if pseudonym(rules) == pseudonym(other) {
+		t.Errorf("pseudonyms of different keys are the same")
+	}
+}
+
+func TestNamesNotRedacted(t *testing.T) {
+	redactor := newRedactor(t, DefaultRules())
+	redactor.AddNames("my-app")
+	if text := redactor.Text("namespace my-app"); text != "namespace my-app" {
+		t.Errorf("names were replaced without Names rule: %s", text)
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var redactor *Redactor
+	redactor.AddNames("my-app")
+	if redactor.Text("sig=abc") != "sig=abc" || redactor.Path("my-app") != "my-app" {
+		t.Errorf("nil Redactor redacted")
+	}
+	content, err := redactor.YAML([]byte("password: hunter2\n"))
+	if err != nil || string(content) != "password: hunter2\n" {
+		t.Errorf("nil Redactor redacted yaml: %s %v", content, err)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	directory := t.TempDir()
+	rulesPath := filepath.Join(directory, "rules.yaml")
+	err := os.WriteFile(rulesPath, []byte(`keys:
+- (?i)region
+patterns:
+- regexp: 'ACCOUNT-[0-9]+'
+  replacement: ACCOUNT-REDACTED
+names: true
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadRules(rulesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rules.SecretData || !rules.Names || len(rules.Keys) != 1 || len(rules.KeepNames) == 0 {
+		t.Errorf("rules were not merged with the default rules: %+v", rules)
+	}
+	redactor := newRedactor(t, rules)
+	redacted, err := redactor.YAML([]byte("config:\n  region: us-east-1\n  s3Url: https://s3.example.com\nmessage: ACCOUNT-1234\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"region: " + Redacted, "s3Url: https://s3.example.com", "message: ACCOUNT-REDACTED"} {
+		if !strings.Contains(string(redacted), text) {
+			t.Errorf("redacted yaml does not contain %q:\n%s", text, redacted)
+		}
+	}
+
+	err = os.WriteFile(rulesPath, []byte("unknown: true\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadRules(rulesPath)
+	if err == nil {
+		t.Errorf("rule file with unknown field was loaded")
+	}
+}
+
+func TestCopyDirectory(t *testing.T) {
+	rules := DefaultRules()
+	rules.Names = true
+	redactor := newRedactor(t, rules)
+	redactor.AddNames("my-app")
+
+	source := t.TempDir()
+	destination := t.TempDir()
+	podFolder := filepath.Join(source, "namespaces", "my-app", "pods", "app")
+	err := os.MkdirAll(podFolder, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(podFolder, "app.yaml"), []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  namespace: my-app\ndata:\n  token: dG9rZW4=\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(podFolder, "current.log"), []byte("GET /bucket?X-Amz-Signature=abcdef in my-app\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = redactor.CopyDirectory(source, destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactedFolder := filepath.Join(destination, "namespaces", redactor.Text("my-app"), "pods", "app")
+	content, err := os.ReadFile(filepath.Join(redactedFolder, "app.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "dG9rZW4=") || strings.Contains(string(content), "my-app") {
+		t.Errorf("copied yaml was not redacted:\n%s", content)
+	}
+	content, err = os.ReadFile(filepath.Join(redactedFolder, "current.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "abcdef") || strings.Contains(string(content), "my-app") {
+		t.Errorf("copied logs were not redacted:\n%s", content)
+	}
+}
@@ -0,0 +1,235 @@
+// Package report is the machine-readable summary of OADP must-gather, written next to the Markdown summary, so support
+// dashboards and CI can parse must-gather output.
+package report
+
+import (
+	"slices"
+	"strings"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/openshift/oadp-operator/must-gather/pkg/diagnosis"
+	"github.com/openshift/oadp-operator/must-gather/pkg/gather"
+)
+
+// SchemaVersion is increased when a field of Report is removed or changes meaning. New fields can be added without
+// increasing it, so consumers must ignore unknown fields.
+const SchemaVersion = "v1"
+
+type Severity string
+
+const (
+	SeverityError   Severity = "Error"
+	SeverityWarning Severity = "Warning"
+)
+
+// Report holds the same information as the Markdown summary
+type Report struct {
+	SchemaVersion              string              `json:"schemaVersion"`
+	MustGatherVersion          string              `json:"mustGatherVersion"`
+	Cluster                    Cluster             `json:"cluster"`
+	Operators                  []Operator          `json:"operators"`
+	DataProtectionApplications []Object            `json:"dataProtectionApplications"`
+	BackupStorageLocations     []Object            `json:"backupStorageLocations"`
+	VolumeSnapshotLocations    []Object            `json:"volumeSnapshotLocations"`
+	Backups                    []Object            `json:"backups"`
+	Restores                   []Object            `json:"restores"`
+	Metrics                    []Metrics           `json:"metrics"`
+	Errors                     []Error             `json:"errors"`
+	Findings                   []diagnosis.Finding `json:"findings"`
+}
+
+type Cluster struct {
+	ID               string   `json:"id"`
+	OpenShiftVersion string   `json:"openShiftVersion"`
+	Platform         string   `json:"platform,omitempty"`
+	Architectures    []string `json:"architectures,omitempty"`
+}
+
+// Operator is an OADP or related product operator installed in the cluster
+type Operator struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Namespace string `json:"namespace"`
+	Phase     string `json:"phase,omitempty"`
+}
+
+// Object is a DataProtectionApplication, BackupStorageLocation, VolumeSnapshotLocation, Backup or Restore
+type Object struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Phase is status.phase. For DataProtectionApplications, it is the reason of the Reconciled condition.
+	Phase    string `json:"phase,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Errors   int    `json:"errors,omitempty"`
+	Warnings int    `json:"warnings,omitempty"`
+}
+
+// Metrics is the digest of the key counters of the velero metrics Service or a node-agent Pod, whose exposition text
+// is written to File
+type Metrics struct {
+	Namespace string             `json:"namespace"`
+	Name      string             `json:"name"`
+	File      string             `json:"file,omitempty"`
+	Counters  map[string]float64 `json:"counters,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// Error is an entry of the Errors section of the Markdown summary
+type Error struct {
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// New returns an empty Report, whose lists are written as empty arrays instead of null
+func New(mustGatherVersion string) *Report {
+	return &Report{
+		SchemaVersion:              SchemaVersion,
+		MustGatherVersion:          mustGatherVersion,
+		Operators:                  []Operator{},
+		DataProtectionApplications: []Object{},
+		BackupStorageLocations:     []Object{},
+		VolumeSnapshotLocations:    []Object{},
+		Backups:                    []Object{},
+		Restores:                   []Object{},
+		Metrics:                    []Metrics{},
+		Errors:                     []Error{},
+		Findings:                   []diagnosis.Finding{},
+	}
+}
+
+// SetArchitectures sets the operating systems and architectures of the nodes
+func (r *Report) SetArchitectures(nodes []corev1.Node) {
+	for _, node := range nodes {
+		architecture := node.Status.NodeInfo.OperatingSystem + "/" + node.Status.NodeInfo.Architecture
+		if !slices.Contains(r.Cluster.Architectures, architecture) {
+			r.Cluster.Architectures = append(r.Cluster.Architectures, architecture)
+		}
+	}
+}
+
+// SetOperators sets the installed operators, sorted by namespace and name
+func (r *Report) SetOperators(csvsByNamespace map[string][]operatorsv1alpha1.ClusterServiceVersion) {
+	r.Operators = []Operator{}
+	for namespace, csvs := range csvsByNamespace {
+		for _, csv := range csvs {
+			r.Operators = append(r.Operators, Operator{
+				Name:      csv.Spec.DisplayName,
+				Version:   csv.Spec.Version.String(),
+				Namespace: namespace,
+				Phase:     string(csv.Status.Phase),
+			})
+		}
+	}
+	slices.SortFunc(r.Operators, func(a, b Operator) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+}
+
+func (r *Report) SetDataProtectionApplications(items []oadpv1alpha1.DataProtectionApplication) {
+	r.DataProtectionApplications = []Object{}
+	for _, dataProtectionApplication := range items {
+		object := Object{Namespace: dataProtectionApplication.Namespace, Name: dataProtectionApplication.Name}
+		condition := meta.FindStatusCondition(dataProtectionApplication.Status.Conditions, oadpv1alpha1.ConditionReconciled)
+		if condition != nil {
+			object.Phase = condition.Reason
+			object.Message = condition.Message
+		}
+		r.DataProtectionApplications = append(r.DataProtectionApplications, object)
+	}
+}
+
+func (r *Report) SetBackupStorageLocations(items []velerov1.BackupStorageLocation) {
+	r.BackupStorageLocations = []Object{}
+	for _, backupStorageLocation := range items {
+		r.BackupStorageLocations = append(r.BackupStorageLocations, Object{
+			Namespace: backupStorageLocation.Namespace,
+			Name:      backupStorageLocation.Name,
+			Phase:     string(backupStorageLocation.Status.Phase),
+			Message:   backupStorageLocation.Status.Message,
+		})
+	}
+}
+
+func (r *Report) SetVolumeSnapshotLocations(items []velerov1.VolumeSnapshotLocation) {
+	r.VolumeSnapshotLocations = []Object{}
+	for _, volumeSnapshotLocation := range items {
+		r.VolumeSnapshotLocations = append(r.VolumeSnapshotLocations, Object{
+			Namespace: volumeSnapshotLocation.Namespace,
+			Name:      volumeSnapshotLocation.Name,
+			Phase:     string(volumeSnapshotLocation.Status.Phase),
+		})
+	}
+}
+
+func (r *Report) SetBackups(items []velerov1.Backup) {
+	r.Backups = []Object{}
+	for _, backup := range items {
+		r.Backups = append(r.Backups, Object{
+			Namespace: backup.Namespace,
+			Name:      backup.Name,
+			Phase:     string(backup.Status.Phase),
+			Message:   backup.Status.FailureReason,
+			Errors:    backup.Status.Errors,
+			Warnings:  backup.Status.Warnings,
+		})
+	}
+}
+
+func (r *Report) SetRestores(items []velerov1.Restore) {
+	r.Restores = []Object{}
+	for _, restore := range items {
+		r.Restores = append(r.Restores, Object{
+			Namespace: restore.Namespace,
+			Name:      restore.Name,
+			Phase:     string(restore.Status.Phase),
+			Message:   restore.Status.FailureReason,
+			Errors:    restore.Status.Errors,
+			Warnings:  restore.Status.Warnings,
+		})
+	}
+}
+
+func (r *Report) SetMetrics(metrics []gather.Metrics) {
+	r.Metrics = []Metrics{}
+	for _, scraped := range metrics {
+		entry := Metrics{Namespace: scraped.Namespace, Name: scraped.Name, Counters: scraped.Counters}
+		if len(scraped.Text) != 0 {
+			entry.File = scraped.File()
+		}
+		if scraped.Err != nil {
+			entry.Error = scraped.Err.Error()
+		}
+		r.Metrics = append(r.Metrics, entry)
+	}
+	slices.SortFunc(r.Metrics, func(a, b Metrics) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+}
+
+// SetErrors sets the entries of the Errors section of the Markdown summary, which are separated by empty lines and
+// start with an emoji of their severity
+func (r *Report) SetErrors(markdown string) {
+	r.Errors = []Error{}
+	for _, entry := range strings.Split(markdown, "\n\n") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		severity := SeverityError
+		if strings.HasPrefix(entry, "⚠️") {
+			severity = SeverityWarning
+		}
+		for _, prefix := range []string{"❌", "🚫", "⚠️"} {
+			entry = strings.TrimPrefix(entry, prefix)
+		}
+		r.Errors = append(r.Errors, Error{
+			Severity: severity,
+			Message:  strings.TrimSpace(strings.ReplaceAll(entry, "**", "")),
+		})
+	}
+}
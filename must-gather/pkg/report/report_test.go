@@ -0,0 +1,83 @@
+package report
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []Error
+	}{
+		{
+			name:     "no errors",
+			markdown: "",
+			want:     []Error{},
+		},
+		{
+			name:     "errors and warnings",
+			markdown: "❌ Unable to get **DataProtectionApplications**\n\n⚠️ Skipping velero logs, `velero` Pod not found\n\n🚫 Cluster is not OpenShift\n\n",
+			want: []Error{
+				{Severity: SeverityError, Message: "Unable to get DataProtectionApplications"},
+				{Severity: SeverityWarning, Message: "Skipping velero logs, `velero` Pod not found"},
+				{Severity: SeverityError, Message: "Cluster is not OpenShift"},
+			},
+		},
+		{
+			name:     "multiline entry",
+			markdown: "❌ Targeted gather: unable to get Backup\nbackups.velero.io \"backup\" not found\n\n\n\n",
+			want: []Error{
+				{Severity: SeverityError, Message: "Targeted gather: unable to get Backup\nbackups.velero.io \"backup\" not found"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New("1.5")
+			r.SetErrors(tt.markdown)
+			if !slices.Equal(r.Errors, tt.want) {
+				t.Errorf("got errors %v, want %v", r.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	content, err := json.Marshal(New("1.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if schema["schemaVersion"] != SchemaVersion || schema["mustGatherVersion"] != "1.5" {
+		t.Errorf("got schemaVersion %v and mustGatherVersion %v", schema["schemaVersion"], schema["mustGatherVersion"])
+	}
+	cluster, ok := schema["cluster"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("cluster is %v, want an object", schema["cluster"])
+	}
+	for _, field := range []string{"id", "openShiftVersion"} {
+		if _, found := cluster[field]; !found {
+			t.Errorf("cluster.%s is missing", field)
+		}
+	}
+	// consumers iterate on the lists, which are empty arrays instead of null
+	lists := []string{
+		"operators", "dataProtectionApplications", "backupStorageLocations", "volumeSnapshotLocations",
+		"backups", "restores", "metrics", "errors", "findings",
+	}
+	for _, field := range lists {
+		if list, ok := schema[field].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("%s is %v, want an empty array", field, schema[field])
+		}
+	}
+	if len(schema) != len(lists)+3 {
+		t.Errorf("got %d fields, want %d: %v", len(schema), len(lists)+3, schema)
+	}
+}
@@ -3,6 +3,7 @@ package templates
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -30,19 +31,29 @@ import (
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/openshift/oadp-operator/must-gather/pkg/diagnosis"
+	"github.com/openshift/oadp-operator/must-gather/pkg/gather"
 	"github.com/openshift/oadp-operator/must-gather/pkg/gvk"
+	"github.com/openshift/oadp-operator/must-gather/pkg/redact"
+	"github.com/openshift/oadp-operator/must-gather/pkg/report"
 )
 
 const (
 	FilePermission   = 0644
 	FolderPermission = 0777
 	IBMCloudConstant = "IBMCloud"
+
+	// SummaryName is the name of the summary files written by a gather
+	SummaryName = "oadp-must-gather-summary"
+	// AnalysisName is the name of the summary files written by analyze, next to the summary of the gather
+	AnalysisName = "oadp-must-gather-analysis"
 )
 
 var (
 	summaryTemplateReplacesKeys = []string{
 		"MUST_GATHER_VERSION",
 		"ERRORS",
+		"DIAGNOSIS",
 		"CLUSTER_ID", "OCP_VERSION", "CLOUD", "ARCH", "CLUSTER_VERSION",
 		"OADP_VERSIONS",
 		"DATA_PROTECTION_APPLICATIONS",
@@ -66,12 +77,18 @@ var (
 		"NON_ADMIN_BACKUPS",
 		"NON_ADMIN_RESTORES",
 		"NON_ADMIN_DOWNLOAD_REQUESTS",
+		"METRICS",
 		"STORAGE_CLASSES",
 		"VOLUME_SNAPSHOT_CLASSES",
 		"CSI_DRIVERS",
 		"CUSTOM_RESOURCE_DEFINITION",
 	}
 	summaryTemplateReplaces = map[string]string{}
+
+	redactor      *redact.Redactor
+	summaryReport *report.Report
+	// analysis is set when analyzing a must-gather directory, whose gathered files are linked instead of written
+	analysis bool
 )
 
 const summaryTemplate = `# OADP must-gather summary version <<MUST_GATHER_VERSION>>
@@ -81,6 +98,7 @@ const summaryTemplate = `# OADP must-gather summary version <<MUST_GATHER_VERSIO
 # Table of Contents
 
 - [Errors](#errors)
+- [Diagnosis](#diagnosis)
 - [Cluster information](#cluster-information)
 - [OADP operator installation information](#oadp-operator-installation-information)
     - [DataProtectionApplications (DPAs)](#dataprotectionapplications-dpas)
@@ -104,6 +122,7 @@ const summaryTemplate = `# OADP must-gather summary version <<MUST_GATHER_VERSIO
     - [NonAdminBackups](#nonadminbackups)
     - [NonAdminRestores](#nonadminrestores)
     - [NonAdminDownloadRequests](#nonadmindownloadrequests)
+    - [Velero metrics](#velero-metrics)
 - Storage
     - [Available StorageClasses in cluster](#available-storageclasses-in-cluster)
     - [Available VolumeSnapshotClasses in cluster](#available-volumesnapshotclasses-in-cluster)
@@ -114,6 +133,10 @@ const summaryTemplate = `# OADP must-gather summary version <<MUST_GATHER_VERSIO
 
 <<ERRORS>>
 
+## Diagnosis
+
+<<DIAGNOSIS>>
+
 ## Cluster information
 
 | Cluster ID | OpenShift version | Cloud provider | Architecture |
@@ -210,6 +233,10 @@ const summaryTemplate = `# OADP must-gather summary version <<MUST_GATHER_VERSIO
 
 <<NON_ADMIN_DOWNLOAD_REQUESTS>>
 
+### Velero metrics
+
+<<METRICS>>
+
 ## Available StorageClasses in cluster
 
 <<STORAGE_CLASSES>>
@@ -235,10 +262,61 @@ func init() {
 	}
 }
 
+// SetRedactor sets the Redactor applied to the files and the summary before they are written
+func SetRedactor(r *redact.Redactor) {
+	redactor = r
+}
+
+// SetReport sets the machine-readable summary written with the Markdown summary. Its errors are filled from the
+// Errors section.
+func SetReport(r *report.Report) {
+	summaryReport = r
+}
+
+// SetAnalysis makes the summary link the files of the analyzed must-gather directory instead of writing them again,
+// which would lose the fields unknown to the Go types of this version
+func SetAnalysis(a bool) {
+	analysis = a
+}
+
 func ReplaceMustGatherVersion(version string) {
 	summaryTemplateReplaces["MUST_GATHER_VERSION"] = "`" + version + "`"
 }
 
+// AddSectionTimeoutError adds an errors entry when a gather section timed out, with the requests not finished
+func AddSectionTimeoutError(result gather.SectionResult) {
+	if len(result.TimedOut) == 0 {
+		return
+	}
+	summaryTemplateReplaces["ERRORS"] += fmt.Sprintf(
+		"❌ Gathering **%s** timed out after **%v**, **%d** of %d requests not finished: %s\n\n",
+		result.Name, result.Timeout, len(result.TimedOut), result.Total, strings.Join(result.TimedOut, ", "),
+	)
+}
+
+// AddTargetError adds an errors entry when the Backup, Restore or Schedule of a targeted must-gather can not be gathered
+func AddTargetError(err error) {
+	summaryTemplateReplaces["ERRORS"] += fmt.Sprintf("❌ Targeted gather: %v\n\n", err)
+}
+
+func ReplaceDiagnosisSection(findings []diagnosis.Finding) {
+	if len(findings) == 0 {
+		summaryTemplateReplaces["DIAGNOSIS"] = "✅ No problem found by the diagnosis rules"
+		return
+	}
+	summaryTemplateReplaces["DIAGNOSIS"] += "| Severity | Rule | Object | Finding | Suggested fix |\n| --- | --- | --- | --- | --- |\n"
+	for _, finding := range findings {
+		severity := "⚠️ " + string(finding.Severity)
+		if finding.Severity == diagnosis.SeverityCritical {
+			severity = "❌ " + string(finding.Severity)
+		}
+		summaryTemplateReplaces["DIAGNOSIS"] += fmt.Sprintf(
+			"| %s | %s | %s | %s | %s |\n",
+			severity, finding.Rule, finding.Object, strings.ReplaceAll(finding.Message, "|", "\\|"), finding.Fix,
+		)
+	}
+}
+
 func ReplaceClusterInformationSection(
 	outputPath string,
 	clusterID string,
@@ -256,6 +334,14 @@ func ReplaceClusterInformationSection(
 	if infrastructureList != nil && len(infrastructureList.Items) != 0 {
 		cloudProvider = string(infrastructureList.Items[0].Spec.PlatformSpec.Type)
 		summaryTemplateReplaces["CLOUD"] = cloudProvider
+
+		list := &corev1.List{}
+		list.GetObjectKind().SetGroupVersionKind(gvk.ListGVK)
+		for _, infrastructure := range infrastructureList.Items {
+			infrastructure.GetObjectKind().SetGroupVersionKind(gvk.InfrastructureGVK)
+			list.Items = append(list.Items, runtime.RawExtension{Object: &infrastructure})
+		}
+		createYAML(outputPath, "cluster-scoped-resources/config.openshift.io/infrastructures.yaml", list)
 	} else {
 		summaryTemplateReplaces["CLOUD"] = "❌ no Infrastructure found in cluster"
 		summaryTemplateReplaces["ERRORS"] += "⚠️ No Infrastructure found in cluster\n\n"
@@ -277,6 +363,14 @@ func ReplaceClusterInformationSection(
 			}
 		}
 		summaryTemplateReplaces["ARCH"] = architectureText
+
+		list := &corev1.List{}
+		list.GetObjectKind().SetGroupVersionKind(gvk.ListGVK)
+		for _, node := range nodeList.Items {
+			node.GetObjectKind().SetGroupVersionKind(gvk.NodeGVK)
+			list.Items = append(list.Items, runtime.RawExtension{Object: &node})
+		}
+		createYAML(outputPath, "cluster-scoped-resources/core/nodes/nodes.yaml", list)
 	} else {
 		summaryTemplateReplaces["ARCH"] = "❌ no Node found in cluster"
 		summaryTemplateReplaces["ERRORS"] += "⚠️ No Node found in cluster\n\n"
@@ -601,6 +695,23 @@ func ReplaceVolumeSnapshotLocationsSection(outputPath string, volumeSnapshotLoca
 	}
 }
 
+// sectionTimedOut is shown for the describe and logs requests not finished before the section timeout
+const sectionTimedOut = "❌ section timed out"
+
+// sectionRow is a Backups or Restores summary table row, whose describe and logs are requested in parallel
+type sectionRow struct {
+	namespace string
+	name      string
+	status    string
+	describe  string
+	logs      string
+	yaml      string
+}
+
+func (r *sectionRow) String() string {
+	return fmt.Sprintf("| %v | %v | %s | %s | %s | %s |\n", r.namespace, r.name, r.status, r.describe, r.logs, r.yaml)
+}
+
 func ReplaceBackupsSection(
 	outputPath string,
 	backupList *velerov1.BackupList,
@@ -609,6 +720,8 @@ func ReplaceBackupsSection(
 	podVolumeBackupList *velerov1.PodVolumeBackupList,
 	requestTimeot time.Duration,
 	skipTLS bool,
+	sectionTimeout time.Duration,
+	workers int,
 ) {
 	if backupList != nil && len(backupList.Items) != 0 {
 		backupsByNamespace := map[string][]velerov1.Backup{}
@@ -618,6 +731,8 @@ func ReplaceBackupsSection(
 		}
 
 		summaryTemplateReplaces["BACKUPS"] += "| Namespace | Name | status.phase | describe | logs | yaml |\n| --- | --- | --- | --- | --- | ---|\n"
+		rows := []*sectionRow{}
+		tasks := []gather.Task{}
 		for namespace, backups := range backupsByNamespace {
 			list := &corev1.List{}
 			list.GetObjectKind().SetGroupVersionKind(gvk.ListGVK)
@@ -671,46 +786,65 @@ func ReplaceBackupsSection(
 					}
 				}
 
-				// TODO caCertFile?
-				describeOutput := func(ctx context.Context) string {
-					ctx, cancel := context.WithTimeout(ctx, requestTimeot)
-					defer cancel()
-					return output.DescribeBackup(ctx, clusterClient, &backup, relatedDeleteBackupRequests, relatedPodVolumeBackupLists, true, skipTLS, "")
-				}(context.Background())
-
-				writeTo := &bytes.Buffer{}
-				// TODO caCertFile?
-				err := downloadrequest.Stream(context.Background(), clusterClient, backup.Namespace, backup.Name, velerov1.DownloadTargetKindBackupLog, writeTo, requestTimeot, skipTLS, "")
-				var logs string
-				if err != nil {
-					fmt.Println(err)
-					logs = fmt.Sprintf("❌ %s", err)
-				} else {
-					logs = createFile(
-						outputPath,
-						folder+"/"+backup.Name+".log",
-						writeTo.String(),
-						"logs",
-					)
+				row := &sectionRow{
+					namespace: namespace,
+					name:      backup.Name,
+					status:    backupStatus,
+					yaml:      fmt.Sprintf("[`yaml`](%s)", file),
 				}
-				yamlLink := fmt.Sprintf("[`yaml`](%s)", file)
-				summaryTemplateReplaces["BACKUPS"] += fmt.Sprintf(
-					"| %v | %v | %s | %s | %s | %s |\n",
-					namespace, backup.Name,
-					backupStatus,
-					createFile(
-						outputPath,
-						folder+"/describe-"+backup.Name+".txt",
-						describeOutput,
-						"describe",
-					),
-					logs,
-					yamlLink,
-				)
+				rows = append(rows, row)
+				// without cluster connection, link the describe and logs files gathered before
+				if clusterClient == nil {
+					row.describe = linkGatheredFile(outputPath, folder+"/describe-"+backup.Name+".txt", "describe")
+					row.logs = linkGatheredFile(outputPath, folder+"/"+backup.Name+".log", "logs")
+					continue
+				}
+
+				row.describe = sectionTimedOut
+				row.logs = sectionTimedOut
+				tasks = append(tasks, gather.Task{
+					Name: namespace + "/" + backup.Name,
+					Run: func(ctx context.Context) (func(), error) {
+						// TODO caCertFile?
+						describeOutput := func(ctx context.Context) string {
+							ctx, cancel := context.WithTimeout(ctx, requestTimeot)
+							defer cancel()
+							return output.DescribeBackup(ctx, clusterClient, &backup, relatedDeleteBackupRequests, relatedPodVolumeBackupLists, true, skipTLS, "")
+						}(ctx)
+
+						writeTo := &bytes.Buffer{}
+						// TODO caCertFile?
+						err := downloadrequest.Stream(ctx, clusterClient, backup.Namespace, backup.Name, velerov1.DownloadTargetKindBackupLog, writeTo, requestTimeot, skipTLS, "")
+						return func() {
+							if err != nil {
+								fmt.Println(err)
+								row.logs = fmt.Sprintf("❌ %s", err)
+							} else {
+								row.logs = createFile(
+									outputPath,
+									folder+"/"+backup.Name+".log",
+									writeTo.String(),
+									"logs",
+								)
+							}
+							row.describe = createFile(
+								outputPath,
+								folder+"/describe-"+backup.Name+".txt",
+								describeOutput,
+								"describe",
+							)
+						}, nil
+					},
+				})
 			}
 
 			createYAML(outputPath, file, list)
 		}
+
+		AddSectionTimeoutError(gather.RunSection("Backups", sectionTimeout, workers, tasks))
+		for _, row := range rows {
+			summaryTemplateReplaces["BACKUPS"] += row.String()
+		}
 	} else {
 		summaryTemplateReplaces["BACKUPS"] = "❌ No Backup was found in the cluster"
 	}
@@ -723,6 +857,8 @@ func ReplaceRestoresSection(
 	podVolumeRestoreList *velerov1.PodVolumeRestoreList,
 	requestTimeot time.Duration,
 	skipTLS bool,
+	sectionTimeout time.Duration,
+	workers int,
 ) {
 	if restoreListList != nil && len(restoreListList.Items) != 0 {
 		restoresByNamespace := map[string][]velerov1.Restore{}
@@ -732,6 +868,8 @@ func ReplaceRestoresSection(
 		}
 
 		summaryTemplateReplaces["RESTORES"] += "| Namespace | Name | status.phase | describe | logs | yaml |\n| --- | --- | --- | --- | --- | --- |\n"
+		rows := []*sectionRow{}
+		tasks := []gather.Task{}
 		for namespace, restores := range restoresByNamespace {
 			list := &corev1.List{}
 			list.GetObjectKind().SetGroupVersionKind(gvk.ListGVK)
@@ -778,47 +916,65 @@ func ReplaceRestoresSection(
 					}
 				}
 
-				// TODO caCertFile?
-				describeOutput := func(ctx context.Context) string {
-					ctx, cancel := context.WithTimeout(ctx, requestTimeot)
-					defer cancel()
-					return output.DescribeRestore(ctx, clusterClient, &restore, relatedPodVolumeRestoreLists, true, skipTLS, "")
-				}(context.Background())
-
-				writeTo := &bytes.Buffer{}
-				// TODO caCertFile?
-				err := downloadrequest.Stream(context.Background(), clusterClient, restore.Namespace, restore.Name, velerov1.DownloadTargetKindRestoreLog, writeTo, requestTimeot, skipTLS, "")
-				var logs string
-				if err != nil {
-					fmt.Println(err)
-					logs = fmt.Sprintf("❌ %s", err)
-				} else {
-					logs = createFile(
-						outputPath,
-						folder+"/"+restore.Name+".log",
-						writeTo.String(),
-						"logs",
-					)
+				row := &sectionRow{
+					namespace: namespace,
+					name:      restore.Name,
+					status:    restoreStatus,
+					yaml:      fmt.Sprintf("[`yaml`](%s)", file),
+				}
+				rows = append(rows, row)
+				// without cluster connection, link the describe and logs files gathered before
+				if clusterClient == nil {
+					row.describe = linkGatheredFile(outputPath, folder+"/describe-"+restore.Name+".txt", "describe")
+					row.logs = linkGatheredFile(outputPath, folder+"/"+restore.Name+".log", "logs")
+					continue
 				}
 
-				yamllink := fmt.Sprintf("[`yaml`](%s)", file)
-				summaryTemplateReplaces["RESTORES"] += fmt.Sprintf(
-					"| %v | %v | %s | %s | %s | %s |\n",
-					namespace, restore.Name,
-					restoreStatus,
-					createFile(
-						outputPath,
-						folder+"/describe-"+restore.Name+".txt",
-						describeOutput,
-						"describe",
-					),
-					logs,
-					yamllink,
-				)
+				row.describe = sectionTimedOut
+				row.logs = sectionTimedOut
+				tasks = append(tasks, gather.Task{
+					Name: namespace + "/" + restore.Name,
+					Run: func(ctx context.Context) (func(), error) {
+						// TODO caCertFile?
+						describeOutput := func(ctx context.Context) string {
+							ctx, cancel := context.WithTimeout(ctx, requestTimeot)
+							defer cancel()
+							return output.DescribeRestore(ctx, clusterClient, &restore, relatedPodVolumeRestoreLists, true, skipTLS, "")
+						}(ctx)
+
+						writeTo := &bytes.Buffer{}
+						// TODO caCertFile?
+						err := downloadrequest.Stream(ctx, clusterClient, restore.Namespace, restore.Name, velerov1.DownloadTargetKindRestoreLog, writeTo, requestTimeot, skipTLS, "")
+						return func() {
+							if err != nil {
+								fmt.Println(err)
+								row.logs = fmt.Sprintf("❌ %s", err)
+							} else {
+								row.logs = createFile(
+									outputPath,
+									folder+"/"+restore.Name+".log",
+									writeTo.String(),
+									"logs",
+								)
+							}
+							row.describe = createFile(
+								outputPath,
+								folder+"/describe-"+restore.Name+".txt",
+								describeOutput,
+								"describe",
+							)
+						}, nil
+					},
+				})
 			}
 
 			createYAML(outputPath, file, list)
 		}
+
+		AddSectionTimeoutError(gather.RunSection("Restores", sectionTimeout, workers, tasks))
+		for _, row := range rows {
+			summaryTemplateReplaces["RESTORES"] += row.String()
+		}
 	} else {
 		summaryTemplateReplaces["RESTORES"] = "❌ No Restore was found in the cluster"
 	}
@@ -879,15 +1035,21 @@ func ReplaceSchedulesSection(outputPath string, scheduleList *velerov1.ScheduleL
 	}
 }
 
-func ReplaceBackupRepositoriesSection(outputPath string, backupRepositoryList *velerov1.BackupRepositoryList) {
+// ReplaceBackupRepositoriesSection writes the BackupRepositories, with the output of the kopia inspection of each one,
+// if run. Without inspections, the kopia inspection files gathered before are linked.
+func ReplaceBackupRepositoriesSection(outputPath string, backupRepositoryList *velerov1.BackupRepositoryList, kopiaInspections []gather.KopiaInspection) {
 	if backupRepositoryList != nil && len(backupRepositoryList.Items) != 0 {
 		backupRepositoriesByNamespace := map[string][]velerov1.BackupRepository{}
+		kopiaInspectionsByName := map[string]gather.KopiaInspection{}
+		for _, kopiaInspection := range kopiaInspections {
+			kopiaInspectionsByName[kopiaInspection.Namespace+"/"+kopiaInspection.Name] = kopiaInspection
+		}
 
 		for _, backupRepository := range backupRepositoryList.Items {
 			backupRepositoriesByNamespace[backupRepository.Namespace] = append(backupRepositoriesByNamespace[backupRepository.Namespace], backupRepository)
 		}
 
-		summaryTemplateReplaces["BACKUPS_REPOSITORIES"] += "| Namespace | Name | status.phase | yaml |\n| --- | --- | --- | --- |\n"
+		summaryTemplateReplaces["BACKUPS_REPOSITORIES"] += "| Namespace | Name | status.phase | kopia | yaml |\n| --- | --- | --- | --- | --- |\n"
 		for namespace, backupRepositories := range backupRepositoriesByNamespace {
 			list := &corev1.List{}
 			list.GetObjectKind().SetGroupVersionKind(gvk.ListGVK)
@@ -920,10 +1082,29 @@ func ReplaceBackupRepositoriesSection(outputPath string, backupRepositoryList *v
 					}
 				}
 
+				kopiaInspectionText := ""
+				kopiaInspectionFile := gather.KopiaInspection{Namespace: namespace, Name: backupRepository.Name}.File()
+				kopiaInspection, inspected := kopiaInspectionsByName[namespace+"/"+backupRepository.Name]
+				if inspected {
+					if kopiaInspection.Err != nil {
+						summaryTemplateReplaces["ERRORS"] += fmt.Sprintf(
+							"⚠️ Unable to inspect kopia repository of BackupRepository **%v** in **%v** namespace: %v\n\n",
+							backupRepository.Name, namespace, kopiaInspection.Err,
+						)
+						kopiaInspectionText = fmt.Sprintf("❌ %s", strings.ReplaceAll(kopiaInspection.Err.Error(), "|", "\\|"))
+					}
+					if len(kopiaInspection.Output) != 0 {
+						kopiaInspectionText += " " + createFile(outputPath, kopiaInspectionFile, kopiaInspection.Output, "kopia")
+					}
+				} else if _, err := os.Stat(outputPath + kopiaInspectionFile); err == nil {
+					// without cluster connection, link the kopia inspection gathered before
+					kopiaInspectionText = fmt.Sprintf("[`kopia`](%s)", kopiaInspectionFile)
+				}
+
 				link := fmt.Sprintf("[`yaml`](%s)", file)
 				summaryTemplateReplaces["BACKUPS_REPOSITORIES"] += fmt.Sprintf(
-					"| %v | %v | %s | %s |\n",
-					namespace, backupRepository.Name, backupRepositoryStatus, link,
+					"| %v | %v | %s | %s | %s |\n",
+					namespace, backupRepository.Name, backupRepositoryStatus, strings.TrimSpace(kopiaInspectionText), link,
 				)
 			}
 
@@ -1605,6 +1786,48 @@ func ReplaceNonAdminDownloadRequestsSection(outputPath string, nonAdminDownloadR
 	}
 }
 
+// ReplaceMetricsSection writes the exposition text of the velero and node-agent metrics, with the digest of their key
+// counters
+func ReplaceMetricsSection(outputPath string, metrics []gather.Metrics) {
+	if len(metrics) == 0 {
+		summaryTemplateReplaces["METRICS"] = "Metrics were not gathered, run OADP Must-gather with `--metrics` to scrape them"
+		return
+	}
+	slices.SortFunc(metrics, func(a, b gather.Metrics) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+	summaryTemplateReplaces["METRICS"] += "| Namespace | Name | key counters | metrics |\n| --- | --- | --- | --- |\n"
+	for _, scraped := range metrics {
+		if scraped.Err != nil && len(scraped.Text) == 0 {
+			summaryTemplateReplaces["ERRORS"] += fmt.Sprintf(
+				"⚠️ Unable to scrape metrics of **%v** in **%v** namespace: %v\n\n",
+				scraped.Name, scraped.Namespace, scraped.Err,
+			)
+			summaryTemplateReplaces["METRICS"] += fmt.Sprintf(
+				"| %v | %v | | ❌ %s |\n",
+				scraped.Namespace, scraped.Name, strings.ReplaceAll(scraped.Err.Error(), "|", "\\|"),
+			)
+			continue
+		}
+		counters := []string{}
+		for name, value := range scraped.Counters {
+			counters = append(counters, fmt.Sprintf("%s: %v", name, value))
+		}
+		slices.Sort(counters)
+		countersText := strings.Join(counters, "<br>")
+		if scraped.Err != nil {
+			countersText = fmt.Sprintf("❌ %s", scraped.Err)
+		} else if len(counters) == 0 {
+			countersText = "no key counter exposed"
+		}
+		summaryTemplateReplaces["METRICS"] += fmt.Sprintf(
+			"| %v | %v | %s | %s |\n",
+			scraped.Namespace, scraped.Name, countersText,
+			createFile(outputPath, scraped.File(), scraped.Text, "metrics"),
+		)
+	}
+}
+
 func ReplaceAvailableStorageClassesSection(outputPath string, storageClassList *storagev1.StorageClassList) {
 	if storageClassList != nil && len(storageClassList.Items) != 0 {
 		list := &corev1.List{}
@@ -1655,6 +1878,17 @@ func ReplaceAvailableCSIDriversSection(outputPath string, csiDriverList *storage
 
 func ReplaceCustomResourceDefinitionsSection(outputPath string, clusterConfig *rest.Config) {
 	errorMessage := "❌ Unable to write CustomResourceDefinitions section: "
+	crdsPath := "cluster-scoped-resources/apiextensions.k8s.io/customresourcedefinitions"
+
+	// without cluster connection, link the CustomResourceDefinitions gathered before
+	if clusterConfig == nil {
+		if _, err := os.Stat(outputPath + crdsPath); err != nil {
+			summaryTemplateReplaces["CUSTOM_RESOURCE_DEFINITION"] = errorMessage + err.Error()
+			return
+		}
+		summaryTemplateReplaces["CUSTOM_RESOURCE_DEFINITION"] = fmt.Sprintf("For more information, check [`%s`](%s)\n\n", crdsPath, crdsPath)
+		return
+	}
 
 	client, err := apiextensionsclientset.NewForConfig(clusterConfig)
 	if err != nil {
@@ -1663,8 +1897,6 @@ func ReplaceCustomResourceDefinitionsSection(outputPath string, clusterConfig *r
 		return
 	}
 
-	crdsPath := "cluster-scoped-resources/apiextensions.k8s.io/customresourcedefinitions"
-
 	// CRD spec.names.plural : CRD spec.group
 	crds := map[string]string{
 		"dataprotectionapplications":            gvk.DataProtectionApplicationGVK.Group,
@@ -1707,6 +1939,14 @@ func ReplaceCustomResourceDefinitionsSection(outputPath string, clusterConfig *r
 }
 
 func createYAML(outputPath string, yamlPath string, obj runtime.Object) string {
+	yamlPath = redactor.Path(yamlPath)
+	if analysis {
+		_, err := os.Stat(outputPath + yamlPath)
+		if err != nil {
+			return "❌ " + yamlPath + " not gathered"
+		}
+		return fmt.Sprintf("For more information, check [`%s`](%s)\n\n", yamlPath, yamlPath)
+	}
 	objFilePath := outputPath + yamlPath
 	dir := path.Dir(objFilePath)
 	err := os.MkdirAll(dir, FolderPermission)
@@ -1720,7 +1960,15 @@ func createYAML(outputPath string, yamlPath string, obj runtime.Object) string {
 		result = "❌ Unable to create file " + objFilePath
 	} else {
 		printer := printers.YAMLPrinter{}
-		err = printer.PrintObj(obj, newFile)
+		content := &bytes.Buffer{}
+		err = printer.PrintObj(obj, content)
+		if err == nil {
+			var redacted []byte
+			redacted, err = redactor.YAML(content.Bytes())
+			if err == nil {
+				_, err = newFile.Write(redacted)
+			}
+		}
 		if err != nil {
 			fmt.Println(err)
 			result = "❌ Unable to write " + objFilePath
@@ -1732,7 +1980,21 @@ func createYAML(outputPath string, yamlPath string, obj runtime.Object) string {
 	return result
 }
 
+// linkGatheredFile returns the link to a file written by a previous run, when analyzing a must-gather directory
+func linkGatheredFile(outputPath string, filePath string, title string) string {
+	_, err := os.Stat(outputPath + filePath)
+	if err != nil {
+		return "❌ not gathered"
+	}
+	return fmt.Sprintf("[`"+title+"`](%s)", filePath)
+}
+
 func createFile(outputPath string, describePath string, describeOutput string, describeTitle string) string {
+	describePath = redactor.Path(describePath)
+	if analysis {
+		return linkGatheredFile(outputPath, describePath, describeTitle)
+	}
+	describeOutput = redactor.Text(describeOutput)
 	describeFilePath := outputPath + describePath
 	dir := path.Dir(describeFilePath)
 	err := os.MkdirAll(dir, FolderPermission)
@@ -1757,7 +2019,15 @@ func createFile(outputPath string, describePath string, describeOutput string, d
 	return result
 }
 
-func Write(outputPath string) error {
+// Write writes the summary to the name.md and name.json files of outputPath
+func Write(outputPath string, name string) error {
+	if summaryReport != nil {
+		summaryReport.SetErrors(summaryTemplateReplaces["ERRORS"])
+		err := writeReport(outputPath + name + ".json")
+		if err != nil {
+			return err
+		}
+	}
 	if len(summaryTemplateReplaces["ERRORS"]) == 0 {
 		summaryTemplateReplaces["ERRORS"] += "No errors happened or were found while running OADP must-gather\n\n"
 	}
@@ -1778,7 +2048,9 @@ func Write(outputPath string) error {
 		)
 	}
 
-	summaryPath := outputPath + "oadp-must-gather-summary.md"
+	summary = redactor.Text(summary)
+
+	summaryPath := outputPath + name + ".md"
 	sumary, err := os.Create(summaryPath)
 	if err != nil {
 		return err
@@ -1791,6 +2063,19 @@ func Write(outputPath string) error {
 	return nil
 }
 
+func writeReport(reportPath string) error {
+	// HTML escaping would turn the & of URL parameters into \u0026, which the presigned URL rule does not match
+	content := &bytes.Buffer{}
+	encoder := json.NewEncoder(content)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(summaryReport)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportPath, []byte(redactor.Text(content.String())), FilePermission)
+}
+
 func WriteVersion(version string) error {
 	versionFileContent := fmt.Sprintf(
 		`OpenShift API for Data Protection (OADP) Must-gather
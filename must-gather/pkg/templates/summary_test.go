@@ -0,0 +1,80 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/oadp-operator/must-gather/pkg/gvk"
+	"github.com/openshift/oadp-operator/must-gather/pkg/redact"
+	"github.com/openshift/oadp-operator/must-gather/pkg/report"
+)
+
+func TestWriteReportRedactsPresignedURLs(t *testing.T) {
+	r, err := redact.New(redact.DefaultRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRedactor(r)
+	SetReport(report.New("1.5"))
+	t.Cleanup(func() {
+		SetRedactor(nil)
+		SetReport(nil)
+	})
+	summaryReport.SetErrors("❌ Unable to download backup from https://bucket.s3.amazonaws.com/backup.tar.gz?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIA%2F20250505&X-Amz-Signature=abcdef0123 <timeout>\n\n")
+
+	reportPath := filepath.Join(t.TempDir(), SummaryName+".json")
+	if err := writeReport(reportPath); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, excluded := range []string{"abcdef0123", "AKIA", `\u0026`, `\u003c`} {
+		if strings.Contains(string(content), excluded) {
+			t.Errorf("report contains %q:\n%s", excluded, content)
+		}
+	}
+	for _, included := range []string{"X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=" + redact.Redacted, "&X-Amz-Signature=" + redact.Redacted + " <timeout>"} {
+		if !strings.Contains(string(content), included) {
+			t.Errorf("report does not contain %q:\n%s", included, content)
+		}
+	}
+}
+
+func TestCreateYAMLKeepsAnalyzedFiles(t *testing.T) {
+	SetAnalysis(true)
+	t.Cleanup(func() { SetAnalysis(false) })
+	outputPath := t.TempDir() + "/"
+	yamlPath := "namespaces/openshift-adp/velero.io/backups/backups.yaml"
+	gathered := "apiVersion: v1\nkind: List\nitems:\n- apiVersion: velero.io/v1\n  kind: Backup\n  spec:\n    fieldOfANewerVelero: true\n"
+	if err := os.MkdirAll(filepath.Dir(outputPath+yamlPath), FolderPermission); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(outputPath+yamlPath, []byte(gathered), FilePermission); err != nil {
+		t.Fatal(err)
+	}
+
+	list := &corev1.List{}
+	list.GetObjectKind().SetGroupVersionKind(gvk.ListGVK)
+	if got := createYAML(outputPath, yamlPath, list); !strings.Contains(got, "("+yamlPath+")") {
+		t.Errorf("createYAML() = %q, want a link to %s", got, yamlPath)
+	}
+	content, err := os.ReadFile(outputPath + yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != gathered {
+		t.Errorf("gathered file was rewritten:\n%s", content)
+	}
+	if got := createYAML(outputPath, "cluster-scoped-resources/core/nodes/nodes.yaml", list); !strings.HasPrefix(got, "❌") {
+		t.Errorf("createYAML() = %q for a file not gathered", got)
+	}
+	if _, err := os.Stat(outputPath + "cluster-scoped-resources/core/nodes/nodes.yaml"); err == nil {
+		t.Errorf("file not gathered was written")
+	}
+}
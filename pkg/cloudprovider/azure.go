@@ -30,6 +30,7 @@ type AzureCredentials struct {
 	StorageAccountName string
 	StorageAccountKey  string
 	CertificatePath    string
+	FederatedTokenFile string
 }
 
 type AzureProvider struct {
@@ -47,6 +48,7 @@ func ParseAzureCredentials(data map[string][]byte) AzureCredentials {
 	creds.StorageAccountName = string(data["AZURE_STORAGE_ACCOUNT_ID"])
 	creds.StorageAccountKey = string(data["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"])
 	creds.CertificatePath = string(data["AZURE_CLIENT_CERTIFICATE_PATH"])
+	creds.FederatedTokenFile = string(data["AZURE_FEDERATED_TOKEN_FILE"])
 	return creds
 }
 
@@ -0,0 +1,219 @@
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awscredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	azureManagementScope  = "https://management.azure.com/.default"
+	gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// ErrCredentialsCheckUnsupported is returned when the credentials can not be checked from the operator pod
+var ErrCredentialsCheckUnsupported = errors.New("credentials check is not supported for this authentication method")
+
+// awsSTSEndpoint replaces the AWS STS endpoint in tests
+var awsSTSEndpoint = ""
+
+// azureErrorCode matches the Microsoft Entra ID error codes in token request failures
+var azureErrorCode = regexp.MustCompile(`AADSTS\d+`)
+
+// CredentialsError is a rejection of credentials by a cloud provider
+type CredentialsError struct {
+	Provider string
+	// Code is the error code returned by the provider, or Unknown
+	Code string
+	Err  error
+}
+
+func (e *CredentialsError) Error() string {
+	return fmt.Sprintf("%s error %s: %v", e.Provider, e.Code, e.Err)
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// CheckAWSCredentials gets the caller identity from STS with the profile of an AWS shared credentials file. A web
+// identity profile first exchanges its token with STS AssumeRoleWithWebIdentity.
+func CheckAWSCredentials(ctx context.Context, credentialsFile []byte, profile string, region string) error {
+	file, err := os.CreateTemp("", "aws-credentials-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(credentialsFile); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if region == "" {
+		region = "us-east-1"
+	}
+	// like velero, an unset profile is the default profile. An explicit profile is resolved from the file before the
+	// AWS_* env of the operator pod, and the static keys of the profile are set explicitly, so the operator identity
+	// is never checked instead of the location secret.
+	if profile == "" {
+		profile = "default"
+	}
+	config := aws.Config{Region: aws.String(region)}
+	if awsSTSEndpoint != "" {
+		config.Endpoint = aws.String(awsSTSEndpoint)
+	}
+	fileCredentials := awscredentials.NewSharedCredentials(file.Name(), profile)
+	if _, err := fileCredentials.Get(); err == nil {
+		config.Credentials = fileCredentials
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		Profile:           profile,
+		SharedConfigFiles: []string{file.Name()},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return awsCredentialsError(err)
+	}
+	if _, err := sts.New(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		return awsCredentialsError(err)
+	}
+	return nil
+}
+
+// awsCredentialsError returns the innermost AWS error code, the web identity errors wrap the STS error. Errors
+// reaching STS are not a rejection of the credentials.
+func awsCredentialsError(err error) error {
+	code := "Unknown"
+	for current := err; current != nil; {
+		awsErr, ok := current.(awserr.Error)
+		if !ok {
+			break
+		}
+		code = awsErr.Code()
+		current = awsErr.OrigErr()
+	}
+	if code == request.ErrCodeRequestError || code == request.CanceledErrorCode {
+		return fmt.Errorf("failed to reach AWS STS: %w", err)
+	}
+	return &CredentialsError{Provider: "aws", Code: code, Err: err}
+}
+
+// CheckGCPCredentials requests an access token with a GCP credentials file, signing a JWT for a service account
+// key or exchanging the external token for a workload identity federation configuration
+func CheckGCPCredentials(ctx context.Context, credentialsJSON []byte) error {
+	credentials, err := google.CredentialsFromJSON(ctx, credentialsJSON, gcpCloudPlatformScope)
+	if err != nil {
+		return &CredentialsError{Provider: "gcp", Code: "InvalidCredentials", Err: err}
+	}
+	if _, err := credentials.TokenSource.Token(); err != nil {
+		var retrieveError *oauth2.RetrieveError
+		if !errors.As(err, &retrieveError) {
+			return fmt.Errorf("failed to request a GCP access token: %w", err)
+		}
+		code := retrieveError.ErrorCode
+		if code == "" {
+			// the error is converted from the auth library, which keeps the response body only
+			var body struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(retrieveError.Body, &body) == nil && body.Error != "" {
+				code = body.Error
+			} else {
+				code = "Unknown"
+			}
+		}
+		return &CredentialsError{Provider: "gcp", Code: code, Err: err}
+	}
+	return nil
+}
+
+// CheckAzureCredentials requests the storage account information with a storage account key, or a management token
+// for a service principal secret or a workload identity, which reads its token from tokenFilePath
+func CheckAzureCredentials(ctx context.Context, creds AzureCredentials, tokenFilePath string) error {
+	if creds.StorageAccountKey != "" {
+		sharedKeyCred, err := azblob.NewSharedKeyCredential(creds.StorageAccountName, creds.StorageAccountKey)
+		if err != nil {
+			return &CredentialsError{Provider: "azure", Code: "InvalidCredentials", Err: err}
+		}
+		serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", creds.StorageAccountName)
+		client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, sharedKeyCred, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create azure client: %w", err)
+		}
+		if _, err := client.ServiceClient().GetAccountInfo(ctx, nil); err != nil {
+			var responseError *azcore.ResponseError
+			if !errors.As(err, &responseError) {
+				return fmt.Errorf("failed to reach the Azure storage account: %w", err)
+			}
+			code := responseError.ErrorCode
+			if code == "" {
+				code = "Unknown"
+			}
+			return &CredentialsError{Provider: "azure", Code: code, Err: err}
+		}
+		return nil
+	}
+
+	var tokenCred azcore.TokenCredential
+	var err error
+	switch {
+	case creds.ClientSecret != "":
+		tokenCred, err = azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret, nil)
+	case creds.CertificatePath != "":
+		// the certificate path refers to the Velero pod file system
+		return ErrCredentialsCheckUnsupported
+	default:
+		tokenCred, err = azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientID:      creds.ClientID,
+			TenantID:      creds.TenantID,
+			TokenFilePath: tokenFilePath,
+		})
+	}
+	if err != nil {
+		return &CredentialsError{Provider: "azure", Code: "InvalidCredentials", Err: err}
+	}
+	if _, err := tokenCred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{azureManagementScope}}); err != nil {
+		var authenticationFailedError *azidentity.AuthenticationFailedError
+		if !errors.As(err, &authenticationFailedError) {
+			return fmt.Errorf("failed to request an Azure access token: %w", err)
+		}
+		code := azureErrorCode.FindString(err.Error())
+		if code == "" {
+			code = "Unknown"
+		}
+		return &CredentialsError{Provider: "azure", Code: code, Err: err}
+	}
+	return nil
+}
+
+// ParseAzureCredentialsFile parses the KEY=VALUE lines of an Azure credentials file
+func ParseAzureCredentialsFile(data []byte) AzureCredentials {
+	values := map[string][]byte{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+			values[strings.TrimSpace(key)] = []byte(strings.Trim(strings.TrimSpace(value), `"'`))
+		}
+	}
+	return ParseAzureCredentials(values)
+}
@@ -0,0 +1,152 @@
+package cloudprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+func TestCheckGCPCredentials(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tests := []struct {
+		name       string
+		status     int
+		response   string
+		wantErr    bool
+		wantCode   string
+		wantReject bool
+	}{
+		{
+			name:     "token granted",
+			status:   http.StatusOK,
+			response: `{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`,
+		},
+		{
+			name:       "key rejected",
+			status:     http.StatusBadRequest,
+			response:   `{"error": "invalid_grant", "error_description": "Invalid JWT Signature."}`,
+			wantErr:    true,
+			wantCode:   "invalid_grant",
+			wantReject: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+			credentialsJSON, _ := json.Marshal(map[string]string{
+				"type":         "service_account",
+				"project_id":   "test-project",
+				"private_key":  string(privateKey),
+				"client_email": "velero@test-project.iam.gserviceaccount.com",
+				"token_uri":    server.URL,
+			})
+
+			err := CheckGCPCredentials(context.Background(), credentialsJSON)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckGCPCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var credentialsError *CredentialsError
+			if errors.As(err, &credentialsError) != tt.wantReject {
+				t.Fatalf("expected a CredentialsError %v, got %v", tt.wantReject, err)
+			}
+			if tt.wantReject && credentialsError.Code != tt.wantCode {
+				t.Errorf("expected error code %s, got %s", tt.wantCode, credentialsError.Code)
+			}
+		})
+	}
+}
+
+func TestCheckAWSCredentials(t *testing.T) {
+	// the operator pod credentials must not be checked instead of the location secret
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAOPERATOR")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "operator-secret")
+	t.Setenv("AWS_SESSION_TOKEN", "operator-token")
+	accessKeys := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, credential, _ := strings.Cut(r.Header.Get("Authorization"), "Credential=")
+		accessKey, _, _ := strings.Cut(credential, "/")
+		accessKeys = append(accessKeys, accessKey)
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<GetCallerIdentityResponse><GetCallerIdentityResult><Arn>arn:aws:iam::123456789012:user/velero</Arn></GetCallerIdentityResult></GetCallerIdentityResponse>`))
+	}))
+	defer server.Close()
+	awsSTSEndpoint = server.URL
+	defer func() { awsSTSEndpoint = "" }()
+
+	credentialsFile := []byte("[default]\naws_access_key_id = AKIADEFAULT\naws_secret_access_key = secret\n\n[backup]\naws_access_key_id = AKIABACKUP\naws_secret_access_key = secret\n")
+	for _, profile := range []string{"", "backup"} {
+		if err := CheckAWSCredentials(context.Background(), credentialsFile, profile, "us-east-1"); err != nil {
+			t.Errorf("CheckAWSCredentials() with profile %q error = %v", profile, err)
+		}
+	}
+	want := []string{"AKIADEFAULT", "AKIABACKUP"}
+	if strings.Join(accessKeys, ",") != strings.Join(want, ",") {
+		t.Errorf("expected STS calls with access keys %v, got %v", want, accessKeys)
+	}
+}
+
+func TestAWSCredentialsError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantReject bool
+	}{
+		{
+			name:       "rejected access key",
+			err:        awserr.New("InvalidClientTokenId", "The security token included in the request is invalid.", nil),
+			wantCode:   "InvalidClientTokenId",
+			wantReject: true,
+		},
+		{
+			name:       "web identity error wraps the STS error",
+			err:        awserr.New("WebIdentityErr", "failed to retrieve credentials", awserr.New("InvalidIdentityToken", "No OpenIDConnect provider found", nil)),
+			wantCode:   "InvalidIdentityToken",
+			wantReject: true,
+		},
+		{
+			name: "STS not reachable",
+			err:  awserr.New(request.ErrCodeRequestError, "send request failed", errors.New("dial tcp: i/o timeout")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := awsCredentialsError(tt.err)
+			var credentialsError *CredentialsError
+			if errors.As(err, &credentialsError) != tt.wantReject {
+				t.Fatalf("expected a CredentialsError %v, got %v", tt.wantReject, err)
+			}
+			if tt.wantReject && credentialsError.Code != tt.wantCode {
+				t.Errorf("expected error code %s, got %s", tt.wantCode, credentialsError.Code)
+			}
+		})
+	}
+}
+
+func TestParseAzureCredentialsFile(t *testing.T) {
+	creds := ParseAzureCredentialsFile([]byte("AZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=\"client\"\n\nAZURE_FEDERATED_TOKEN_FILE=/var/run/secrets/token\n"))
+	if creds.SubscriptionID != "sub" || creds.TenantID != "tenant" || creds.ClientID != "client" || creds.FederatedTokenFile != "/var/run/secrets/token" {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+}
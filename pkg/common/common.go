@@ -26,6 +26,7 @@ const (
 	NodeAgentConfigMapPrefix   = "node-agent-"
 	BackupRepoConfigMapPrefix  = "backup-repository-"
 	RepoMaintConfigMapPrefix   = "repository-maintenance-"
+	TrustedCABundleCMPrefix    = "trusted-ca-bundle-"
 )
 
 var DefaultRestoreResourcePriorities = types.Priorities{
@@ -0,0 +1,91 @@
+// Package cosign verifies cosign image signatures offline, from the simple signing payload and signature printed by
+// cosign download signature and the public key of the signer, without access to the registry or the transparency log.
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// simpleSigningType is the type of the cosign simple signing payload
+const simpleSigningType = "cosign container image signature"
+
+// simpleSigningPayload is the payload signed by cosign sign
+type simpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// VerifySignature verifies that the base64 encoded signature of the base64 encoded payload is valid for the PEM
+// encoded public key, and that the payload signs the image digest of the repository
+func VerifySignature(publicKeyPEM, payloadBase64, signatureBase64, repository, digest string) error {
+	if payloadBase64 == "" || signatureBase64 == "" {
+		return errors.New("image is not signed, payload and signature are required")
+	}
+	payload, err := base64.StdEncoding.DecodeString(payloadBase64)
+	if err != nil {
+		return fmt.Errorf("payload is not base64 encoded: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return fmt.Errorf("signature is not base64 encoded: %w", err)
+	}
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return errors.New("public key is not PEM encoded")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	hash := sha256.Sum256(payload)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, hash[:], signature) {
+			return errors.New("signature does not match the public key")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+			return errors.New("signature does not match the public key")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, signature) {
+			return errors.New("signature does not match the public key")
+		}
+	default:
+		return fmt.Errorf("public key type %T is not supported", publicKey)
+	}
+
+	// the payload is trusted once the signature is verified
+	var signed simpleSigningPayload
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("failed to parse payload: %w", err)
+	}
+	if signed.Critical.Type != simpleSigningType {
+		return fmt.Errorf("payload type %q is not a %s", signed.Critical.Type, simpleSigningType)
+	}
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signature is for digest %s, not %s", signed.Critical.Image.DockerManifestDigest, digest)
+	}
+	if signed.Critical.Identity.DockerReference != repository {
+		return fmt.Errorf("signature is for image %s, not %s", signed.Critical.Identity.DockerReference, repository)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+)
+
+const (
+	testRepository = "quay.io/example/velero-plugin"
+	testDigest     = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func testPayload(repository, digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, repository, digest))
+}
+
+func encodePublicKey(t *testing.T, publicKey interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifySignature(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ed25519PublicKey, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signECDSA := func(payload []byte) string {
+		hash := sha256.Sum256(payload)
+		signature, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, hash[:])
+		if err != nil {
+			t.Fatalf("failed to sign payload: %v", err)
+		}
+		return base64.StdEncoding.EncodeToString(signature)
+	}
+	payload := testPayload(testRepository, testDigest)
+	otherPayload := testPayload(testRepository, "sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210")
+
+	tests := []struct {
+		name      string
+		publicKey string
+		payload   []byte
+		signature string
+		wantErr   string
+	}{
+		{
+			name:      "ecdsa signature",
+			publicKey: encodePublicKey(t, &ecdsaKey.PublicKey),
+			payload:   payload,
+			signature: signECDSA(payload),
+		},
+		{
+			name:      "ed25519 signature",
+			publicKey: encodePublicKey(t, ed25519PublicKey),
+			payload:   payload,
+			signature: base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519Key, payload)),
+		},
+		{
+			name:      "unsigned",
+			publicKey: encodePublicKey(t, &ecdsaKey.PublicKey),
+			wantErr:   "image is not signed, payload and signature are required",
+		},
+		{
+			name:      "signed by another key",
+			publicKey: encodePublicKey(t, &otherKey.PublicKey),
+			payload:   payload,
+			signature: signECDSA(payload),
+			wantErr:   "signature does not match the public key",
+		},
+		{
+			name:      "signature of another digest",
+			publicKey: encodePublicKey(t, &ecdsaKey.PublicKey),
+			payload:   otherPayload,
+			signature: signECDSA(otherPayload),
+			wantErr:   "signature is for digest sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210, not " + testDigest,
+		},
+		{
+			name:      "public key not PEM encoded",
+			publicKey: "not a key",
+			payload:   payload,
+			signature: signECDSA(payload),
+			wantErr:   "public key is not PEM encoded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadBase64 := ""
+			if tt.payload != nil {
+				payloadBase64 = base64.StdEncoding.EncodeToString(tt.payload)
+			}
+			err := VerifySignature(tt.publicKey, payloadBase64, tt.signature, testRepository, testDigest)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("VerifySignature() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package credentials
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+)
 
 type gcpCredAccountKeys string
 
@@ -15,8 +18,11 @@ func getGCPSecretAccountTypeKey(secretByte []byte) (gcpCredAccountKeys, error) {
 	if err := json.Unmarshal(secretByte, &f); err != nil {
 		return "", err
 	}
-	// following will panic if cannot cast to credAccountKeys
-	return gcpCredAccountKeys(f["type"].(string)), nil
+	accountType, ok := f["type"].(string)
+	if !ok {
+		return "", errors.New(`credentials JSON has no string "type" field`)
+	}
+	return gcpCredAccountKeys(accountType), nil
 }
 
 func gcpSecretAccountTypeIsShortLived(secretName, secretKey, namespace string) (bool, error) {
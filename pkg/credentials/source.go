@@ -0,0 +1,174 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// CredentialSourceMountPath is the directory the credential sources are mounted under in the Velero and node-agent containers
+	CredentialSourceMountPath = "/credential-sources"
+	// SecretsStoreCSIDriver is the name of the Secrets Store CSI driver
+	SecretsStoreCSIDriver = "secrets-store.csi.k8s.io"
+	// FileCredentialSourcePathPrefix is the node directory file credential sources must be under, so a DPA
+	// cannot mount arbitrary node files into the Velero and node-agent pods
+	FileCredentialSourcePathPrefix = "/var/run/oadp/credentials"
+
+	CredentialSourceTypeSecretProviderClass = "SecretProviderClass"
+	CredentialSourceTypeFile                = "File"
+
+	backupLocationPrefix   = "bsl"
+	snapshotLocationPrefix = "vsl"
+
+	// maxVolumeNameLength is the length limit of pod volume names, which are DNS labels
+	maxVolumeNameLength = 63
+	// volumeNameHashLength is the length of the hash suffix of sanitized volume names
+	volumeNameHashLength = 8
+)
+
+// volume names are DNS labels, while location names may be longer and contain dots
+var invalidVolumeNameCharacters = regexp.MustCompile(`[^a-z0-9-]`)
+
+// CredentialSourceMount is a credential source of a location, mounted into the Velero and node-agent containers
+type CredentialSourceMount struct {
+	// Kind is BackupStorageLocation or VolumeSnapshotLocation
+	Kind     string
+	Location string
+	Source   *oadpv1alpha1.CredentialSource
+	// prefix keeps the volumes of a BSL and a VSL with the same name apart
+	prefix string
+}
+
+// NewBackupLocationCredentialSourceMount returns the credential source mount of the named BSL
+func NewBackupLocationCredentialSourceMount(location string, source *oadpv1alpha1.CredentialSource) CredentialSourceMount {
+	return CredentialSourceMount{Kind: "BackupStorageLocation", Location: location, Source: source, prefix: backupLocationPrefix}
+}
+
+// NewSnapshotLocationCredentialSourceMount returns the credential source mount of the named VSL
+func NewSnapshotLocationCredentialSourceMount(location string, source *oadpv1alpha1.CredentialSource) CredentialSourceMount {
+	return CredentialSourceMount{Kind: "VolumeSnapshotLocation", Location: location, Source: source, prefix: snapshotLocationPrefix}
+}
+
+// VolumeName returns the name of the pod volume of the credential source
+func (m CredentialSourceMount) VolumeName() string {
+	return sanitizeVolumeName(fmt.Sprintf("%s-credentials-%s", m.prefix, m.Location))
+}
+
+// sanitizeVolumeName returns name as a DNS label. BSL and VSL names may contain dots and be up to 253 characters, so
+// they are lower-cased, invalid characters are replaced by dashes and the name is truncated. A hash of the original
+// name is appended when it is changed, so names differing only by replaced or truncated characters stay apart.
+func sanitizeVolumeName(name string) string {
+	sanitized := invalidVolumeNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+	if sanitized == name && len(name) <= maxVolumeNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:volumeNameHashLength]
+	if len(sanitized) > maxVolumeNameLength-volumeNameHashLength-1 {
+		sanitized = sanitized[:maxVolumeNameLength-volumeNameHashLength-1]
+	}
+	return strings.TrimRight(sanitized, "-") + "-" + hash
+}
+
+// MountPath returns the directory the credential source is mounted to
+func (m CredentialSourceMount) MountPath() string {
+	return path.Join(CredentialSourceMountPath, m.prefix, m.Location)
+}
+
+// FilePath returns the path of the credentials file, passed to the plugin with the credentialsFile config key
+func (m CredentialSourceMount) FilePath() string {
+	return path.Join(m.MountPath(), GetCredentialSourceFileName(m.Source))
+}
+
+// Type returns SecretProviderClass or File
+func (m CredentialSourceMount) Type() string {
+	if m.Source.SecretProviderClass != nil {
+		return CredentialSourceTypeSecretProviderClass
+	}
+	return CredentialSourceTypeFile
+}
+
+// Volume returns the pod volume of the credential source
+func (m CredentialSourceMount) Volume() corev1.Volume {
+	volume := corev1.Volume{Name: m.VolumeName()}
+	if m.Source.SecretProviderClass != nil {
+		volume.VolumeSource.CSI = &corev1.CSIVolumeSource{
+			Driver:   SecretsStoreCSIDriver,
+			ReadOnly: ptr.To(true),
+			VolumeAttributes: map[string]string{
+				"secretProviderClass": m.Source.SecretProviderClass.Name,
+			},
+		}
+	} else if m.Source.File != nil {
+		volume.VolumeSource.HostPath = &corev1.HostPathVolumeSource{
+			Path: m.Source.File.Path,
+			Type: ptr.To(corev1.HostPathDirectory),
+		}
+	}
+	return volume
+}
+
+// GetCredentialSourceFileName returns the name of the credentials file of the credential source
+func GetCredentialSourceFileName(source *oadpv1alpha1.CredentialSource) string {
+	if source.SecretProviderClass != nil {
+		return source.SecretProviderClass.FileName
+	}
+	if source.File != nil {
+		return source.File.FileName
+	}
+	return ""
+}
+
+// GetCredentialSourceMounts returns the credential sources of the DPA backup and snapshot locations.
+// Location names follow the names given to the BSLs and VSLs created by the operator.
+func GetCredentialSourceMounts(dpa *oadpv1alpha1.DataProtectionApplication) []CredentialSourceMount {
+	mounts := []CredentialSourceMount{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.CredentialSource == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s-%d", dpa.Name, i+1)
+		if bslSpec.Name != "" {
+			name = bslSpec.Name
+		}
+		mounts = append(mounts, NewBackupLocationCredentialSourceMount(name, bslSpec.CredentialSource))
+	}
+	for i, vslSpec := range dpa.Spec.SnapshotLocations {
+		if vslSpec.CredentialSource == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s-%d", dpa.Name, i+1)
+		if vslSpec.Name != "" {
+			name = vslSpec.Name
+		}
+		mounts = append(mounts, NewSnapshotLocationCredentialSourceMount(name, vslSpec.CredentialSource))
+	}
+	return mounts
+}
+
+// AppendCredentialSourceVolumes mounts the credential sources of the DPA locations into the named container
+func AppendCredentialSourceVolumes(dpa *oadpv1alpha1.DataProtectionApplication, podTemplate *corev1.PodTemplateSpec, containerName string) {
+	var container *corev1.Container
+	for i := range podTemplate.Spec.Containers {
+		if podTemplate.Spec.Containers[i].Name == containerName {
+			container = &podTemplate.Spec.Containers[i]
+		}
+	}
+	for _, mount := range GetCredentialSourceMounts(dpa) {
+		podTemplate.Spec.Volumes = append(podTemplate.Spec.Volumes, mount.Volume())
+		if container != nil {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      mount.VolumeName(),
+				MountPath: mount.MountPath(),
+				ReadOnly:  true,
+			})
+		}
+	}
+}
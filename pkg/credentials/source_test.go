@@ -0,0 +1,96 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestCredentials_AppendCredentialSourceVolumes(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{},
+				{
+					CredentialSource: &oadpv1alpha1.CredentialSource{
+						SecretProviderClass: &oadpv1alpha1.SecretProviderClassCredentialSource{Name: "vault-aws", FileName: "cloud"},
+					},
+				},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+				{
+					Name: "test-dpa-2",
+					CredentialSource: &oadpv1alpha1.CredentialSource{
+						File: &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials/vault", FileName: "cloud"},
+					},
+				},
+			},
+		},
+	}
+	podTemplate := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "velero"}, {Name: "sidecar"}}},
+	}
+
+	AppendCredentialSourceVolumes(dpa, podTemplate, "velero")
+
+	volumes := podTemplate.Spec.Volumes
+	if len(volumes) != 2 {
+		t.Fatalf("expected 2 credential source volumes, got %v", volumes)
+	}
+	if volumes[0].Name != "bsl-credentials-test-dpa-2" || volumes[0].CSI == nil || volumes[0].CSI.Driver != SecretsStoreCSIDriver ||
+		volumes[0].CSI.VolumeAttributes["secretProviderClass"] != "vault-aws" {
+		t.Errorf("unexpected secretProviderClass volume %v", volumes[0])
+	}
+	// a BSL and a VSL with the same name get distinct volumes
+	if volumes[1].Name != "vsl-credentials-test-dpa-2" || volumes[1].HostPath == nil || volumes[1].HostPath.Path != "/var/run/oadp/credentials/vault" {
+		t.Errorf("unexpected file volume %v", volumes[1])
+	}
+	volumeMounts := podTemplate.Spec.Containers[0].VolumeMounts
+	if len(volumeMounts) != 2 || volumeMounts[0].MountPath != "/credential-sources/bsl/test-dpa-2" || !volumeMounts[0].ReadOnly {
+		t.Errorf("unexpected volume mounts %v", volumeMounts)
+	}
+	if len(podTemplate.Spec.Containers[1].VolumeMounts) != 0 {
+		t.Errorf("expected credential sources to be mounted only into the velero container")
+	}
+}
+
+func TestCredentials_CredentialSourceMountVolumeName(t *testing.T) {
+	source := &oadpv1alpha1.CredentialSource{File: &oadpv1alpha1.FileCredentialSource{Path: "/var/run/oadp/credentials/vault", FileName: "cloud"}}
+	longName := strings.Repeat("long-location-name", 14)
+	tests := []struct {
+		name     string
+		location string
+		want     string
+	}{
+		{name: "valid name is kept", location: "test-dpa-1", want: "bsl-credentials-test-dpa-1"},
+		{name: "dotted name", location: "aws.us-east-1"},
+		{name: "long name", location: longName},
+		{name: "long dotted name", location: "a." + longName},
+	}
+	names := map[string]string{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewBackupLocationCredentialSourceMount(tt.location, source).VolumeName()
+			if tt.want != "" && got != tt.want {
+				t.Errorf("expected volume name %s, got %s", tt.want, got)
+			}
+			if errs := validation.IsDNS1123Label(got); len(errs) != 0 {
+				t.Errorf("volume name %s of location %s is not a DNS label: %v", got, tt.location, errs)
+			}
+			if other, found := names[got]; found {
+				t.Errorf("locations %s and %s share volume name %s", other, tt.location, got)
+			}
+			names[got] = tt.location
+		})
+	}
+	// names differing only by replaced characters get distinct volumes
+	if NewBackupLocationCredentialSourceMount("aws.east", source).VolumeName() == NewBackupLocationCredentialSourceMount("aws-east", source).VolumeName() {
+		t.Errorf("expected distinct volume names for aws.east and aws-east")
+	}
+}
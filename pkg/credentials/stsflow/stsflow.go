@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -57,6 +58,10 @@ const (
 	VeleroAWSSecretName   = "cloud-credentials"
 	VeleroAzureSecretName = "cloud-credentials-azure"
 	VeleroGCPSecretName   = "cloud-credentials-gcp"
+
+	// STSSecretTypeLabel marks the secrets created by the standardized flow
+	STSSecretTypeLabel = "oadp.openshift.io/secret-type"
+	STSSecretTypeValue = "sts-credentials"
 )
 
 // STSStandardizedFlow creates secrets for Short Term Service Account Tokens from environment variables for
@@ -118,20 +123,45 @@ func STSStandardizedFlow() (string, error) {
 	return "", nil
 }
 
-func CreateOrUpdateSTSAWSSecret(setupLog logr.Logger, roleARN string, secretNS string, kubeconf *rest.Config) error {
+// GetSTSSecretDataFromEnv returns the name and content of the secret the standardized flow creates from the operator
+// environment variables. Returns "", nil if no STS environment variables are provided.
+func GetSTSSecretDataFromEnv() (string, map[string]string) {
+	roleARN := os.Getenv(RoleARNEnvKey)
+	serviceAccountEmail := os.Getenv(ServiceAccountEmailEnvKey)
+	projectNumber := os.Getenv(ProjectNumberEnvKey)
+	poolId := os.Getenv(PoolIDEnvKey)
+	providerId := os.Getenv(ProviderId)
+	clientID := os.Getenv(ClientIDEnvKey)
+	tenantID := os.Getenv(TenantIDEnvKey)
+	subscriptionID := os.Getenv(SubscriptionIDEnvKey)
+
+	switch {
+	case len(roleARN) > 0:
+		return VeleroAWSSecretName, AWSSecretData(roleARN)
+	case len(serviceAccountEmail) > 0 && len(projectNumber) > 0 && len(poolId) > 0 && len(providerId) > 0:
+		return VeleroGCPSecretName, GCPSecretData(serviceAccountEmail, projectNumber, poolId, providerId)
+	case len(clientID) > 0 && len(tenantID) > 0 && len(subscriptionID) > 0:
+		return VeleroAzureSecretName, AzureSecretData(clientID, tenantID, subscriptionID)
+	}
+	return "", nil
+}
+
+// AWSSecretData returns the AWS STS credentials secret content for a role ARN
+func AWSSecretData(roleARN string) map[string]string {
 	// AWS STS credentials format
-	return CreateOrUpdateSTSSecret(setupLog, VeleroAWSSecretName, map[string]string{
+	return map[string]string{
 		"credentials": fmt.Sprintf(`[default]
 sts_regional_endpoints = regional
 role_arn = %s
 web_identity_token_file = %s`, roleARN, WebIdentityTokenPath),
-	}, secretNS, kubeconf)
+	}
 }
 
-func CreateOrUpdateSTSGCPSecret(setupLog logr.Logger, serviceAccountEmail, projectNumber, poolId, providerId, secretNS string, kubeconf *rest.Config) error {
+// GCPSecretData returns the GCP Workload Identity Federation credentials secret content
+func GCPSecretData(serviceAccountEmail, projectNumber, poolId, providerId string) map[string]string {
 	audience := fmt.Sprintf("//iam.googleapis.com/projects/%s/locations/global/workloadIdentityPools/%s/providers/%s", projectNumber, poolId, providerId)
 	// GCP external account credentials format for Workload Identity Federation
-	return CreateOrUpdateSTSSecret(setupLog, VeleroGCPSecretName, map[string]string{
+	return map[string]string{
 		GcpSecretJSONKey: fmt.Sprintf(`{
 	"type": "external_account",
 	"audience": "%s",
@@ -145,7 +175,43 @@ func CreateOrUpdateSTSGCPSecret(setupLog logr.Logger, serviceAccountEmail, proje
 		}
 	}
 }`, audience, serviceAccountEmail, WebIdentityTokenPath),
-	}, secretNS, kubeconf)
+	}
+}
+
+// AzureSecretData returns the Azure federated identity credentials secret content
+func AzureSecretData(azureClientId, azureTenantId, azureSubscriptionId string) map[string]string {
+	// Azure federated identity credentials format
+	return map[string]string{
+		"azurekey": fmt.Sprintf(`
+AZURE_SUBSCRIPTION_ID=%s
+AZURE_TENANT_ID=%s
+AZURE_CLIENT_ID=%s
+AZURE_CLOUD_NAME=AzurePublicCloud
+`, azureSubscriptionId, azureTenantId, azureClientId),
+	}
+}
+
+// HasSTSSecretDrifted returns true when the secret no longer holds the content or label of the standardized flow.
+// The backup location reconcile appends the region and resource group to the credentials, so the content only has to
+// start with the expected value.
+func HasSTSSecretDrifted(secret *corev1.Secret, credStringData map[string]string) bool {
+	if secret.Labels[STSSecretTypeLabel] != STSSecretTypeValue {
+		return true
+	}
+	for key, value := range credStringData {
+		if !strings.HasPrefix(string(secret.Data[key]), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func CreateOrUpdateSTSAWSSecret(setupLog logr.Logger, roleARN string, secretNS string, kubeconf *rest.Config) error {
+	return CreateOrUpdateSTSSecret(setupLog, VeleroAWSSecretName, AWSSecretData(roleARN), secretNS, kubeconf)
+}
+
+func CreateOrUpdateSTSGCPSecret(setupLog logr.Logger, serviceAccountEmail, projectNumber, poolId, providerId, secretNS string, kubeconf *rest.Config) error {
+	return CreateOrUpdateSTSSecret(setupLog, VeleroGCPSecretName, GCPSecretData(serviceAccountEmail, projectNumber, poolId, providerId), secretNS, kubeconf)
 }
 
 func CreateOrUpdateSTSAzureSecret(setupLog logr.Logger, azureClientId, azureTenantId, azureSubscriptionId, secretNS string, kubeconf *rest.Config) error {
@@ -167,14 +233,7 @@ func CreateOrUpdateSTSAzureSecret(setupLog logr.Logger, azureClientId, azureTena
 
 // CreateOrUpdateSTSAzureSecretWithClients is a testable version that accepts injected clients
 func CreateOrUpdateSTSAzureSecretWithClients(setupLog logr.Logger, azureClientId, azureTenantId, azureSubscriptionId, secretNS string, clientInstance client.Client, clientset kubernetes.Interface) error {
-	// Azure federated identity credentials format
-	err := CreateOrUpdateSTSSecretWithClients(setupLog, VeleroAzureSecretName, map[string]string{
-		"azurekey": fmt.Sprintf(`
-AZURE_SUBSCRIPTION_ID=%s
-AZURE_TENANT_ID=%s
-AZURE_CLIENT_ID=%s
-AZURE_CLOUD_NAME=AzurePublicCloud
-`, azureSubscriptionId, azureTenantId, azureClientId)}, secretNS, clientInstance, clientset)
+	err := CreateOrUpdateSTSSecretWithClients(setupLog, VeleroAzureSecretName, AzureSecretData(azureClientId, azureTenantId, azureSubscriptionId), secretNS, clientInstance, clientset)
 
 	if err != nil {
 		return err
@@ -220,7 +279,7 @@ func CreateOrUpdateSTSSecretWithClientsAndWait(setupLog logr.Logger, secretName
 			Name:      secretName,
 			Namespace: secretNS,
 			Labels: map[string]string{
-				"oadp.openshift.io/secret-type": "sts-credentials",
+				STSSecretTypeLabel: STSSecretTypeValue,
 			},
 		},
 		StringData: credStringData,
@@ -252,7 +311,7 @@ func CreateOrUpdateSTSSecretWithClientsAndWait(setupLog logr.Logger, secretName
 			if updatedFromCluster.Labels == nil {
 				updatedFromCluster.Labels = make(map[string]string)
 			}
-			updatedFromCluster.Labels["oadp.openshift.io/secret-type"] = "sts-credentials"
+			updatedFromCluster.Labels[STSSecretTypeLabel] = STSSecretTypeValue
 			if err := clientInstance.Patch(context.Background(), updatedFromCluster, client.MergeFrom(&fromCluster)); err != nil {
 				setupLog.Error(err, fmt.Sprintf("unable to update secret resource: %v", err))
 				return err
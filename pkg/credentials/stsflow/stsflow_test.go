@@ -659,3 +659,39 @@ AZURE_CLOUD_NAME=AzurePublicCloud
 		assert.NoError(t, err)
 	})
 }
+
+func TestHasSTSSecretDrifted(t *testing.T) {
+	credStringData := AWSSecretData("arn:aws:iam::123456789012:role/velero")
+	labels := map[string]string{STSSecretTypeLabel: STSSecretTypeValue}
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+		want   bool
+	}{
+		{
+			name:   "unchanged",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Data: map[string][]byte{"credentials": []byte(credStringData["credentials"])}},
+		},
+		{
+			name:   "region appended by the backup location reconcile",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Data: map[string][]byte{"credentials": []byte(credStringData["credentials"] + "\nregion = us-east-1\n")}},
+		},
+		{
+			name:   "role ARN edited",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Data: map[string][]byte{"credentials": []byte("[default]\nrole_arn = other\n")}},
+			want:   true,
+		},
+		{
+			name:   "label removed",
+			secret: &corev1.Secret{Data: map[string][]byte{"credentials": []byte(credStringData["credentials"])}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasSTSSecretDrifted(tt.secret, credStringData); got != tt.want {
+				t.Errorf("HasSTSSecretDrifted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package credentials
+
+import (
+	"strings"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// CredentialsType is the kind of secret a cloud credentials file holds
+type CredentialsType string
+
+const (
+	// CredentialsTypeStatic is a long-lived key: AWS access keys, GCP service account keys, Azure client secrets,
+	// client certificates and storage account keys
+	CredentialsTypeStatic CredentialsType = "static"
+	// CredentialsTypeShortLived is a federated identity exchanging the projected service account token for
+	// short-lived tokens: AWS web identity roles, GCP workload identity federation and Azure workload identity
+	CredentialsTypeShortLived CredentialsType = "short-lived"
+	// CredentialsTypeUnknown is a credentials file the type can not be read from
+	CredentialsTypeUnknown CredentialsType = "unknown"
+)
+
+// GetCredentialsType returns the type of the credentials file of a provider, reading the AWS profile of the location
+func GetCredentialsType(plugin oadpv1alpha1.DefaultPlugin, data []byte, profile string) CredentialsType {
+	switch plugin {
+	case oadpv1alpha1.DefaultPluginAWS:
+		keys := getAWSProfileKeys(data, profile)
+		switch {
+		case keys["web_identity_token_file"] != "":
+			return CredentialsTypeShortLived
+		case keys["aws_access_key_id"] != "":
+			return CredentialsTypeStatic
+		}
+	case oadpv1alpha1.DefaultPluginGCP:
+		switch accountType, _ := getGCPSecretAccountTypeKey(data); accountType {
+		case externalAccountKey:
+			return CredentialsTypeShortLived
+		case serviceAccountKey:
+			return CredentialsTypeStatic
+		}
+	case oadpv1alpha1.DefaultPluginMicrosoftAzure:
+		keys := map[string]string{}
+		for _, line := range strings.Split(string(data), "\n") {
+			if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+				keys[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+		switch {
+		case keys["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"] != "" || keys["AZURE_CLIENT_SECRET"] != "" || keys["AZURE_CLIENT_CERTIFICATE_PATH"] != "":
+			return CredentialsTypeStatic
+		case keys["AZURE_CLIENT_ID"] != "":
+			return CredentialsTypeShortLived
+		}
+	}
+	return CredentialsTypeUnknown
+}
+
+// getAWSProfileKeys returns the keys of a profile of an AWS shared credentials file, ValidateAWSCredentials reports
+// the syntax errors
+func getAWSProfileKeys(data []byte, profile string) map[string]string {
+	if profile == "" {
+		profile = "default"
+	}
+	keys := map[string]string{}
+	current := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			current = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.Trim(line, "[]")), "profile "))
+			continue
+		}
+		if key, value, found := strings.Cut(line, "="); found && current == profile {
+			keys[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return keys
+}
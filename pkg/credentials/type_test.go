@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"testing"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestCredentials_GetCredentialsType(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugin  oadpv1alpha1.DefaultPlugin
+		data    string
+		profile string
+		want    CredentialsType
+	}{
+		{
+			name:   "aws static keys",
+			plugin: oadpv1alpha1.DefaultPluginAWS,
+			data:   "[default]\naws_access_key_id = AKIA\naws_secret_access_key = secret\n",
+			want:   CredentialsTypeStatic,
+		},
+		{
+			name:    "aws web identity role in the location profile",
+			plugin:  oadpv1alpha1.DefaultPluginAWS,
+			data:    "[default]\naws_access_key_id = AKIA\naws_secret_access_key = secret\n[profile backup]\nrole_arn = arn:aws:iam::123456789012:role/velero\nweb_identity_token_file = /var/run/secrets/openshift/serviceaccount/token\n",
+			profile: "backup",
+			want:    CredentialsTypeShortLived,
+		},
+		{
+			name:   "gcp service account key",
+			plugin: oadpv1alpha1.DefaultPluginGCP,
+			data:   `{"type": "service_account", "private_key": "key"}`,
+			want:   CredentialsTypeStatic,
+		},
+		{
+			name:   "gcp workload identity federation",
+			plugin: oadpv1alpha1.DefaultPluginGCP,
+			data:   `{"type": "external_account", "audience": "audience"}`,
+			want:   CredentialsTypeShortLived,
+		},
+		{
+			name:   "azure client secret",
+			plugin: oadpv1alpha1.DefaultPluginMicrosoftAzure,
+			data:   "AZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n",
+			want:   CredentialsTypeStatic,
+		},
+		{
+			name:   "azure workload identity",
+			plugin: oadpv1alpha1.DefaultPluginMicrosoftAzure,
+			data:   "\nAZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\n",
+			want:   CredentialsTypeShortLived,
+		},
+		{
+			name:   "invalid gcp json",
+			plugin: oadpv1alpha1.DefaultPluginGCP,
+			data:   "not json",
+			want:   CredentialsTypeUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetCredentialsType(tt.plugin, []byte(tt.data), tt.profile); got != tt.want {
+				t.Errorf("GetCredentialsType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatError is a credentials file format error, located at a line of the file when Line is not 0
+type FormatError struct {
+	Line    int
+	Message string
+}
+
+func (e *FormatError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
+
+func formatErrorf(line int, format string, args ...interface{}) *FormatError {
+	return &FormatError{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// keyLine is a key of a credentials file with the line it is set on
+type keyLine struct {
+	value string
+	line  int
+	// nested is set for an AWS key without value followed by the indented keys of its sub-section, like s3
+	nested bool
+}
+
+// ValidateAWSCredentials validates the INI syntax of an AWS shared credentials file and that the named profile
+// holds static keys, a web identity role or a credential process
+func ValidateAWSCredentials(data []byte, profile string) error {
+	if profile == "" {
+		profile = "default"
+	}
+	profiles := map[string]map[string]*keyLine{}
+	profileLines := map[string]int{}
+	current := ""
+	// last is the previous key of the current profile. Lines indented below it continue its value or, when it has
+	// no value like "s3 =", are the keys of its nested sub-section.
+	var last *keyLine
+	lastIndent := 0
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNumber := i + 1
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if last != nil && indent > lastIndent {
+			if last.value == "" {
+				last.nested = true
+			}
+			continue
+		}
+		last = nil
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return formatErrorf(lineNumber, "profile header %q is missing the closing ]", line)
+			}
+			// the config file format prefixes profile names with "profile "
+			current = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.Trim(line, "[]")), "profile "))
+			if current == "" {
+				return formatErrorf(lineNumber, "profile header has no name")
+			}
+			if _, found := profiles[current]; found {
+				return formatErrorf(lineNumber, "profile %q is defined again, first defined on line %d", current, profileLines[current])
+			}
+			profiles[current] = map[string]*keyLine{}
+			profileLines[current] = lineNumber
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return formatErrorf(lineNumber, "expected key = value or a [profile] header")
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if key == "" {
+			return formatErrorf(lineNumber, "key is empty")
+		}
+		if current == "" {
+			return formatErrorf(lineNumber, "key %s is set before any [profile] header", key)
+		}
+		last = &keyLine{value: value, line: lineNumber}
+		lastIndent = indent
+		profiles[current][key] = last
+	}
+
+	keys, found := profiles[profile]
+	if !found {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return formatErrorf(0, "profile %q referenced by the location config is not defined, found profiles %v", profile, names)
+	}
+	// only the keys of the referenced profile are used, other profiles may be incomplete
+	var empty *keyLine
+	emptyKey := ""
+	for key, value := range keys {
+		if value.value == "" && !value.nested && (empty == nil || value.line < empty.line) {
+			empty, emptyKey = value, key
+		}
+	}
+	if empty != nil {
+		return formatErrorf(empty.line, "key %s of profile %q has no value", emptyKey, profile)
+	}
+	accessKey, hasAccessKey := keys["aws_access_key_id"]
+	secretKey, hasSecretKey := keys["aws_secret_access_key"]
+	roleArn, hasRoleArn := keys["role_arn"]
+	_, hasTokenFile := keys["web_identity_token_file"]
+	_, hasSourceProfile := keys["source_profile"]
+	_, hasCredentialSource := keys["credential_source"]
+	_, hasCredentialProcess := keys["credential_process"]
+	switch {
+	case hasAccessKey && !hasSecretKey:
+		return formatErrorf(accessKey.line, "profile %q sets aws_access_key_id without aws_secret_access_key", profile)
+	case hasSecretKey && !hasAccessKey:
+		return formatErrorf(secretKey.line, "profile %q sets aws_secret_access_key without aws_access_key_id", profile)
+	case hasAccessKey && hasSecretKey:
+		return nil
+	case hasRoleArn:
+		if !strings.HasPrefix(roleArn.value, "arn:") {
+			return formatErrorf(roleArn.line, "role_arn of profile %q is not an ARN", profile)
+		}
+		if !hasTokenFile && !hasSourceProfile && !hasCredentialSource {
+			return formatErrorf(roleArn.line, "profile %q sets role_arn without web_identity_token_file, source_profile or credential_source", profile)
+		}
+		return nil
+	case hasCredentialProcess:
+		return nil
+	}
+	return formatErrorf(profileLines[profile], "profile %q has no aws_access_key_id and aws_secret_access_key, role_arn or credential_process", profile)
+}
+
+// ValidateAzureCredentials validates the KEY=VALUE syntax of an Azure credentials file and that the keys required by
+// its authentication mode are set: storage account key, service principal with a client secret or certificate, or
+// workload identity
+func ValidateAzureCredentials(data []byte) error {
+	keys := map[string]keyLine{}
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNumber := i + 1
+		line = strings.TrimSpace(line)
+		// section headers such as [default] are ignored by the plugin
+		if line == "" || strings.HasPrefix(line, "#") || (strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return formatErrorf(lineNumber, "expected KEY=VALUE")
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return formatErrorf(lineNumber, "key is empty")
+		}
+		if strings.Trim(strings.TrimSpace(value), `"'`) == "" {
+			return formatErrorf(lineNumber, "key %s has no value", key)
+		}
+		keys[key] = keyLine{value: value, line: lineNumber}
+	}
+
+	requireKeys := func(mode string, modeKey string, required ...string) error {
+		for _, key := range required {
+			if _, found := keys[key]; found {
+				continue
+			}
+			if modeKey == "" {
+				return formatErrorf(0, "%s is missing for %s authentication, set AZURE_CLIENT_SECRET for a service principal or AZURE_STORAGE_ACCOUNT_ACCESS_KEY for a storage account key", key, mode)
+			}
+			return formatErrorf(keys[modeKey].line, "%s is set for %s authentication but %s is missing", modeKey, mode, key)
+		}
+		return nil
+	}
+	switch {
+	case has(keys, "AZURE_STORAGE_ACCOUNT_ACCESS_KEY"):
+		return nil
+	case has(keys, "AZURE_CLIENT_SECRET"):
+		return requireKeys("service principal", "AZURE_CLIENT_SECRET", "AZURE_CLIENT_ID", "AZURE_TENANT_ID", "AZURE_SUBSCRIPTION_ID")
+	case has(keys, "AZURE_CLIENT_CERTIFICATE_PATH"):
+		return requireKeys("service principal certificate", "AZURE_CLIENT_CERTIFICATE_PATH", "AZURE_CLIENT_ID", "AZURE_TENANT_ID", "AZURE_SUBSCRIPTION_ID")
+	}
+	// the federated token file is passed to the pods with the azure-workload-identity-env secret when not set here
+	return requireKeys("workload identity", "", "AZURE_CLIENT_ID", "AZURE_TENANT_ID", "AZURE_SUBSCRIPTION_ID")
+}
+
+func has(keys map[string]keyLine, key string) bool {
+	_, found := keys[key]
+	return found
+}
+
+// ValidateGCPCredentials validates the JSON syntax of a GCP credentials file and the fields required by its type,
+// service_account for a key file or external_account for workload identity federation
+func ValidateGCPCredentials(data []byte) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		var syntaxError *json.SyntaxError
+		if errors.As(err, &syntaxError) {
+			offset := min(int(syntaxError.Offset), len(data))
+			return formatErrorf(bytes.Count(data[:offset], []byte("\n"))+1, "invalid JSON: %v", err)
+		}
+		return formatErrorf(0, "invalid JSON: %v", err)
+	}
+	accountType, err := getGCPSecretAccountTypeKey(data)
+	if err != nil {
+		return formatErrorf(0, "%v", err)
+	}
+
+	var required []string
+	switch accountType {
+	case serviceAccountKey:
+		required = []string{"project_id", "private_key", "client_email"}
+	case externalAccountKey:
+		required = []string{"audience", "subject_token_type", "token_url", "credential_source"}
+	default:
+		return formatErrorf(lineOfJSONKey(data, "type"), "type %q is not supported, expected %s or %s", accountType, serviceAccountKey, externalAccountKey)
+	}
+	for _, field := range required {
+		if value, found := fields[field]; !found || value == "" || value == nil {
+			return formatErrorf(0, "%s credentials are missing the %q field", accountType, field)
+		}
+	}
+	return nil
+}
+
+// lineOfJSONKey returns the line of the first occurrence of the JSON key, or 0 when it is not found
+func lineOfJSONKey(data []byte, key string) int {
+	index := bytes.Index(data, []byte(`"`+key+`"`))
+	if index < 0 {
+		return 0
+	}
+	return bytes.Count(data[:index], []byte("\n")) + 1
+}
@@ -0,0 +1,184 @@
+package credentials
+
+import (
+	"testing"
+)
+
+func TestCredentials_ValidateAWSCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		profile string
+		wantErr string
+	}{
+		{
+			name: "static keys in default profile",
+			data: "[default]\naws_access_key_id = AKIA\naws_secret_access_key = secret\n",
+		},
+		{
+			name:    "static keys in named config file profile",
+			data:    "# backup credentials\n[profile backup]\naws_access_key_id=AKIA\naws_secret_access_key=secret",
+			profile: "backup",
+		},
+		{
+			name: "web identity role",
+			data: "[default]\nsts_regional_endpoints = regional\nrole_arn = arn:aws:iam::123456789012:role/velero\nweb_identity_token_file = /var/run/secrets/openshift/serviceaccount/token",
+		},
+		{
+			name:    "key before any profile",
+			data:    "aws_access_key_id = AKIA\n[default]\n",
+			wantErr: `line 1: key aws_access_key_id is set before any [profile] header`,
+		},
+		{
+			name:    "line without key value",
+			data:    "[default]\naws_access_key_id = AKIA\nnot a key\n",
+			wantErr: "line 3: expected key = value or a [profile] header",
+		},
+		{
+			name:    "unterminated profile header",
+			data:    "[default\naws_access_key_id = AKIA\n",
+			wantErr: `line 1: profile header "[default" is missing the closing ]`,
+		},
+		{
+			name:    "profile referenced by the location is missing",
+			data:    "[default]\naws_access_key_id = AKIA\naws_secret_access_key = secret\n",
+			profile: "backup",
+			wantErr: `profile "backup" referenced by the location config is not defined, found profiles [default]`,
+		},
+		{
+			name:    "access key without secret key",
+			data:    "[default]\n\naws_access_key_id = AKIA\n",
+			wantErr: `line 3: profile "default" sets aws_access_key_id without aws_secret_access_key`,
+		},
+		{
+			name:    "empty value",
+			data:    "[default]\naws_access_key_id = AKIA\naws_secret_access_key =\n",
+			wantErr: `line 3: key aws_secret_access_key of profile "default" has no value`,
+		},
+		{
+			name: "nested s3 sub-section",
+			data: "[default]\ns3 =\n  max_concurrent_requests = 20\n  addressing_style = path\naws_access_key_id = AKIA\naws_secret_access_key = secret\n",
+		},
+		{
+			name:    "empty value in another profile",
+			data:    "[default]\naws_access_key_id = AKIA\naws_secret_access_key = secret\n[profile unused]\nregion =\n",
+			profile: "default",
+		},
+		{
+			name:    "empty value followed by a key of the profile",
+			data:    "[default]\nregion =\naws_access_key_id = AKIA\naws_secret_access_key = secret\n",
+			wantErr: `line 2: key region of profile "default" has no value`,
+		},
+		{
+			name:    "role without token file",
+			data:    "[default]\nrole_arn = arn:aws:iam::123456789012:role/velero\n",
+			wantErr: `line 2: profile "default" sets role_arn without web_identity_token_file, source_profile or credential_source`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFormatError(t, ValidateAWSCredentials([]byte(tt.data), tt.profile), tt.wantErr)
+		})
+	}
+}
+
+func TestCredentials_ValidateAzureCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "service principal",
+			data: "AZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\nAZURE_RESOURCE_GROUP=rg\nAZURE_CLOUD_NAME=AzurePublicCloud\n",
+		},
+		{
+			name: "storage account key",
+			data: "AZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\nAZURE_CLOUD_NAME=AzurePublicCloud\n",
+		},
+		{
+			name: "workload identity",
+			data: "\nAZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLOUD_NAME=AzurePublicCloud\n",
+		},
+		{
+			name:    "service principal without tenant",
+			data:    "AZURE_SUBSCRIPTION_ID=sub\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n",
+			wantErr: "line 3: AZURE_CLIENT_SECRET is set for service principal authentication but AZURE_TENANT_ID is missing",
+		},
+		{
+			name:    "line without key value",
+			data:    "AZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID tenant\n",
+			wantErr: "line 2: expected KEY=VALUE",
+		},
+		{
+			name:    "empty value",
+			data:    "AZURE_SUBSCRIPTION_ID=\n",
+			wantErr: "line 1: key AZURE_SUBSCRIPTION_ID has no value",
+		},
+		{
+			name:    "no credentials",
+			data:    "AZURE_CLOUD_NAME=AzurePublicCloud\n",
+			wantErr: "AZURE_CLIENT_ID is missing for workload identity authentication, set AZURE_CLIENT_SECRET for a service principal or AZURE_STORAGE_ACCOUNT_ACCESS_KEY for a storage account key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFormatError(t, ValidateAzureCredentials([]byte(tt.data)), tt.wantErr)
+		})
+	}
+}
+
+func TestCredentials_ValidateGCPCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "service account key",
+			data: `{"type": "service_account", "project_id": "project", "private_key": "key", "client_email": "velero@project.iam.gserviceaccount.com"}`,
+		},
+		{
+			name: "external account",
+			data: `{"type": "external_account", "audience": "aud", "subject_token_type": "urn:ietf:params:oauth:token-type:jwt", "token_url": "https://sts.googleapis.com/v1/token", "credential_source": {"file": "/token"}}`,
+		},
+		{
+			name:    "invalid JSON",
+			data:    "{\n  \"type\": \"service_account\",\n  \"project_id\" \"project\"\n}",
+			wantErr: "line 3: invalid JSON: invalid character '\"' after object key",
+		},
+		{
+			name:    "missing type",
+			data:    `{"project_id": "project"}`,
+			wantErr: `credentials JSON has no string "type" field`,
+		},
+		{
+			name:    "unsupported type",
+			data:    "{\n  \"type\": \"authorized_user\"\n}",
+			wantErr: `line 2: type "authorized_user" is not supported, expected service_account or external_account`,
+		},
+		{
+			name:    "service account without private key",
+			data:    `{"type": "service_account", "project_id": "project", "client_email": "velero@project.iam.gserviceaccount.com"}`,
+			wantErr: `service_account credentials are missing the "private_key" field`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFormatError(t, ValidateGCPCredentials([]byte(tt.data)), tt.wantErr)
+		})
+	}
+}
+
+func assertFormatError(t *testing.T, err error, wantErr string) {
+	t.Helper()
+	if wantErr == "" {
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		return
+	}
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("expected error %q, got %v", wantErr, err)
+	}
+}